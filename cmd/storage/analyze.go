@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service/analyzer"
+	storage "radio-storage/internal/service/storage"
+)
+
+// runAnalyze implements "storage analyze" command,
+// backfilling analysis results over the existing archive.
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+
+	configPath := fs.String("config", os.Getenv("CONFIG_PATH"), "path to config file")
+	namespace := fs.String("namespace", models.DefaultNamespace, "namespace to work with")
+	stages := fs.String("stages", "", "comma separated stages to run, all if empty")
+	force := fs.Bool("force", false, "reanalyze files with up to date results")
+	fromID := fs.Int("from-id", 0, "lowest file id to analyze")
+	toID := fs.Int("to-id", 0, "highest file id to analyze, 0 for no limit")
+
+	fs.Parse(args)
+
+	opts := models.BackfillOptions{
+		Force:  *force,
+		FromID: *fromID,
+		ToID:   *toID,
+	}
+	if *stages != "" {
+		opts.Stages = strings.Split(*stages, ",")
+	}
+
+	cfg := mustLoadCommandConfig(*configPath)
+	log := setupCommandLogger(cfg.Env)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	dir, err := namespacePath(cfg, *namespace)
+	if err != nil {
+		return err
+	}
+
+	storageSrv := storage.New(
+		log,
+		dir,
+		cfg.Source.NestingDepth,
+		cfg.Source.IdLength,
+	)
+
+	a := analyzer.New(log, 0)
+	a.Register(analyzer.Stages(storageSrv)...)
+
+	stats, err := a.Backfill(ctx, storageSrv, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("analyzed: %d, skipped: %d, failed: %d\n", stats.Analyzed, stats.Skipped, stats.Failed)
+
+	return nil
+}
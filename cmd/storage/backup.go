@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"radio-storage/internal/config"
+	"radio-storage/internal/domain/models"
+	storage "radio-storage/internal/service/storage"
+)
+
+const (
+	compressZstd = "zstd"
+	compressNone = "none"
+)
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// runBackup implements "storage backup" command.
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+
+	configPath := fs.String("config", os.Getenv("CONFIG_PATH"), "path to config file")
+	namespace := fs.String("namespace", models.DefaultNamespace, "namespace to work with")
+	out := fs.String("out", "-", "output archive, \"-\" for stdout")
+	compress := fs.String("compress", compressZstd, "archive compression: zstd or none")
+	fromID := fs.Int("from-id", 0, "lowest file id to include")
+	toID := fs.Int("to-id", 0, "highest file id to include, 0 for no limit")
+	since := fs.String("since", "", "include only files changed since given RFC3339 time")
+
+	fs.Parse(args)
+
+	filter := models.BackupFilter{
+		FromID: *fromID,
+		ToID:   *toID,
+	}
+	if *since != "" {
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			return fmt.Errorf("invalid since: %w", err)
+		}
+		filter.Since = t
+	}
+
+	if *compress != compressZstd && *compress != compressNone {
+		return fmt.Errorf("unknown compression %q", *compress)
+	}
+
+	cfg := mustLoadCommandConfig(*configPath)
+	log := setupCommandLogger(cfg.Env)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	dir, err := namespacePath(cfg, *namespace)
+	if err != nil {
+		return err
+	}
+
+	storageSrv := storage.New(
+		log,
+		dir,
+		cfg.Source.NestingDepth,
+		cfg.Source.IdLength,
+	)
+
+	var w io.WriteCloser = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+	defer w.Close()
+
+	bw := bufio.NewWriter(w)
+
+	var archive io.Writer = bw
+	var enc *zstd.Encoder
+	if *compress == compressZstd {
+		var err error
+		enc, err = zstd.NewWriter(bw)
+		if err != nil {
+			return err
+		}
+		archive = enc
+	}
+
+	if _, err := storageSrv.Backup(ctx, archive, filter); err != nil {
+		return err
+	}
+
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// runRestore implements "storage restore" command.
+//
+// Like other offline commands, it is meant to run while server
+// is stopped, since server keeps indexes of stored files.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+
+	configPath := fs.String("config", os.Getenv("CONFIG_PATH"), "path to config file")
+	namespace := fs.String("namespace", models.DefaultNamespace, "namespace to work with")
+	in := fs.String("in", "-", "input archive, \"-\" for stdin")
+	conflict := fs.String("conflict", "skip", "policy for existing files: skip, overwrite or fail")
+
+	fs.Parse(args)
+
+	policy, err := storage.ParseConflictPolicy(*conflict)
+	if err != nil {
+		return err
+	}
+
+	cfg := mustLoadCommandConfig(*configPath)
+	log := setupCommandLogger(cfg.Env)
+
+	if err := checkWritable(cfg); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	dir, err := namespacePath(cfg, *namespace)
+	if err != nil {
+		return err
+	}
+
+	storageSrv := storage.New(
+		log,
+		dir,
+		cfg.Source.NestingDepth,
+		cfg.Source.IdLength,
+	)
+
+	var r io.ReadCloser = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	defer r.Close()
+
+	br := bufio.NewReader(r)
+
+	// Detect compression by magic bytes.
+	var archive io.Reader = br
+	if magic, err := br.Peek(len(zstdMagic)); err == nil && bytes.Equal(magic, zstdMagic) {
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer dec.Close()
+		archive = dec
+	}
+
+	_, err = storageSrv.Restore(ctx, archive, policy)
+
+	return err
+}
+
+// namespacePath returns root of namespace given to command.
+func namespacePath(cfg *config.Config, name string) (string, error) {
+	dir, ok := cfg.Source.NamespacePath(name)
+	if !ok {
+		return "", fmt.Errorf("unknown namespace %q", name)
+	}
+	return dir, nil
+}
+
+// checkWritable rejects offline changes of replicated storage.
+// They bypass replication log, so primary never passes them
+// to replicas, and replica diverges from its primary.
+func checkWritable(cfg *config.Config) error {
+	switch cfg.Replication.Role {
+	case config.RolePrimary:
+		return errors.New("storage is replication primary, offline changes would not reach replicas")
+	case config.RoleReplica:
+		return errors.New("storage is replication replica, it is changed by primary only")
+	}
+	return nil
+}
+
+func mustLoadCommandConfig(configPath string) *config.Config {
+	if configPath == "" {
+		panic("config path is empty")
+	}
+
+	return config.MustLoadPath(configPath)
+}
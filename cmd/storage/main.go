@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -18,7 +19,25 @@ const (
 	envProd  = "prod"
 )
 
+var commands = map[string]func(args []string) error{
+	"analyze": runAnalyze,
+	"backup":  runBackup,
+	"restore": runRestore,
+	"sync":    runSync,
+}
+
 func main() {
+	// Offline maintenance commands.
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	cfg := config.MustLoad()
 
 	log := setupLogger(cfg.Env, cfg.LogPath)
@@ -28,11 +47,8 @@ func main() {
 
 	application := app.New(
 		log,
-		cfg.GRPC.Port,
+		cfg,
 		getAllowedIPs(),
-		cfg.Source.SourcePath,
-		cfg.Source.NestingDepth,
-		cfg.Source.IdLength,
 	)
 
 	// Start app
@@ -57,7 +73,7 @@ func setupLogger(env, logPath string) *slog.Logger {
 
 	switch env {
 	case envLocal:
-		log = setupPrettySlog()
+		log = setupPrettySlog(os.Stdout)
 	case envProd:
 		var logWriter io.Writer
 
@@ -79,14 +95,27 @@ func setupLogger(env, logPath string) *slog.Logger {
 	return log
 }
 
-func setupPrettySlog() *slog.Logger {
+// setupCommandLogger sets up logger for maintenance commands.
+// Logs are written to stderr, since stdout may carry data.
+func setupCommandLogger(env string) *slog.Logger {
+	switch env {
+	case envLocal:
+		return setupPrettySlog(os.Stderr)
+	default:
+		return slog.New(
+			slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		)
+	}
+}
+
+func setupPrettySlog(out io.Writer) *slog.Logger {
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
 			Level: slog.LevelDebug,
 		},
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
+	handler := opts.NewPrettyHandler(out)
 
 	return slog.New(handler)
 }
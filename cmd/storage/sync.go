@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc/metadata"
+
+	"radio-storage/internal/domain/models"
+	storageGRPC "radio-storage/internal/grpc"
+	storage "radio-storage/internal/service/storage"
+	"radio-storage/internal/service/syncer"
+)
+
+// runSync implements "storage sync" command.
+//
+// Like other offline commands, it is meant to run while server
+// is stopped, since server keeps indexes of stored files.
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+
+	configPath := fs.String("config", os.Getenv("CONFIG_PATH"), "path to config file")
+	namespace := fs.String("namespace", models.DefaultNamespace, "namespace to work with")
+	peer := fs.String("peer", "", "peer storage gRPC address")
+	direction := fs.String("direction", "pull", "sync direction: pull, push or both")
+	mirror := fs.Bool("mirror", false, "delete files absent on the source side")
+	dryRun := fs.Bool("dry-run", false, "only report what would be transferred")
+
+	fs.Parse(args)
+
+	if *peer == "" {
+		return fmt.Errorf("peer is required")
+	}
+
+	opts := models.SyncOptions{
+		Mirror: *mirror,
+		DryRun: *dryRun,
+	}
+	switch *direction {
+	case "pull":
+		opts.Direction = models.SyncPull
+	case "push":
+		opts.Direction = models.SyncPush
+	case "both":
+		opts.Direction = models.SyncBoth
+	default:
+		return fmt.Errorf("unknown direction %q", *direction)
+	}
+
+	cfg := mustLoadCommandConfig(*configPath)
+	log := setupCommandLogger(cfg.Env)
+
+	// Push changes only the peer.
+	if opts.Direction != models.SyncPush && !opts.DryRun {
+		if err := checkWritable(cfg); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	dir, err := namespacePath(cfg, *namespace)
+	if err != nil {
+		return err
+	}
+
+	storageSrv := storage.New(
+		log,
+		dir,
+		cfg.Source.NestingDepth,
+		cfg.Source.IdLength,
+	)
+
+	// Peer is synchronized in the same namespace.
+	ctx = metadata.AppendToOutgoingContext(ctx, storageGRPC.NamespaceKey, *namespace)
+
+	stats, err := syncer.New(log, storageSrv).SyncPeer(ctx, *peer, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"pulled: %d, pushed: %d, deleted local: %d, deleted remote: %d, conflicts: %d, bytes: %d\n",
+		stats.Pulled, stats.Pushed, stats.DeletedLocal, stats.DeletedRemote, stats.Conflicts, stats.Bytes,
+	)
+
+	return nil
+}
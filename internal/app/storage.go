@@ -1,50 +1,357 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
+	"regexp"
+	"slices"
 
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 
+	"radio-storage/internal/config"
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/gateway"
 	storageGRPC "radio-storage/internal/grpc"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service/analyzer"
+	"radio-storage/internal/service/mount"
+	"radio-storage/internal/service/reaper"
+	"radio-storage/internal/service/replication"
 	storage "radio-storage/internal/service/storage"
+	"radio-storage/internal/service/syncer"
 )
 
+const (
+	replicationDir = ".replication"
+)
+
+// namespaceName starts with letter, so namespace
+// can not be mistaken for directory of the storage tree.
+var namespaceName = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
 	port       int
+
+	httpServer *http.Server
+
+	changes *replication.Log
+	primary *replication.Primary
+	replica *replication.Replica
+
+	analyzers []*analyzer.Analyzer
+	reapers   []*reaper.Reaper
+	mount     *mount.Mount
+
+	// ctx bounds background jobs, cancelled on stop.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func New(
 	log *slog.Logger,
-	port int,
+	cfg *config.Config,
 	allowedIPs []string,
-	storageDir string,
-	nestingDepth int,
-	idLength int,
 ) *App {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a := &App{
+		log:    log,
+		port:   cfg.GRPC.Port,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
 	gRPCServer := grpc.NewServer()
 
-	storageSrv := storage.New(
-		log,
-		storageDir,
-		nestingDepth,
-		idLength,
+	storageDir := cfg.Source.SourcePath
+
+	// Options shared by all namespaces.
+	var opts []storage.Option
+
+	opts = append(opts, storage.WithValidation(storage.ValidationPolicy{
+		MinSize:      cfg.Validation.MinSize,
+		MaxSize:      cfg.Validation.MaxSize,
+		MinSyncRatio: cfg.Validation.MinSyncRatio,
+		SampleRates:  cfg.Validation.SampleRates,
+		Bitrates:     cfg.Validation.Bitrates,
+		MaxDuration:  cfg.Validation.MaxDuration,
+		Quarantine:   cfg.Validation.Quarantine,
+	}))
+
+	// Quotas are shared by all namespaces.
+	quotas := storage.NewQuotas(quotaPolicy(cfg))
+	prometheus.MustRegister(quotas)
+
+	// Options of default namespace.
+	defaultOpts := slices.Clone(opts)
+	defaultOpts = append(defaultOpts,
+		storage.WithQuotas(quotas, models.DefaultNamespace),
+		storage.WithRetention(cfg.Source.Retention),
 	)
 
+	if len(cfg.Source.Formats) != 0 {
+		defaultOpts = append(defaultOpts, storage.WithFormats(mustParseFormats(cfg.Source.Formats)...))
+	}
+
+	switch cfg.Replication.Role {
+	case config.RoleStandalone, config.RoleReplica:
+	case config.RolePrimary:
+		changes, err := replication.OpenLog(storageDir + "/" + replicationDir + "/log")
+		if err != nil {
+			panic("failed to open replication log: " + err.Error())
+		}
+		a.changes = changes
+	default:
+		panic("unknown replication role: " + cfg.Replication.Role)
+	}
+
+	// replicationOpts returns options of namespace
+	// following replication role.
+	replicationOpts := func(namespace string) []storage.Option {
+		switch cfg.Replication.Role {
+		case config.RolePrimary:
+			journal, err := a.changes.Journal(namespace)
+			if err != nil {
+				panic("failed to register namespace in replication log: " + err.Error())
+			}
+			return []storage.Option{storage.WithJournal(journal)}
+		case config.RoleReplica:
+			return []storage.Option{storage.WithReadOnly()}
+		}
+		return nil
+	}
+	defaultOpts = append(defaultOpts, replicationOpts(models.DefaultNamespace)...)
+
+	storages := map[string]*storage.Storage{
+		models.DefaultNamespace: a.newStorage(log, cfg, models.DefaultNamespace, storageDir, defaultOpts),
+	}
+
+	for _, ns := range cfg.Source.Namespaces {
+		if !namespaceName.MatchString(ns.Name) || ns.Name == models.DefaultNamespace {
+			panic("invalid namespace name: " + ns.Name)
+		}
+		// Namespace is rooted next to service directories of default one.
+		if storage.ReservedName(ns.Name) {
+			panic("reserved namespace name: " + ns.Name)
+		}
+		if _, ok := storages[ns.Name]; ok {
+			panic("duplicate namespace: " + ns.Name)
+		}
+
+		formats := ns.Formats
+		if len(formats) == 0 {
+			formats = cfg.Source.Formats
+		}
+
+		nsOpts := slices.Clone(opts)
+		nsOpts = append(nsOpts,
+			storage.WithQuotas(quotas, ns.Name),
+			storage.WithRetention(ns.Retention),
+		)
+		if len(formats) != 0 {
+			nsOpts = append(nsOpts, storage.WithFormats(mustParseFormats(formats)...))
+		}
+		nsOpts = append(nsOpts, replicationOpts(ns.Name)...)
+
+		dir, _ := cfg.Source.NamespacePath(ns.Name)
+		storages[ns.Name] = a.newStorage(log, cfg, ns.Name, dir, nsOpts)
+	}
+
+	// Mount plays files of default namespace.
+	storageSrv := storages[models.DefaultNamespace]
+
+	files := make(storageGRPC.Namespaces[storageGRPC.Storage], len(storages))
+	admins := make(storageGRPC.Namespaces[storageGRPC.Admin], len(storages))
+	syncers := make(storageGRPC.Namespaces[storageGRPC.Syncer], len(storages))
+	media := make(storageGRPC.Namespaces[storageGRPC.Media], len(storages))
+	gatewayMedia := make(map[string]gateway.Media, len(storages))
+	replicated := make(map[string]replication.FileStorage, len(storages))
+	applied := make(map[string]replication.Applier, len(storages))
+	for name, s := range storages {
+		files[name] = s
+		admins[name] = s
+		syncers[name] = syncer.New(log.With(slog.String("namespace", name)), s)
+		media[name] = s
+		gatewayMedia[name] = s
+		replicated[name] = s
+		applied[name] = s
+	}
+
 	storageGRPC.Register(
 		gRPCServer,
-		storageSrv,
+		files,
+		allowedIPs,
+	)
+	storageGRPC.RegisterAdmin(
+		gRPCServer,
+		admins,
+		syncers,
+		quotas,
+		allowedIPs,
+	)
+	storageGRPC.RegisterMedia(
+		gRPCServer,
+		media,
 		allowedIPs,
 	)
 
-	return &App{
-		log:        log,
-		gRPCServer: gRPCServer,
-		port:       port,
+	switch cfg.Replication.Role {
+	case config.RolePrimary:
+		a.primary = replication.NewPrimary(log, a.changes, replicated)
+		if err := a.primary.Seed(context.Background()); err != nil {
+			panic("failed to seed replication log: " + err.Error())
+		}
+
+		storageGRPC.RegisterReplication(
+			gRPCServer,
+			a.primary,
+			allowedIPs,
+		)
+	case config.RoleReplica:
+		cc, err := grpc.NewClient(
+			cfg.Replication.PrimaryAddr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			panic("failed to connect to primary: " + err.Error())
+		}
+
+		a.replica, err = replication.NewReplica(
+			log,
+			cfg.Replication.Name,
+			ssov1.NewReplicationServiceClient(cc),
+			applied,
+			storageDir+"/"+replicationDir+"/position",
+		)
+		if err != nil {
+			panic("failed to init replica: " + err.Error())
+		}
+	}
+
+	if cfg.HTTP.Port != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		hlsIPs := allowedIPs
+		if cfg.HTTP.PublicHLS {
+			hlsIPs = nil
+		}
+		gateway.Register(mux, log, gatewayMedia, allowedIPs, hlsIPs)
+
+		if cfg.Mount.Path != "" {
+			var queue mount.Queue
+			switch cfg.Mount.Mode {
+			case config.MountLoop:
+				queue = mount.Loop(cfg.Mount.Tracks)
+			case config.MountRandom:
+				queue = mount.Random(storageSrv)
+			default:
+				panic("unknown mount mode: " + cfg.Mount.Mode)
+			}
+			a.mount = mount.New(log, storageSrv, queue, cfg.Mount.Name)
+
+			mountIPs := allowedIPs
+			if cfg.Mount.Public {
+				mountIPs = nil
+			}
+			mux.Handle(cfg.Mount.Path, a.mount.Handler(mountIPs))
+		}
+
+		a.httpServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.HTTP.Port),
+			Handler: mux,
+		}
 	}
+
+	a.gRPCServer = gRPCServer
+
+	return a
+}
+
+// newStorage creates storage of namespace rooted in dir,
+// along with its analyzer and reaper unless they are disabled.
+func (a *App) newStorage(
+	log *slog.Logger,
+	cfg *config.Config,
+	namespace string,
+	dir string,
+	opts []storage.Option,
+) *storage.Storage {
+	log = log.With(slog.String("namespace", namespace))
+
+	var an *analyzer.Analyzer
+	if !cfg.Analysis.Disabled {
+		an = analyzer.New(log, cfg.Analysis.QueueLen)
+		opts = append(opts, storage.WithAnalyzer(an))
+	}
+
+	s := storage.New(
+		log,
+		dir,
+		cfg.Source.NestingDepth,
+		cfg.Source.IdLength,
+		opts...,
+	)
+
+	if an != nil {
+		an.Register(analyzer.Stages(s)...)
+		a.analyzers = append(a.analyzers, an)
+	}
+
+	// Replica deletes expired files following primary.
+	if !cfg.Reaper.Disabled && cfg.Replication.Role != config.RoleReplica {
+		a.reapers = append(a.reapers, reaper.New(log, s, cfg.Reaper.Interval))
+	}
+
+	return s
+}
+
+// quotaPolicy returns limits of storage quotas set in config.
+func quotaPolicy(cfg *config.Config) storage.QuotaPolicy {
+	policy := storage.QuotaPolicy{
+		Global: usageLimit(cfg.Quota.Limit),
+		Namespaces: map[string]models.Usage{
+			models.DefaultNamespace: usageLimit(cfg.Source.Quota),
+		},
+		Clients: make(map[string]models.Usage, len(cfg.Quota.Clients)),
+		Reserve: cfg.Quota.Reserve,
+	}
+	for _, ns := range cfg.Source.Namespaces {
+		policy.Namespaces[ns.Name] = usageLimit(ns.Quota)
+	}
+	for client, limit := range cfg.Quota.Clients {
+		policy.Clients[client] = usageLimit(limit)
+	}
+	return policy
+}
+
+func usageLimit(limit config.Limit) models.Usage {
+	return models.Usage{Bytes: limit.Bytes, Files: limit.Files}
+}
+
+// mustParseFormats returns formats by their names.
+//
+// Panics if format is unknown.
+func mustParseFormats(names []string) []format.Format {
+	formats := make([]format.Format, 0, len(names))
+	for _, name := range names {
+		f, ok := format.ByName(name)
+		if !ok {
+			panic("unknown file format: " + name)
+		}
+		formats = append(formats, f)
+	}
+	return formats
 }
 
 // MustRun runs gRPC server and panics if any error occurs.
@@ -63,6 +370,32 @@ func (a *App) Run() error {
 		slog.Int("port", a.port),
 	)
 
+	if a.replica != nil {
+		go a.replica.Run(a.ctx)
+	}
+
+	for _, analyzer := range a.analyzers {
+		go analyzer.Run(a.ctx)
+	}
+
+	for _, reaper := range a.reapers {
+		go reaper.Run(a.ctx)
+	}
+
+	if a.mount != nil {
+		go a.mount.Run(a.ctx)
+	}
+
+	if a.httpServer != nil {
+		go func() {
+			log.Info("http server is running", slog.String("addr", a.httpServer.Addr))
+
+			if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("http server failed", sl.Err(err))
+			}
+		}()
+	}
+
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -83,5 +416,20 @@ func (a *App) Stop() {
 
 	a.log.With(slog.String("op", op)).Info("stopping gRPC server", slog.Int("port", a.port))
 
+	a.cancel()
+
+	// Replication streams never end by themselves.
+	if a.primary != nil {
+		a.primary.Close()
+	}
+
+	if a.httpServer != nil {
+		a.httpServer.Shutdown(context.Background())
+	}
+
 	a.gRPCServer.GracefulStop()
+
+	if a.changes != nil {
+		a.changes.Close()
+	}
 }
@@ -6,13 +6,22 @@ import (
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+
+	"radio-storage/internal/domain/models"
 )
 
 type Config struct {
-	Env     string        `yaml:"env" env-required:"true"`
-	LogPath string        `yaml:"log_path" env-default:""`
-	GRPC    GRPCConfig    `yaml:"grpc"`
-	Source  SourceStorage `yaml:"source_storage"`
+	Env         string        `yaml:"env" env-required:"true"`
+	LogPath     string        `yaml:"log_path" env-default:""`
+	GRPC        GRPCConfig    `yaml:"grpc"`
+	HTTP        HTTPConfig    `yaml:"http"`
+	Source      SourceStorage `yaml:"source_storage"`
+	Replication Replication   `yaml:"replication"`
+	Validation  Validation    `yaml:"validation"`
+	Analysis    Analysis      `yaml:"analysis"`
+	Mount       Mount         `yaml:"mount"`
+	Quota       Quota         `yaml:"quota"`
+	Reaper      Reaper        `yaml:"reaper"`
 }
 
 type GRPCConfig struct {
@@ -20,10 +29,128 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout" env-default:"1m"`
 }
 
+// HTTPConfig configures HTTP server with metrics and gateway.
+// Zero port disables it.
+type HTTPConfig struct {
+	Port int `yaml:"port" env-default:"0"`
+	// PublicHLS serves HLS streams to any client,
+	// otherwise only to allowed ips.
+	PublicHLS bool `yaml:"public_hls"`
+}
+
+const (
+	RoleStandalone = ""
+	RolePrimary    = "primary"
+	RoleReplica    = "replica"
+)
+
+// Replication configures role of the instance.
+// Replica connects to primary and serves read-only requests.
+type Replication struct {
+	Role        string `yaml:"role" env-default:""`
+	Name        string `yaml:"name" env-default:""`
+	PrimaryAddr string `yaml:"primary_addr" env-default:""`
+}
+
+// Validation configures checks of uploaded files.
+// Zero values disable corresponding checks.
+type Validation struct {
+	MinSize      int64         `yaml:"min_size"`
+	MaxSize      int64         `yaml:"max_size"`
+	MinSyncRatio float64       `yaml:"min_sync_ratio"`
+	SampleRates  []int         `yaml:"sample_rates"`
+	Bitrates     []int         `yaml:"bitrates"`
+	MaxDuration  time.Duration `yaml:"max_duration"`
+	// Quarantine keeps rejected files for inspection.
+	Quarantine bool `yaml:"quarantine"`
+}
+
+// Analysis configures background analysis of uploaded files.
+type Analysis struct {
+	Disabled bool `yaml:"disabled"`
+	QueueLen int  `yaml:"queue_len" env-default:"1024"`
+}
+
+// Reaper configures background deletion of expired files.
+type Reaper struct {
+	Disabled bool          `yaml:"disabled"`
+	Interval time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+const (
+	MountLoop   = "loop"
+	MountRandom = "random"
+)
+
+// Mount configures Icecast compatible fallback stream
+// served by HTTP server. Empty path disables it.
+type Mount struct {
+	Path string `yaml:"path"`
+	Name string `yaml:"name" env-default:"Fallback"`
+	// Mode is "loop" over Tracks or "random" over pinned files.
+	Mode   string `yaml:"mode" env-default:"loop"`
+	Tracks []int  `yaml:"tracks"`
+	// Public mount is served to any client,
+	// otherwise only to allowed ips.
+	Public bool `yaml:"public"`
+}
+
 type SourceStorage struct {
 	SourcePath   string `yaml:"path" env-required:"true"`
 	NestingDepth int    `yaml:"nesting_depth" env-required:"true"`
 	IdLength     int    `yaml:"id_length" env-required:"true"`
+	// Formats accepted on upload, all known formats if empty.
+	Formats []string `yaml:"formats"`
+	// Quota limits files of default namespace.
+	Quota Limit `yaml:"quota"`
+	// Retention is age files of default namespace are deleted at,
+	// unless their expiry is set. Zero keeps files forever.
+	Retention time.Duration `yaml:"retention"`
+	// Namespaces are separate storages with own id spaces,
+	// besides default one stored in the root.
+	Namespaces []Namespace `yaml:"namespaces"`
+}
+
+// Namespace is a storage rooted in
+// subdirectory of source path named after it.
+type Namespace struct {
+	Name string `yaml:"name"`
+	// Formats accepted on upload, same as in the root if empty.
+	Formats   []string      `yaml:"formats"`
+	Quota     Limit         `yaml:"quota"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// NamespacePath returns root of the namespace,
+// false if it is not configured.
+func (s SourceStorage) NamespacePath(name string) (string, bool) {
+	if name == models.DefaultNamespace {
+		return s.SourcePath, true
+	}
+	for _, ns := range s.Namespaces {
+		if ns.Name == name {
+			return s.SourcePath + "/" + name, true
+		}
+	}
+	return "", false
+}
+
+// Limit bounds total size and number of files.
+// Zero values are not limited.
+type Limit struct {
+	Bytes int64 `yaml:"bytes"`
+	Files int   `yaml:"files"`
+}
+
+// Quota limits usage of the storage,
+// uploads exceeding it are rejected.
+type Quota struct {
+	// Limit bounds files of all namespaces together.
+	Limit `yaml:",inline"`
+	// Clients bound files uploaded by client ip.
+	Clients map[string]Limit `yaml:"clients"`
+	// Reserve is disk free space in bytes uploads never take.
+	Reserve int64 `yaml:"reserve"`
 }
 
 func MustLoad() *Config {
@@ -47,6 +174,10 @@ func MustLoadPath(configPath string) *Config {
 		panic("cannot read config: " + err.Error())
 	}
 
+	if !cfg.Reaper.Disabled && cfg.Reaper.Interval <= 0 {
+		panic("reaper interval must be positive: " + cfg.Reaper.Interval.String())
+	}
+
 	return &cfg
 }
 
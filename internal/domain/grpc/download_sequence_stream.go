@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"fmt"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"radio-storage/internal/domain/models"
+)
+
+type DownloadSequenceStreamWrapper struct {
+	Stream grpc.ServerStreamingServer[ssov1.DownloadSequenceResponse]
+
+	contentType string
+}
+
+// SetContentType sets content type
+// sent along with the next message.
+func (w *DownloadSequenceStreamWrapper) SetContentType(contentType string) {
+	w.contentType = contentType
+}
+
+// StartItem reports start of the next item.
+func (w *DownloadSequenceStreamWrapper) StartItem(item models.SequenceItem) error {
+	const op = "DownloadSequenceStreamWrapper.StartItem"
+
+	return w.send(op, &ssov1.DownloadSequenceResponse{
+		Item: &ssov1.SequenceItem{
+			Index:          int32(item.Index),
+			FileId:         int32(item.FileID),
+			ByteOffset:     item.ByteOffset,
+			TimeOffset:     durationpb.New(item.TimeOffset),
+			Duration:       durationpb.New(item.Duration),
+			EncoderDelay:   int32(item.EncoderDelay),
+			EncoderPadding: int32(item.EncoderPadding),
+		},
+	})
+}
+
+func (w *DownloadSequenceStreamWrapper) Write(p []byte) error {
+	const op = "DownloadSequenceStreamWrapper.Write"
+
+	return w.send(op, &ssov1.DownloadSequenceResponse{Chunk: p})
+}
+
+func (w *DownloadSequenceStreamWrapper) send(op string, resp *ssov1.DownloadSequenceResponse) error {
+	resp.ContentType = w.contentType
+
+	if err := w.Stream.Send(resp); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	w.contentType = ""
+
+	return nil
+}
@@ -9,14 +9,23 @@ import (
 
 type DownloadStreamWrapper struct {
 	Stream grpc.ServerStreamingServer[ssov1.DownloadResponse]
+
+	contentType string
+}
+
+// SetContentType sets content type
+// sent along with the next chunk.
+func (w *DownloadStreamWrapper) SetContentType(contentType string) {
+	w.contentType = contentType
 }
 
 func (w *DownloadStreamWrapper) Write(p []byte) error {
 	const op = "DownloadStreamWrapper.Write"
 
-	if err := w.Stream.Send(&ssov1.DownloadResponse{Chunk: p}); err != nil {
+	if err := w.Stream.Send(&ssov1.DownloadResponse{Chunk: p, ContentType: w.contentType}); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	w.contentType = ""
 
 	return nil
 }
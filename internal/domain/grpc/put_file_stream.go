@@ -0,0 +1,56 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+)
+
+// PutFileStreamWrapper reads file content from PutFile stream.
+type PutFileStreamWrapper struct {
+	Stream grpc.ClientStreamingServer[ssov1.PutFileRequest, ssov1.PutFileResponse]
+
+	buf  []byte
+	read int64
+}
+
+// FileID receives first message and returns target file id.
+func (w *PutFileStreamWrapper) FileID() (int, error) {
+	const op = "PutFileStreamWrapper.FileID"
+
+	req, err := w.Stream.Recv()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	w.buf = req.GetChunk()
+
+	return int(req.GetFileId()), nil
+}
+
+func (w *PutFileStreamWrapper) Read(p []byte) (int, error) {
+	for len(w.buf) == 0 {
+		req, err := w.Stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		w.buf = req.GetChunk()
+	}
+
+	n := copy(p, w.buf)
+	w.buf = w.buf[n:]
+	w.read += int64(n)
+
+	return n, nil
+}
+
+// Size returns number of bytes read.
+func (w *PutFileStreamWrapper) Size() int64 {
+	return w.read
+}
@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+)
+
+// PutSidecarStreamWrapper reads sidecar archive from PutSidecar stream.
+type PutSidecarStreamWrapper struct {
+	Stream grpc.ClientStreamingServer[ssov1.PutSidecarRequest, ssov1.PutSidecarResponse]
+
+	buf []byte
+}
+
+// FileID receives first message and returns target file id.
+func (w *PutSidecarStreamWrapper) FileID() (int, error) {
+	const op = "PutSidecarStreamWrapper.FileID"
+
+	req, err := w.Stream.Recv()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	w.buf = req.GetChunk()
+
+	return int(req.GetFileId()), nil
+}
+
+func (w *PutSidecarStreamWrapper) Read(p []byte) (int, error) {
+	for len(w.buf) == 0 {
+		req, err := w.Stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		w.buf = req.GetChunk()
+	}
+
+	n := copy(p, w.buf)
+	w.buf = w.buf[n:]
+
+	return n, nil
+}
@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"fmt"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"radio-storage/internal/domain/models"
+)
+
+type ReplicationStreamWrapper struct {
+	Stream grpc.BidiStreamingServer[ssov1.ReplicationAck, ssov1.ReplicationEvent]
+}
+
+// Recv returns replica name and its applied position.
+func (w *ReplicationStreamWrapper) Recv() (string, uint64, error) {
+	const op = "ReplicationStreamWrapper.Recv"
+
+	ack, err := w.Stream.Recv()
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ack.GetReplica(), ack.GetAppliedSeq(), nil
+}
+
+// Send sends part of the change.
+func (w *ReplicationStreamWrapper) Send(change models.Change, chunk []byte, last bool, head uint64) error {
+	const op = "ReplicationStreamWrapper.Send"
+
+	var replicationOp ssov1.ReplicationOp
+	switch change.Op {
+	case models.OpUpload:
+		replicationOp = ssov1.ReplicationOp_REPLICATION_OP_UPLOAD
+	case models.OpDelete:
+		replicationOp = ssov1.ReplicationOp_REPLICATION_OP_DELETE
+	case models.OpSidecar:
+		replicationOp = ssov1.ReplicationOp_REPLICATION_OP_SIDECAR
+	}
+
+	if err := w.Stream.Send(&ssov1.ReplicationEvent{
+		Seq:         change.Seq,
+		Op:          replicationOp,
+		FileId:      int32(change.ID),
+		Chunk:       chunk,
+		Last:        last,
+		HeadSeq:     head,
+		CommittedAt: timestamppb.New(change.CommittedAt),
+		Namespace:   change.Namespace,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Heartbeat reports primary head to idle replica.
+func (w *ReplicationStreamWrapper) Heartbeat(head uint64) error {
+	const op = "ReplicationStreamWrapper.Heartbeat"
+
+	if err := w.Stream.Send(&ssov1.ReplicationEvent{
+		Op:      ssov1.ReplicationOp_REPLICATION_OP_HEARTBEAT,
+		HeadSeq: head,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
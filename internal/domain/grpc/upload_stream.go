@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
 	"google.golang.org/grpc"
@@ -11,6 +12,12 @@ import (
 
 type UploadStreamWrapper struct {
 	Stream grpc.ClientStreamingServer[ssov1.UploadRequest, ssov1.UploadResponse]
+	// Client identifies uploader in quotas, may be empty.
+	Client string
+
+	labels    map[string]string
+	size      int64
+	expiresAt time.Time
 }
 
 func (w *UploadStreamWrapper) GetChunk() ([]byte, error) {
@@ -24,5 +31,35 @@ func (w *UploadStreamWrapper) GetChunk() ([]byte, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	for k, v := range req.GetLabels() {
+		if w.labels == nil {
+			w.labels = make(map[string]string)
+		}
+		w.labels[k] = v
+	}
+
+	if w.size == 0 {
+		w.size = req.GetSize()
+	}
+	if w.expiresAt.IsZero() && req.GetExpiresAt() != nil {
+		w.expiresAt = req.GetExpiresAt().AsTime()
+	}
+
 	return req.GetChunk(), nil
 }
+
+// Labels returns labels sent along with chunks received so far.
+func (w *UploadStreamWrapper) Labels() map[string]string {
+	return w.labels
+}
+
+// Size returns expected file size sent by client, zero if unknown.
+func (w *UploadStreamWrapper) Size() int64 {
+	return w.size
+}
+
+// ExpiresAt returns time the file is deleted at sent by client,
+// zero if it is not sent.
+func (w *UploadStreamWrapper) ExpiresAt() time.Time {
+	return w.expiresAt
+}
@@ -0,0 +1,20 @@
+package models
+
+// BackfillOptions selects analysis run over stored files.
+type BackfillOptions struct {
+	// Stages to run by name, all if empty.
+	Stages []string
+	// Force reanalyzes files with up to date results.
+	Force bool
+
+	FromID int
+	ToID   int
+}
+
+// BackfillStats contains result of backfill,
+// counted per file and stage.
+type BackfillStats struct {
+	Analyzed int
+	Skipped  int
+	Failed   int
+}
@@ -0,0 +1,9 @@
+package models
+
+// Artwork is cover art of a track.
+type Artwork struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
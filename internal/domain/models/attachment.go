@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Attachment is named blob attached to stored file,
+// e.g. lyrics, chapters or transcript.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Size        int64
+	ModifiedAt  time.Time
+	// Data is set only when single attachment is read.
+	Data []byte
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// BackupManifest describes content of backup archive.
+type BackupManifest struct {
+	Version   int                  `json:"version"`
+	CreatedAt time.Time            `json:"created_at"`
+	Filter    BackupFilter         `json:"filter"`
+	Files     []BackupManifestFile `json:"files"`
+}
+
+// BackupManifestFile describes single file in backup archive.
+type BackupManifestFile struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Sidecar is archive of sidecar files holding
+	// state of the file, absent if it has none.
+	SidecarSize   int64  `json:"sidecar_size,omitempty"`
+	SidecarSHA256 string `json:"sidecar_sha256,omitempty"`
+}
+
+// BackupFilter selects files to include in backup.
+//
+// Zero ToID means no upper bound,
+// zero Since means no time bound.
+type BackupFilter struct {
+	FromID int       `json:"from_id,omitempty"`
+	ToID   int       `json:"to_id,omitempty"`
+	Since  time.Time `json:"since,omitempty"`
+}
+
+// RestoreStats contains result of restore.
+type RestoreStats struct {
+	Restored int
+	Skipped  int
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// CuePoints are positions in a track used for segues.
+type CuePoints struct {
+	// Start is end of leading silence.
+	Start time.Duration `json:"start"`
+	// End is start of trailing silence.
+	End time.Duration `json:"end"`
+	// FadeOut is start of fade out.
+	FadeOut time.Duration `json:"fade_out"`
+	// IntroEnd is estimated end of intro.
+	IntroEnd time.Duration `json:"intro_end"`
+}
+
+// CueOverride holds manually set cue points.
+// Nil fields keep detected ones.
+type CueOverride struct {
+	Start    *time.Duration `json:"start,omitempty"`
+	End      *time.Duration `json:"end,omitempty"`
+	FadeOut  *time.Duration `json:"fade_out,omitempty"`
+	IntroEnd *time.Duration `json:"intro_end,omitempty"`
+}
+
+// IsZero reports whether nothing is overridden.
+func (o CueOverride) IsZero() bool {
+	return o.Start == nil && o.End == nil && o.FadeOut == nil && o.IntroEnd == nil
+}
+
+// Apply returns cue points with overridden fields replaced.
+func (o CueOverride) Apply(c CuePoints) CuePoints {
+	for _, f := range []struct {
+		dst *time.Duration
+		src *time.Duration
+	}{
+		{&c.Start, o.Start},
+		{&c.End, o.End},
+		{&c.FadeOut, o.FadeOut},
+		{&c.IntroEnd, o.IntroEnd},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+
+	return c
+}
+
+// Cues are detected and manually set cue points of a track.
+type Cues struct {
+	Detected CuePoints
+	Override CueOverride
+}
+
+// Effective returns cue points used for playout.
+func (c Cues) Effective() CuePoints {
+	return c.Override.Apply(c.Detected)
+}
@@ -0,0 +1,57 @@
+package models
+
+// OutputFormat is format of downloaded file.
+type OutputFormat int
+
+const (
+	// OutputOriginal is file as it is stored.
+	OutputOriginal OutputFormat = iota
+	// OutputWAV is decoded audio in 16-bit PCM WAV.
+	OutputWAV
+	// OutputPCM is decoded audio in raw 16-bit
+	// signed little-endian interleaved PCM.
+	OutputPCM
+)
+
+// TagMode is what is done with tags of downloaded MP3 file.
+type TagMode int
+
+const (
+	// TagsKeep keeps tags as they are stored.
+	TagsKeep TagMode = iota
+	// TagsStrip drops all tags.
+	TagsStrip
+	// TagsReplace replaces tags with ones given in request.
+	TagsReplace
+)
+
+// Tags are textual tags written to downloaded file.
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+	Year   string
+}
+
+// DownloadOptions controls how file is downloaded.
+type DownloadOptions struct {
+	Output OutputFormat
+
+	// SampleRate and Channels of decoded audio,
+	// zero keeps ones of the file.
+	SampleRate int
+	Channels   int
+
+	// TrimSilence drops whole MP3 frames of leading and
+	// trailing digital silence, decoded output is trimmed too.
+	TrimSilence bool
+	// StripArtwork drops pictures from ID3v2 tag
+	// of MP3 file, original output only.
+	StripArtwork bool
+
+	// TagMode controls ID3v2, ID3v1 and APEv2 tags of MP3 file,
+	// original output only. Stored file is never changed.
+	TagMode TagMode
+	// Tags are written if TagMode is TagsReplace.
+	Tags Tags
+}
@@ -0,0 +1,16 @@
+package models
+
+// FindQuery selects stored files by their labels.
+type FindQuery struct {
+	// Selector is label selector, empty one matches all labeled files.
+	Selector string
+	// AfterID skips files with lesser or equal id, for paging.
+	AfterID int
+	Limit   int
+}
+
+// LabeledFile is stored file with its labels.
+type LabeledFile struct {
+	FileID int
+	Labels map[string]string
+}
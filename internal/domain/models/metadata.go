@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// BitrateMode is bitrate mode of a track.
+type BitrateMode int
+
+const (
+	BitrateUnknown BitrateMode = iota
+	BitrateCBR
+	BitrateVBR
+	BitrateABR
+)
+
+// Metadata describes stored track.
+type Metadata struct {
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+
+	Title    string `json:"title,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	Album    string `json:"album,omitempty"`
+	Year     string `json:"year,omitempty"`
+	HasCover bool   `json:"has_cover,omitempty"`
+
+	Duration    time.Duration `json:"duration"`
+	Bitrate     int           `json:"bitrate"` // kbps
+	BitrateMode BitrateMode   `json:"bitrate_mode"`
+	SampleRate  int           `json:"sample_rate"`
+	Channels    int           `json:"channels"`
+
+	// Loudness and TempoKey are set once file is analyzed.
+	Loudness *Loudness `json:"loudness,omitempty"`
+	TempoKey *TempoKey `json:"tempo_key,omitempty"`
+}
+
+// Loudness is measured loudness of a track.
+type Loudness struct {
+	Integrated float64 `json:"integrated"` // LUFS
+	Range      float64 `json:"range"`      // LU
+	TruePeak   float64 `json:"true_peak"`  // dBTP
+	TrackGain  float64 `json:"track_gain"` // ReplayGain, dB
+}
+
+// TempoKey is estimated tempo and musical key of a track.
+type TempoKey struct {
+	// BPM is zero if track has no beat.
+	BPM float64 `json:"bpm"`
+	// Key is like "A minor", Camelot is its code on Camelot
+	// wheel like "8A". Both are empty if key is not detected.
+	Key     string `json:"key,omitempty"`
+	Camelot string `json:"camelot,omitempty"`
+}
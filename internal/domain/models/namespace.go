@@ -0,0 +1,5 @@
+package models
+
+// DefaultNamespace serves requests naming no namespace,
+// its files are stored in the root of source storage.
+const DefaultNamespace = "default"
@@ -0,0 +1,47 @@
+package models
+
+// Usage is total size and number of stored files.
+type Usage struct {
+	Bytes int64
+	Files int
+}
+
+func (u Usage) Add(other Usage) Usage {
+	return Usage{Bytes: u.Bytes + other.Bytes, Files: u.Files + other.Files}
+}
+
+func (u Usage) Sub(other Usage) Usage {
+	return Usage{Bytes: u.Bytes - other.Bytes, Files: u.Files - other.Files}
+}
+
+// QuotaScope is a kind of files counted by quota.
+type QuotaScope uint8
+
+const (
+	// QuotaGlobal counts files of all namespaces.
+	QuotaGlobal QuotaScope = iota + 1
+	// QuotaNamespace counts files of the namespace.
+	QuotaNamespace
+	// QuotaClient counts files uploaded by client
+	// to any namespace.
+	QuotaClient
+)
+
+// Quota is usage of files in scope along with its limit.
+// Zero limit fields are not limited.
+type Quota struct {
+	Scope QuotaScope
+	// Name is namespace or client, empty for global scope.
+	Name  string
+	Usage Usage
+	Limit Usage
+}
+
+// UsageReport is usage of all scopes and disk space.
+type UsageReport struct {
+	Quotas []Quota
+	// DiskFree is free space available to storage.
+	DiskFree int64
+	// DiskReserve is free space uploads never take.
+	DiskReserve int64
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ChangeOp is a kind of committed storage change.
+type ChangeOp uint8
+
+const (
+	OpUpload ChangeOp = iota + 1
+	OpDelete
+	// OpSidecar replaces sidecar files
+	// holding state of the file.
+	OpSidecar
+)
+
+// Change is a replication log entry.
+type Change struct {
+	Seq         uint64
+	Op          ChangeOp
+	ID          int
+	CommittedAt time.Time
+	Namespace   string
+}
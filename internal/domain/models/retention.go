@@ -0,0 +1,9 @@
+package models
+
+// ReapStats counts expired files handled by reaper run.
+type ReapStats struct {
+	Deleted int
+	// Pinned files are expired, but kept.
+	Pinned int
+	Failed int
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SequenceItem is position of a file
+// in concatenated stream of files.
+type SequenceItem struct {
+	Index      int
+	FileID     int
+	ByteOffset int64
+	// TimeOffset and Duration count all decoded
+	// samples, encoder delay and padding included.
+	TimeOffset time.Duration
+	Duration   time.Duration
+	// EncoderDelay and EncoderPadding are numbers of samples
+	// skipped at the start and the end by gapless playback.
+	EncoderDelay   int
+	EncoderPadding int
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SimilarQuery selects audio to search stored files for.
+type SimilarQuery struct {
+	// Clip is MP3 audio to search for,
+	// if it is empty, file with FileID is searched.
+	FileID int
+	Clip   []byte
+
+	// MinConfidence is from 0 to 1, zero selects default.
+	MinConfidence float64
+	// Limit is maximum number of results, zero selects default.
+	Limit int
+}
+
+// SimilarFile is stored file sounding like queried audio.
+type SimilarFile struct {
+	FileID int
+	// Offset is position in the file
+	// where queried audio starts.
+	Offset time.Duration
+	// Confidence is from 0 to 1.
+	Confidence float64
+}
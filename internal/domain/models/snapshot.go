@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Snapshot describes point-in-time copy of the storage.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     int       `json:"files"`
+	Size      int64     `json:"size"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// FileInfo describes stored file.
+type FileInfo struct {
+	ID     int
+	Size   int64
+	SHA256 string
+	// SidecarSHA256 is checksum of sidecar files holding
+	// state of the file, empty if it has none.
+	SidecarSHA256 string
+}
+
+// SyncDirection defines which side of sync receives files.
+type SyncDirection int
+
+const (
+	SyncPull SyncDirection = iota + 1
+	SyncPush
+	SyncBoth
+)
+
+// SyncOptions configures synchronization with peer storage.
+type SyncOptions struct {
+	Direction SyncDirection
+	// Mirror deletes files absent on the source side.
+	Mirror bool
+	// DryRun only counts changes.
+	DryRun bool
+}
+
+// SyncStats contains result of synchronization.
+type SyncStats struct {
+	Pulled        int
+	Pushed        int
+	DeletedLocal  int
+	DeletedRemote int
+	Conflicts     int
+	Bytes         int64
+}
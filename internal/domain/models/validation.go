@@ -0,0 +1,7 @@
+package models
+
+// Violation describes validation rule violated by a file.
+type Violation struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,319 @@
+// Package gateway serves read-only media data over HTTP
+// for clients that can not speak gRPC, e.g. browsers.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/allowlist"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/hls"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/waveform"
+	"radio-storage/internal/service"
+)
+
+const (
+	waveformPath = "/waveform/"
+	hlsPath      = "/hls/"
+	artworkPath  = "/artwork/"
+
+	hlsPlaylist = "index.m3u8"
+)
+
+type Media interface {
+	Waveform(ctx context.Context, id int, samplesPerPixel int) (*waveform.Waveform, error)
+	HLSSegments(ctx context.Context, id int) ([]hls.Segment, error)
+	HLSSegment(ctx context.Context, id int, n int) ([]byte, error)
+	Artwork(ctx context.Context, id int, size int) (models.Artwork, error)
+}
+
+type gateway struct {
+	log        *slog.Logger
+	media      map[string]Media
+	allowedIps []string
+	hlsIps     []string
+}
+
+// Register adds gateway handlers to mux.
+// Media is served from storage of namespace
+// given by "namespace" parameter, default one if it is missing.
+//
+//	GET /waveform/{id}?resolution=256&format=json|dat&bits=8|16
+//	GET /hls/{id}/index.m3u8
+//	GET /hls/{id}/{n}.mp3
+//	GET /artwork/{id}?size=100|300|600
+//
+// HLS streams are served to hlsIps, nil hlsIps allows any client,
+// so players can be pointed to them directly.
+func Register(
+	mux *http.ServeMux,
+	log *slog.Logger,
+	media map[string]Media,
+	allowedIps []string,
+	hlsIps []string,
+) {
+	g := &gateway{
+		log:        log,
+		media:      media,
+		allowedIps: allowedIps,
+		hlsIps:     hlsIps,
+	}
+
+	mux.HandleFunc(waveformPath, g.waveform)
+	mux.HandleFunc(hlsPath, g.hls)
+	mux.HandleFunc(artworkPath, g.artwork)
+}
+
+func (g *gateway) waveform(w http.ResponseWriter, r *http.Request) {
+	const op = "gateway.waveform"
+
+	log := g.log.With(
+		slog.String("op", op),
+	)
+
+	if !allowlist.Allowed(r.RemoteAddr, g.allowedIps) {
+		http.Error(w, "ip is not allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	media, ok := g.namespace(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, waveformPath))
+	if err != nil {
+		http.Error(w, "invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	resolution, err := intParam(query, "resolution")
+	if err != nil {
+		http.Error(w, "invalid resolution", http.StatusBadRequest)
+		return
+	}
+	bits, err := intParam(query, "bits")
+	if err != nil {
+		http.Error(w, "invalid bits", http.StatusBadRequest)
+		return
+	}
+
+	var f waveform.Format
+	switch query.Get("format") {
+	case "", "json":
+		f = waveform.JSON
+	case "dat":
+		f = waveform.Binary
+	default:
+		http.Error(w, "unknown format", http.StatusBadRequest)
+		return
+	}
+
+	wf, err := media.Waveform(r.Context(), id, resolution)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileNotExist):
+			http.Error(w, "file not exists", http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidResolution):
+			http.Error(w, "invalid resolution", http.StatusBadRequest)
+		case errors.Is(err, service.ErrConversionUnsupported), errors.Is(err, service.ErrDecodeFailed):
+			http.Error(w, "waveform is not available for file", http.StatusUnprocessableEntity)
+		default:
+			log.Error("failed to get waveform", slog.Int("id", id), sl.Err(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data, contentType, err := wf.Encode(f, bits)
+	if err != nil {
+		if errors.Is(err, waveform.ErrInvalidBits) {
+			http.Error(w, "bits must be 8 or 16", http.StatusBadRequest)
+			return
+		}
+		log.Error("failed to encode waveform", slog.Int("id", id), sl.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+func (g *gateway) hls(w http.ResponseWriter, r *http.Request) {
+	const op = "gateway.hls"
+
+	log := g.log.With(
+		slog.String("op", op),
+	)
+
+	if g.hlsIps != nil && !allowlist.Allowed(r.RemoteAddr, g.hlsIps) {
+		http.Error(w, "ip is not allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	media, ok := g.namespace(w, r)
+	if !ok {
+		return
+	}
+
+	idStr, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, hlsPath), "/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		data        []byte
+		contentType string
+	)
+	if name == hlsPlaylist {
+		var segments []hls.Segment
+		segments, err = media.HLSSegments(r.Context(), id)
+		if err == nil {
+			// Segments are requested from the same namespace.
+			var query string
+			if ns := r.URL.Query().Get("namespace"); ns != "" {
+				query = "?" + url.Values{"namespace": {ns}}.Encode()
+			}
+			data = hls.Playlist(segments, func(i int) string {
+				return strconv.Itoa(i) + ".mp3" + query
+			})
+			contentType = hls.PlaylistContentType
+		}
+	} else {
+		nStr, ok := strings.CutSuffix(name, ".mp3")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		n, convErr := strconv.Atoi(nStr)
+		if convErr != nil {
+			http.Error(w, "invalid segment", http.StatusBadRequest)
+			return
+		}
+		data, err = media.HLSSegment(r.Context(), id, n)
+		contentType = format.MP3.ContentType
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileNotExist):
+			http.Error(w, "file not exists", http.StatusNotFound)
+		case errors.Is(err, service.ErrSegmentNotExist):
+			http.Error(w, "segment not exists", http.StatusNotFound)
+		case errors.Is(err, service.ErrConversionUnsupported), errors.Is(err, service.ErrDecodeFailed):
+			http.Error(w, "hls is not available for file", http.StatusUnprocessableEntity)
+		default:
+			log.Error("failed to get hls", slog.Int("id", id), slog.String("name", name), sl.Err(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+func (g *gateway) artwork(w http.ResponseWriter, r *http.Request) {
+	const op = "gateway.artwork"
+
+	log := g.log.With(
+		slog.String("op", op),
+	)
+
+	if !allowlist.Allowed(r.RemoteAddr, g.allowedIps) {
+		http.Error(w, "ip is not allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	media, ok := g.namespace(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, artworkPath))
+	if err != nil {
+		http.Error(w, "invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	size, err := intParam(r.URL.Query(), "size")
+	if err != nil {
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+
+	artwork, err := media.Artwork(r.Context(), id, size)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileNotExist):
+			http.Error(w, "file not exists", http.StatusNotFound)
+		case errors.Is(err, service.ErrArtworkNotExist):
+			http.Error(w, "artwork not exists", http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidArtworkSize):
+			http.Error(w, "invalid size", http.StatusBadRequest)
+		default:
+			log.Error("failed to get artwork", slog.Int("id", id), sl.Err(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", artwork.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(artwork.Data)))
+	w.Write(artwork.Data)
+}
+
+// namespace returns media of namespace requested by client,
+// writes error if it not exists.
+func (g *gateway) namespace(w http.ResponseWriter, r *http.Request) (Media, bool) {
+	name := r.URL.Query().Get("namespace")
+	if name == "" {
+		name = models.DefaultNamespace
+	}
+
+	media, ok := g.media[name]
+	if !ok {
+		http.Error(w, "namespace not exists", http.StatusNotFound)
+	}
+
+	return media, ok
+}
+
+// intParam parses optional integer query parameter.
+func intParam(query url.Values, name string) (int, error) {
+	str := query.Get(name)
+	if str == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(str)
+}
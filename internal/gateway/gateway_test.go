@@ -0,0 +1,234 @@
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/gateway"
+	"radio-storage/internal/lib/hls"
+	"radio-storage/internal/lib/waveform"
+	"radio-storage/internal/service"
+)
+
+type media struct{}
+
+func (media) Waveform(_ context.Context, id int, samplesPerPixel int) (*waveform.Waveform, error) {
+	switch {
+	case id != 1:
+		return nil, service.ErrFileNotExist
+	case samplesPerPixel%256 != 0:
+		return nil, service.ErrInvalidResolution
+	}
+
+	w := &waveform.Waveform{
+		SampleRate:      44100,
+		SamplesPerPixel: 256,
+		Bits:            16,
+		Data:            []int16{-512, 256, -1024, 1024},
+	}
+	if samplesPerPixel == 0 {
+		return w, nil
+	}
+	return w.Resample(samplesPerPixel)
+}
+
+func (media) HLSSegments(_ context.Context, id int) ([]hls.Segment, error) {
+	if id != 1 {
+		return nil, service.ErrFileNotExist
+	}
+
+	return []hls.Segment{
+		{Size: 3, Duration: 6 * time.Second},
+		{Offset: 3, Size: 2, Start: 6 * time.Second, Duration: 2 * time.Second},
+	}, nil
+}
+
+func (m media) HLSSegment(ctx context.Context, id int, n int) ([]byte, error) {
+	segments, err := m.HLSSegments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n >= len(segments) {
+		return nil, service.ErrSegmentNotExist
+	}
+
+	return []byte("segment " + strconv.Itoa(n)), nil
+}
+
+func (media) Artwork(_ context.Context, id int, size int) (models.Artwork, error) {
+	switch {
+	case id == 2:
+		return models.Artwork{}, service.ErrArtworkNotExist
+	case id != 1:
+		return models.Artwork{}, service.ErrFileNotExist
+	case size != 0 && size != 100:
+		return models.Artwork{}, service.ErrInvalidArtworkSize
+	}
+
+	return models.Artwork{
+		Data:        []byte("cover " + strconv.Itoa(size)),
+		ContentType: "image/jpeg",
+		Width:       size,
+		Height:      size,
+	}, nil
+}
+
+func TestWaveform(t *testing.T) {
+	mux := http.NewServeMux()
+	gateway.Register(mux, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]gateway.Media{models.DefaultNamespace: media{}}, []string{"192.0.2.1"}, []string{"192.0.2.1"})
+
+	get := func(target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/waveform/1?resolution=512&bits=8", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var res struct {
+		SamplesPerPixel int     `json:"samples_per_pixel"`
+		Bits            int     `json:"bits"`
+		Data            []int16 `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, 512, res.SamplesPerPixel)
+	require.Equal(t, 8, res.Bits)
+	require.Equal(t, []int16{-4, 4}, res.Data)
+
+	rec = get("/waveform/1?format=dat", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	var w waveform.Waveform
+	require.NoError(t, w.UnmarshalBinary(rec.Body.Bytes()))
+	require.Equal(t, []int16{-512, 256, -1024, 1024}, w.Data)
+
+	for target, code := range map[string]int{
+		"/waveform/2":                http.StatusNotFound,
+		"/waveform/abc":              http.StatusBadRequest,
+		"/waveform/1?resolution=300": http.StatusBadRequest,
+		"/waveform/1?bits=12":        http.StatusBadRequest,
+		"/waveform/1?format=png":     http.StatusBadRequest,
+	} {
+		require.Equal(t, code, get(target, "192.0.2.1:1234").Code, target)
+	}
+
+	require.Equal(t, http.StatusForbidden, get("/waveform/1", "192.0.2.2:1234").Code)
+}
+
+func TestHLS(t *testing.T) {
+	mux := http.NewServeMux()
+	gateway.Register(mux, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]gateway.Media{
+		models.DefaultNamespace: media{},
+		"jingles":               media{},
+	}, []string{"192.0.2.1"}, []string{"192.0.2.1"})
+
+	get := func(target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/hls/1/index.m3u8", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/vnd.apple.mpegurl", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "#EXTINF:6.000,\n0.mp3\n#EXTINF:2.000,\n1.mp3\n#EXT-X-ENDLIST\n")
+
+	// Segments are requested from the same namespace.
+	rec = get("/hls/1/index.m3u8?namespace=jingles", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "0.mp3?namespace=jingles\n")
+
+	rec = get("/hls/1/1.mp3", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "audio/mpeg", rec.Header().Get("Content-Type"))
+	require.Equal(t, "segment 1", rec.Body.String())
+
+	for target, code := range map[string]int{
+		"/hls/2/index.m3u8":          http.StatusNotFound,
+		"/hls/1/2.mp3":               http.StatusNotFound,
+		"/hls/1/x.mp3":               http.StatusBadRequest,
+		"/hls/1/0.ts":                http.StatusNotFound,
+		"/hls/1":                     http.StatusNotFound,
+		"/hls/abc/0.mp3":             http.StatusBadRequest,
+		"/hls/1/0.mp3?namespace=ads": http.StatusNotFound,
+	} {
+		require.Equal(t, code, get(target, "192.0.2.1:1234").Code, target)
+	}
+
+	require.Equal(t, http.StatusForbidden, get("/hls/1/index.m3u8", "192.0.2.2:1234").Code)
+}
+
+func TestPublicHLS(t *testing.T) {
+	mux := http.NewServeMux()
+	gateway.Register(mux, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]gateway.Media{models.DefaultNamespace: media{}}, []string{"192.0.2.1"}, nil)
+
+	get := func(target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/hls/1/index.m3u8", "198.51.100.7:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "0.mp3\n")
+
+	rec = get("/hls/1/0.mp3", "198.51.100.7:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "segment 0", rec.Body.String())
+
+	// Other media stays limited to allowed ips.
+	require.Equal(t, http.StatusForbidden, get("/waveform/1", "198.51.100.7:1234").Code)
+	require.Equal(t, http.StatusForbidden, get("/artwork/1", "198.51.100.7:1234").Code)
+}
+
+func TestArtwork(t *testing.T) {
+	mux := http.NewServeMux()
+	gateway.Register(mux, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]gateway.Media{models.DefaultNamespace: media{}}, []string{"192.0.2.1"}, []string{"192.0.2.1"})
+
+	get := func(target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/artwork/1?size=100", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	require.Equal(t, "cover 100", rec.Body.String())
+
+	rec = get("/artwork/1", "192.0.2.1:1234")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "cover 0", rec.Body.String())
+
+	for target, code := range map[string]int{
+		"/artwork/2":          http.StatusNotFound,
+		"/artwork/3":          http.StatusNotFound,
+		"/artwork/1?size=150": http.StatusBadRequest,
+		"/artwork/1?size=x":   http.StatusBadRequest,
+		"/artwork/abc":        http.StatusBadRequest,
+	} {
+		require.Equal(t, code, get(target, "192.0.2.1:1234").Code, target)
+	}
+
+	require.Equal(t, http.StatusForbidden, get("/artwork/1", "192.0.2.2:1234").Code)
+}
@@ -0,0 +1,464 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	grpcModels "radio-storage/internal/domain/grpc"
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+)
+
+// sidecarChunkLen is size of chunks sidecar archive is streamed by.
+const sidecarChunkLen = 1024 * 32
+
+type Admin interface {
+	CreateSnapshot(ctx context.Context, name string) (models.Snapshot, error)
+	ListSnapshots(ctx context.Context) ([]models.Snapshot, error)
+	DeleteSnapshot(ctx context.Context, name string) error
+	RestoreSnapshot(ctx context.Context, name string, ids []int) (int, error)
+
+	List(ctx context.Context, fromID, toID int, fn func(info models.FileInfo) error) error
+	Import(ctx context.Context, id int, r io.Reader) error
+	OpenSidecar(ctx context.Context, id int) (io.ReadCloser, error)
+	ImportSidecar(ctx context.Context, id int, r io.Reader) error
+
+	SetPinned(ctx context.Context, id int, pinned bool) error
+	ListPinned(ctx context.Context) ([]int, error)
+}
+
+// Usage reports usage of storage quotas shared by all namespaces.
+type Usage interface {
+	Usage(ctx context.Context) (models.UsageReport, error)
+}
+
+type Syncer interface {
+	SyncPeer(ctx context.Context, addr string, opts models.SyncOptions) (models.SyncStats, error)
+}
+
+type adminAPI struct {
+	ssov1.UnimplementedAdminServiceServer
+
+	admin      Namespaces[Admin]
+	syncer     Namespaces[Syncer]
+	usage      Usage
+	allowedIps []string
+}
+
+func RegisterAdmin(
+	gRPC *grpc.Server,
+	admin Namespaces[Admin],
+	syncer Namespaces[Syncer],
+	usage Usage,
+	allowedIps []string,
+) {
+	ssov1.RegisterAdminServiceServer(gRPC, &adminAPI{
+		admin:      admin,
+		syncer:     syncer,
+		usage:      usage,
+		allowedIps: allowedIps,
+	})
+}
+
+func (s *adminAPI) CreateSnapshot(
+	ctx context.Context,
+	req *ssov1.CreateSnapshotRequest,
+) (*ssov1.Snapshot, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := admin.CreateSnapshot(ctx, req.GetName())
+	if err != nil {
+		return nil, snapshotError(err)
+	}
+
+	return snapshotToProto(snapshot), nil
+}
+
+func (s *adminAPI) ListSnapshots(
+	ctx context.Context,
+	req *ssov1.ListSnapshotsRequest,
+) (*ssov1.ListSnapshotsResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := admin.ListSnapshots(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	res := make([]*ssov1.Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		res = append(res, snapshotToProto(snapshot))
+	}
+
+	return &ssov1.ListSnapshotsResponse{Snapshots: res}, nil
+}
+
+func (s *adminAPI) DeleteSnapshot(
+	ctx context.Context,
+	req *ssov1.DeleteSnapshotRequest,
+) (*ssov1.DeleteSnapshotResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := admin.DeleteSnapshot(ctx, req.GetName()); err != nil {
+		return nil, snapshotError(err)
+	}
+
+	return &ssov1.DeleteSnapshotResponse{Success: true}, nil
+}
+
+func (s *adminAPI) RestoreSnapshot(
+	ctx context.Context,
+	req *ssov1.RestoreSnapshotRequest,
+) (*ssov1.RestoreSnapshotResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(req.GetFileIds()))
+	for _, id := range req.GetFileIds() {
+		ids = append(ids, int(id))
+	}
+
+	restored, err := admin.RestoreSnapshot(ctx, req.GetName(), ids)
+	if err != nil {
+		return nil, snapshotError(err)
+	}
+
+	return &ssov1.RestoreSnapshotResponse{Restored: int32(restored)}, nil
+}
+
+func (s *adminAPI) ListFiles(
+	req *ssov1.ListFilesRequest,
+	stream grpc.ServerStreamingServer[ssov1.FileInfo],
+) error {
+	ctx := stream.Context()
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := admin.List(ctx, int(req.GetFromId()), int(req.GetToId()), func(info models.FileInfo) error {
+		return stream.Send(&ssov1.FileInfo{
+			FileId:        int32(info.ID),
+			Size:          info.Size,
+			Sha256:        info.SHA256,
+			SidecarSha256: info.SidecarSHA256,
+		})
+	}); err != nil {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return nil
+}
+
+func (s *adminAPI) PutFile(
+	stream grpc.ClientStreamingServer[ssov1.PutFileRequest, ssov1.PutFileResponse],
+) error {
+	ctx := stream.Context()
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	putStream := &grpcModels.PutFileStreamWrapper{Stream: stream}
+
+	id, err := putStream.FileID()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "file id is not provided")
+	}
+
+	if err := admin.Import(ctx, id, putStream); err != nil {
+		return uploadStatus(err)
+	}
+
+	if err := stream.SendAndClose(&ssov1.PutFileResponse{Size: putStream.Size()}); err != nil {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return nil
+}
+
+func (s *adminAPI) GetSidecar(
+	req *ssov1.GetSidecarRequest,
+	stream grpc.ServerStreamingServer[ssov1.SidecarChunk],
+) error {
+	ctx := stream.Context()
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	sidecar, err := admin.OpenSidecar(ctx, int(req.GetFileId()))
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return status.Error(codes.NotFound, "file not exists")
+		}
+		return status.Error(codes.Internal, "internal server error")
+	}
+	defer sidecar.Close()
+
+	buffer := make([]byte, sidecarChunkLen)
+	for {
+		n, err := io.ReadFull(sidecar, buffer)
+		if n > 0 {
+			if err := stream.Send(&ssov1.SidecarChunk{Chunk: buffer[:n]}); err != nil {
+				return status.Error(codes.Internal, "internal server error")
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return status.Error(codes.Internal, "internal server error")
+		}
+	}
+}
+
+func (s *adminAPI) PutSidecar(
+	stream grpc.ClientStreamingServer[ssov1.PutSidecarRequest, ssov1.PutSidecarResponse],
+) error {
+	ctx := stream.Context()
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	putStream := &grpcModels.PutSidecarStreamWrapper{Stream: stream}
+
+	id, err := putStream.FileID()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "file id is not provided")
+	}
+
+	if err := admin.ImportSidecar(ctx, id, putStream); err != nil {
+		switch {
+		case errors.Is(err, service.ErrReadOnly):
+			return status.Error(codes.FailedPrecondition, "storage is read-only")
+		case errors.Is(err, service.ErrFileNotExist):
+			return status.Error(codes.NotFound, "file not exists")
+		case errors.Is(err, service.ErrInvalidArchive):
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	if err := stream.SendAndClose(&ssov1.PutSidecarResponse{}); err != nil {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return nil
+}
+
+func (s *adminAPI) Sync(
+	ctx context.Context,
+	req *ssov1.SyncRequest,
+) (*ssov1.SyncResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	syncer, err := s.syncer.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetPeer() == "" {
+		return nil, status.Error(codes.InvalidArgument, "peer is required")
+	}
+
+	var direction models.SyncDirection
+	switch req.GetDirection() {
+	case ssov1.SyncDirection_SYNC_DIRECTION_PULL:
+		direction = models.SyncPull
+	case ssov1.SyncDirection_SYNC_DIRECTION_PUSH:
+		direction = models.SyncPush
+	case ssov1.SyncDirection_SYNC_DIRECTION_BOTH:
+		direction = models.SyncBoth
+	default:
+		return nil, status.Error(codes.InvalidArgument, "direction is required")
+	}
+
+	// Peer is synchronized in the same namespace.
+	ctx = metadata.AppendToOutgoingContext(ctx, NamespaceKey, namespace(ctx))
+
+	stats, err := syncer.SyncPeer(ctx, req.GetPeer(), models.SyncOptions{
+		Direction: direction,
+		Mirror:    req.GetMirror(),
+		DryRun:    req.GetDryRun(),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSyncOptions) {
+			return nil, status.Error(codes.InvalidArgument, "mirror is not allowed for both directions")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.SyncResponse{
+		Pulled:        int32(stats.Pulled),
+		Pushed:        int32(stats.Pushed),
+		DeletedLocal:  int32(stats.DeletedLocal),
+		DeletedRemote: int32(stats.DeletedRemote),
+		Conflicts:     int32(stats.Conflicts),
+		Bytes:         stats.Bytes,
+	}, nil
+}
+
+func (s *adminAPI) SetPinned(
+	ctx context.Context,
+	req *ssov1.SetPinnedRequest,
+) (*ssov1.SetPinnedResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := admin.SetPinned(ctx, int(req.GetFileId()), req.GetPinned()); err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		if errors.Is(err, service.ErrReadOnly) {
+			return nil, status.Error(codes.FailedPrecondition, "storage is read-only")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.SetPinnedResponse{}, nil
+}
+
+func (s *adminAPI) ListPinned(
+	ctx context.Context,
+	req *ssov1.ListPinnedRequest,
+) (*ssov1.ListPinnedResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	admin, err := s.admin.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := admin.ListPinned(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	res := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, int32(id))
+	}
+
+	return &ssov1.ListPinnedResponse{FileIds: res}, nil
+}
+
+func (s *adminAPI) GetUsage(
+	ctx context.Context,
+	req *ssov1.GetUsageRequest,
+) (*ssov1.GetUsageResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	report, err := s.usage.Usage(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	quotas := make([]*ssov1.Quota, 0, len(report.Quotas))
+	for _, q := range report.Quotas {
+		quotas = append(quotas, &ssov1.Quota{
+			Scope:    ssov1.QuotaScope(q.Scope),
+			Name:     q.Name,
+			Bytes:    q.Usage.Bytes,
+			Files:    int32(q.Usage.Files),
+			MaxBytes: q.Limit.Bytes,
+			MaxFiles: int32(q.Limit.Files),
+		})
+	}
+
+	return &ssov1.GetUsageResponse{
+		Quotas:      quotas,
+		DiskFree:    report.DiskFree,
+		DiskReserve: report.DiskReserve,
+	}, nil
+}
+
+func snapshotError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidSnapshotName):
+		return status.Error(codes.InvalidArgument, "invalid snapshot name")
+	case errors.Is(err, service.ErrSnapshotExists):
+		return status.Error(codes.AlreadyExists, "snapshot already exists")
+	case errors.Is(err, service.ErrSnapshotNotExist):
+		return status.Error(codes.NotFound, "snapshot not exists")
+	case errors.Is(err, service.ErrFileNotExist):
+		return status.Error(codes.NotFound, "file not exists")
+	case errors.Is(err, service.ErrReadOnly):
+		return status.Error(codes.FailedPrecondition, "storage is read-only")
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+func snapshotToProto(snapshot models.Snapshot) *ssov1.Snapshot {
+	return &ssov1.Snapshot{
+		Name:      snapshot.Name,
+		CreatedAt: timestamppb.New(snapshot.CreatedAt),
+		Files:     int32(snapshot.Files),
+		Size:      snapshot.Size,
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+)
+
+func (s *serverAPI) PutAttachment(
+	ctx context.Context,
+	req *ssov1.PutAttachmentRequest,
+) (*ssov1.Attachment, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := storage.PutAttachment(ctx, int(req.GetFileId()), req.GetName(), req.GetData())
+	if err != nil {
+		return nil, attachmentError(err)
+	}
+
+	return attachmentToProto(attachment), nil
+}
+
+func (s *serverAPI) GetAttachment(
+	ctx context.Context,
+	req *ssov1.GetAttachmentRequest,
+) (*ssov1.Attachment, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := storage.Attachment(ctx, int(req.GetFileId()), req.GetName())
+	if err != nil {
+		return nil, attachmentError(err)
+	}
+
+	return attachmentToProto(attachment), nil
+}
+
+func (s *serverAPI) ListAttachments(
+	ctx context.Context,
+	req *ssov1.ListAttachmentsRequest,
+) (*ssov1.ListAttachmentsResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := storage.Attachments(ctx, int(req.GetFileId()))
+	if err != nil {
+		return nil, attachmentError(err)
+	}
+
+	res := make([]*ssov1.Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		res = append(res, attachmentToProto(a))
+	}
+
+	return &ssov1.ListAttachmentsResponse{Attachments: res}, nil
+}
+
+func (s *serverAPI) DeleteAttachment(
+	ctx context.Context,
+	req *ssov1.DeleteAttachmentRequest,
+) (*ssov1.DeleteAttachmentResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.DeleteAttachment(ctx, int(req.GetFileId()), req.GetName()); err != nil {
+		return nil, attachmentError(err)
+	}
+
+	return &ssov1.DeleteAttachmentResponse{}, nil
+}
+
+func attachmentError(err error) error {
+	if errors.Is(err, service.ErrFileNotExist) {
+		return status.Error(codes.NotFound, "file not exists")
+	}
+	if errors.Is(err, service.ErrAttachmentNotExist) {
+		return status.Error(codes.NotFound, "attachment not exists")
+	}
+	if errors.Is(err, service.ErrInvalidAttachmentName) || errors.Is(err, service.ErrAttachmentTooLarge) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, service.ErrReadOnly) {
+		return status.Error(codes.FailedPrecondition, "storage is read-only")
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) || errors.Is(err, service.ErrInsufficientSpace) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func attachmentToProto(a models.Attachment) *ssov1.Attachment {
+	return &ssov1.Attachment{
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		ModifiedAt:  timestamppb.New(a.ModifiedAt),
+		Data:        a.Data,
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+)
+
+func (s *serverAPI) SetLabels(
+	ctx context.Context,
+	req *ssov1.SetLabelsRequest,
+) (*ssov1.SetLabelsResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetLabels(ctx, int(req.GetFileId()), req.GetLabels()); err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		if errors.Is(err, service.ErrInvalidLabels) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrReadOnly) {
+			return nil, status.Error(codes.FailedPrecondition, "storage is read-only")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.SetLabelsResponse{}, nil
+}
+
+func (s *serverAPI) GetLabels(
+	ctx context.Context,
+	req *ssov1.GetLabelsRequest,
+) (*ssov1.GetLabelsResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := storage.Labels(ctx, int(req.GetFileId()))
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.GetLabelsResponse{Labels: labels}, nil
+}
+
+func (s *serverAPI) Find(
+	ctx context.Context,
+	req *ssov1.FindRequest,
+) (*ssov1.FindResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := storage.Find(ctx, models.FindQuery{
+		Selector: req.GetSelector(),
+		AfterID:  int(req.GetAfterId()),
+		Limit:    int(req.GetLimit()),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFindQuery) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	res := make([]*ssov1.LabeledFile, 0, len(files))
+	for _, f := range files {
+		res = append(res, &ssov1.LabeledFile{
+			FileId: int32(f.FileID),
+			Labels: f.Labels,
+		})
+	}
+
+	return &ssov1.FindResponse{Files: res}, nil
+}
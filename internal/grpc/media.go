@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/waveform"
+	"radio-storage/internal/service"
+)
+
+type Media interface {
+	Metadata(ctx context.Context, id int) (models.Metadata, error)
+	Waveform(ctx context.Context, id int, samplesPerPixel int) (*waveform.Waveform, error)
+	CuePoints(ctx context.Context, id int) (models.Cues, error)
+	SetCueOverride(ctx context.Context, id int, override models.CueOverride) (models.Cues, error)
+	FindSimilar(ctx context.Context, query models.SimilarQuery) ([]models.SimilarFile, error)
+	Artwork(ctx context.Context, id int, size int) (models.Artwork, error)
+}
+
+type mediaAPI struct {
+	ssov1.UnimplementedMediaServiceServer
+
+	media      Namespaces[Media]
+	allowedIps []string
+}
+
+func RegisterMedia(
+	gRPC *grpc.Server,
+	media Namespaces[Media],
+	allowedIps []string,
+) {
+	ssov1.RegisterMediaServiceServer(gRPC, &mediaAPI{
+		media:      media,
+		allowedIps: allowedIps,
+	})
+}
+
+func (s *mediaAPI) GetMetadata(
+	ctx context.Context,
+	req *ssov1.GetMetadataRequest,
+) (*ssov1.Metadata, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	media, err := s.media.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := media.Metadata(ctx, int(req.GetFileId()))
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	var mode ssov1.BitrateMode
+	switch metadata.BitrateMode {
+	case models.BitrateCBR:
+		mode = ssov1.BitrateMode_BITRATE_MODE_CBR
+	case models.BitrateVBR:
+		mode = ssov1.BitrateMode_BITRATE_MODE_VBR
+	case models.BitrateABR:
+		mode = ssov1.BitrateMode_BITRATE_MODE_ABR
+	}
+
+	res := &ssov1.Metadata{
+		FileId:      req.GetFileId(),
+		Title:       metadata.Title,
+		Artist:      metadata.Artist,
+		Album:       metadata.Album,
+		Year:        metadata.Year,
+		HasCover:    metadata.HasCover,
+		Duration:    durationpb.New(metadata.Duration),
+		Bitrate:     int32(metadata.Bitrate),
+		BitrateMode: mode,
+		SampleRate:  int32(metadata.SampleRate),
+		Channels:    int32(metadata.Channels),
+		Format:      metadata.Format,
+		ContentType: metadata.ContentType,
+	}
+	if l := metadata.Loudness; l != nil {
+		res.Loudness = &ssov1.Loudness{
+			Integrated: l.Integrated,
+			Range:      l.Range,
+			TruePeak:   l.TruePeak,
+			TrackGain:  l.TrackGain,
+		}
+	}
+	if tk := metadata.TempoKey; tk != nil {
+		res.TempoKey = &ssov1.TempoKey{
+			Bpm:     tk.BPM,
+			Key:     tk.Key,
+			Camelot: tk.Camelot,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *mediaAPI) GetWaveform(
+	ctx context.Context,
+	req *ssov1.GetWaveformRequest,
+) (*ssov1.Waveform, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	media, err := s.media.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var f waveform.Format
+	switch req.GetFormat() {
+	case ssov1.WaveformFormat_WAVEFORM_FORMAT_JSON:
+		f = waveform.JSON
+	case ssov1.WaveformFormat_WAVEFORM_FORMAT_BINARY:
+		f = waveform.Binary
+	default:
+		return nil, status.Error(codes.InvalidArgument, "unknown waveform format")
+	}
+
+	w, err := media.Waveform(ctx, int(req.GetFileId()), int(req.GetResolution()))
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		if errors.Is(err, service.ErrInvalidResolution) {
+			return nil, status.Error(codes.InvalidArgument, "invalid resolution")
+		}
+		if errors.Is(err, service.ErrConversionUnsupported) {
+			return nil, status.Error(codes.FailedPrecondition, "waveform is not supported for file format")
+		}
+		if errors.Is(err, service.ErrDecodeFailed) {
+			return nil, status.Error(codes.FailedPrecondition, "failed to decode file")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	data, contentType, err := w.Encode(f, int(req.GetBits()))
+	if err != nil {
+		if errors.Is(err, waveform.ErrInvalidBits) {
+			return nil, status.Error(codes.InvalidArgument, "bits must be 8 or 16")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.Waveform{Data: data, ContentType: contentType}, nil
+}
+
+func (s *mediaAPI) GetCuePoints(
+	ctx context.Context,
+	req *ssov1.GetCuePointsRequest,
+) (*ssov1.Cues, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	media, err := s.media.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cues, err := media.CuePoints(ctx, int(req.GetFileId()))
+	if err != nil {
+		return nil, cuesError(err)
+	}
+
+	return cuesToProto(req.GetFileId(), cues), nil
+}
+
+func (s *mediaAPI) SetCuePoints(
+	ctx context.Context,
+	req *ssov1.SetCuePointsRequest,
+) (*ssov1.Cues, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	media, err := s.media.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var override models.CueOverride
+	if o := req.GetOverride(); o != nil {
+		override = models.CueOverride{
+			Start:    durationPtr(o.GetStart()),
+			End:      durationPtr(o.GetEnd()),
+			FadeOut:  durationPtr(o.GetFadeOut()),
+			IntroEnd: durationPtr(o.GetIntroEnd()),
+		}
+	}
+
+	cues, err := media.SetCueOverride(ctx, int(req.GetFileId()), override)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCuePoints) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrReadOnly) {
+			return nil, status.Error(codes.FailedPrecondition, "storage is read-only")
+		}
+		return nil, cuesError(err)
+	}
+
+	return cuesToProto(req.GetFileId(), cues), nil
+}
+
+func (s *mediaAPI) FindSimilar(
+	ctx context.Context,
+	req *ssov1.FindSimilarRequest,
+) (*ssov1.FindSimilarResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	media, err := s.media.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := media.FindSimilar(ctx, models.SimilarQuery{
+		FileID:        int(req.GetFileId()),
+		Clip:          req.GetClip(),
+		MinConfidence: req.GetMinConfidence(),
+		Limit:         int(req.GetLimit()),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSimilarQuery) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		if errors.Is(err, service.ErrConversionUnsupported) {
+			return nil, status.Error(codes.FailedPrecondition, "fingerprint is not supported for file format")
+		}
+		if errors.Is(err, service.ErrDecodeFailed) {
+			return nil, status.Error(codes.FailedPrecondition, "failed to decode file")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	res := make([]*ssov1.SimilarFile, 0, len(files))
+	for _, f := range files {
+		res = append(res, &ssov1.SimilarFile{
+			FileId:     int32(f.FileID),
+			Offset:     durationpb.New(f.Offset),
+			Confidence: f.Confidence,
+		})
+	}
+
+	return &ssov1.FindSimilarResponse{Files: res}, nil
+}
+
+func (s *mediaAPI) GetArtwork(
+	ctx context.Context,
+	req *ssov1.GetArtworkRequest,
+) (*ssov1.Artwork, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	media, err := s.media.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	artwork, err := media.Artwork(ctx, int(req.GetFileId()), int(req.GetSize()))
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		if errors.Is(err, service.ErrArtworkNotExist) {
+			return nil, status.Error(codes.NotFound, "artwork not exists")
+		}
+		if errors.Is(err, service.ErrInvalidArtworkSize) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.Artwork{
+		Data:        artwork.Data,
+		ContentType: artwork.ContentType,
+		Width:       int32(artwork.Width),
+		Height:      int32(artwork.Height),
+	}, nil
+}
+
+func cuesError(err error) error {
+	if errors.Is(err, service.ErrFileNotExist) {
+		return status.Error(codes.NotFound, "file not exists")
+	}
+	if errors.Is(err, service.ErrConversionUnsupported) {
+		return status.Error(codes.FailedPrecondition, "cue points are not supported for file format")
+	}
+	if errors.Is(err, service.ErrDecodeFailed) {
+		return status.Error(codes.FailedPrecondition, "failed to decode file")
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func cuesToProto(id int32, cues models.Cues) *ssov1.Cues {
+	o := cues.Override
+
+	return &ssov1.Cues{
+		FileId:    id,
+		Effective: cuePointsToProto(cues.Effective()),
+		Detected:  cuePointsToProto(cues.Detected),
+		Override: &ssov1.CuePoints{
+			Start:    durationProto(o.Start),
+			End:      durationProto(o.End),
+			FadeOut:  durationProto(o.FadeOut),
+			IntroEnd: durationProto(o.IntroEnd),
+		},
+	}
+}
+
+func cuePointsToProto(c models.CuePoints) *ssov1.CuePoints {
+	return &ssov1.CuePoints{
+		Start:    durationpb.New(c.Start),
+		End:      durationpb.New(c.End),
+		FadeOut:  durationpb.New(c.FadeOut),
+		IntroEnd: durationpb.New(c.IntroEnd),
+	}
+}
+
+func durationPtr(d *durationpb.Duration) *time.Duration {
+	if d == nil {
+		return nil
+	}
+	res := d.AsDuration()
+	return &res
+}
+
+func durationProto(d *time.Duration) *durationpb.Duration {
+	if d == nil {
+		return nil
+	}
+	return durationpb.New(*d)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"radio-storage/internal/domain/models"
+)
+
+// NamespaceKey is metadata key naming namespace of request.
+// Requests without it are served by default namespace.
+const NamespaceKey = "namespace"
+
+// Namespaces maps names of namespaces to their storages.
+type Namespaces[S any] map[string]S
+
+// resolve returns storage of namespace requested by client.
+func (n Namespaces[S]) resolve(ctx context.Context) (S, error) {
+	name := namespace(ctx)
+
+	s, ok := n[name]
+	if !ok {
+		return s, status.Errorf(codes.NotFound, "namespace %q not exists", name)
+	}
+
+	return s, nil
+}
+
+// namespace returns name of namespace requested by client.
+func namespace(ctx context.Context) string {
+	if v := metadata.ValueFromIncomingContext(ctx, NamespaceKey); len(v) != 0 && v[0] != "" {
+		return v[0]
+	}
+	return models.DefaultNamespace
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcModels "radio-storage/internal/domain/grpc"
+	"radio-storage/internal/service"
+)
+
+type Replication interface {
+	Replicate(ctx context.Context, w *grpcModels.ReplicationStreamWrapper) error
+}
+
+type replicationAPI struct {
+	ssov1.UnimplementedReplicationServiceServer
+
+	replication Replication
+	allowedIps  []string
+}
+
+func RegisterReplication(
+	gRPC *grpc.Server,
+	replication Replication,
+	allowedIps []string,
+) {
+	ssov1.RegisterReplicationServiceServer(gRPC, &replicationAPI{
+		replication: replication,
+		allowedIps:  allowedIps,
+	})
+}
+
+func (s *replicationAPI) Replicate(
+	stream grpc.BidiStreamingServer[ssov1.ReplicationAck, ssov1.ReplicationEvent],
+) error {
+	ctx := stream.Context()
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	replicationStream := &grpcModels.ReplicationStreamWrapper{Stream: stream}
+
+	if err := s.replication.Replicate(ctx, replicationStream); err != nil {
+		if errors.Is(err, service.ErrReplicaAhead) {
+			return status.Error(codes.FailedPrecondition, "replica is ahead of primary")
+		}
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"radio-storage/internal/service"
+)
+
+func (s *serverAPI) SetExpiry(
+	ctx context.Context,
+	req *ssov1.SetExpiryRequest,
+) (*ssov1.SetExpiryResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var at time.Time
+	if req.GetExpiresAt() != nil {
+		at = req.GetExpiresAt().AsTime()
+	}
+
+	if err := storage.SetExpiry(ctx, int(req.GetFileId()), at); err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		if errors.Is(err, service.ErrReadOnly) {
+			return nil, status.Error(codes.FailedPrecondition, "storage is read-only")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &ssov1.SetExpiryResponse{}, nil
+}
+
+func (s *serverAPI) GetExpiry(
+	ctx context.Context,
+	req *ssov1.GetExpiryRequest,
+) (*ssov1.GetExpiryResponse, error) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	at, err := storage.Expiry(ctx, int(req.GetFileId()))
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, status.Error(codes.NotFound, "file not exists")
+		}
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	res := &ssov1.GetExpiryResponse{}
+	if !at.IsZero() {
+		res.ExpiresAt = timestamppb.New(at)
+	}
+
+	return res, nil
+}
@@ -3,35 +3,50 @@ package server
 import (
 	"context"
 	"errors"
-	"slices"
-	"strings"
+	"time"
 
 	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	grpcModels "radio-storage/internal/domain/grpc"
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/allowlist"
 	"radio-storage/internal/service"
 )
 
 type Storage interface {
-	Upload(ctx context.Context, w *grpcModels.UploadStreamWrapper) (int, error)
-	Download(ctx context.Context, id int, w *grpcModels.DownloadStreamWrapper) error
+	Upload(ctx context.Context, w *grpcModels.UploadStreamWrapper) (int, string, error)
+	Download(ctx context.Context, id int, opts models.DownloadOptions, w *grpcModels.DownloadStreamWrapper) error
 	Delete(ctx context.Context, fileId int) error
+	DownloadSequence(ctx context.Context, ids []int, w *grpcModels.DownloadSequenceStreamWrapper) error
+
+	PutAttachment(ctx context.Context, id int, name string, data []byte) (models.Attachment, error)
+	Attachment(ctx context.Context, id int, name string) (models.Attachment, error)
+	Attachments(ctx context.Context, id int) ([]models.Attachment, error)
+	DeleteAttachment(ctx context.Context, id int, name string) error
+
+	SetLabels(ctx context.Context, id int, labels map[string]string) error
+	Labels(ctx context.Context, id int) (map[string]string, error)
+	Find(ctx context.Context, query models.FindQuery) ([]models.LabeledFile, error)
+
+	SetExpiry(ctx context.Context, id int, at time.Time) error
+	Expiry(ctx context.Context, id int) (time.Time, error)
 }
 
 type serverAPI struct {
 	ssov1.UnimplementedFileServiceServer
 
-	storage    Storage
+	storage    Namespaces[Storage]
 	allowedIps []string
 }
 
 func Register(
 	gRPC *grpc.Server,
-	storage Storage,
+	storage Namespaces[Storage],
 	allowedIps []string,
 ) {
 	ssov1.RegisterFileServiceServer(gRPC, &serverAPI{
@@ -44,43 +59,138 @@ func (s *serverAPI) Upload(
 	stream grpc.ClientStreamingServer[ssov1.UploadRequest, ssov1.UploadResponse],
 ) error {
 	ctx := stream.Context()
-	p, _ := peer.FromContext(ctx)
-	ip := strings.FieldsFunc(p.Addr.String(), func(r rune) bool { return r == ':' })[0]
-	if !slices.Contains(s.allowedIps, ip) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
 		return status.Error(codes.PermissionDenied, "ip is not allowed")
 	}
 
-	uploadStream := &grpcModels.UploadStreamWrapper{Stream: stream}
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	client, _ := peerIP(ctx)
+	uploadStream := &grpcModels.UploadStreamWrapper{Stream: stream, Client: client}
 
-	id, err := s.storage.Upload(ctx, uploadStream)
+	id, contentType, err := storage.Upload(ctx, uploadStream)
 	if err != nil {
-		return status.Error(codes.Internal, "internal server error")
+		return uploadStatus(err)
 	}
 
-	if err := stream.SendAndClose(&ssov1.UploadResponse{FileId: int32(id)}); err != nil {
+	if err := stream.SendAndClose(&ssov1.UploadResponse{FileId: int32(id), ContentType: contentType}); err != nil {
 		return status.Error(codes.Internal, "internal server error")
 	}
 
 	return nil
 }
 
+// uploadStatus returns status of rejected upload or import.
+func uploadStatus(err error) error {
+	if errors.Is(err, service.ErrReadOnly) {
+		return status.Error(codes.FailedPrecondition, "storage is read-only")
+	}
+	if errors.Is(err, service.ErrFormatNotAllowed) {
+		return status.Error(codes.InvalidArgument, "file format is not allowed")
+	}
+	if errors.Is(err, service.ErrInvalidLabels) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) || errors.Is(err, service.ErrInsufficientSpace) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationStatus(validationErr)
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
+
 func (s *serverAPI) Download(
 	req *ssov1.DownloadRequest,
 	stream grpc.ServerStreamingServer[ssov1.DownloadResponse],
 ) error {
 	ctx := stream.Context()
-	p, _ := peer.FromContext(ctx)
-	ip := strings.FieldsFunc(p.Addr.String(), func(r rune) bool { return r == ':' })[0]
-	if !slices.Contains(s.allowedIps, ip) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
 		return status.Error(codes.PermissionDenied, "ip is not allowed")
 	}
 
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
 	downloadStream := &grpcModels.DownloadStreamWrapper{Stream: stream}
 
-	if err := s.storage.Download(ctx, int(req.GetFileId()), downloadStream); err != nil {
+	opts := models.DownloadOptions{
+		Output:       models.OutputFormat(req.GetOutputFormat()),
+		SampleRate:   int(req.GetSampleRate()),
+		Channels:     int(req.GetChannels()),
+		TrimSilence:  req.GetTrimSilence(),
+		StripArtwork: req.GetStripArtwork(),
+		TagMode:      models.TagMode(req.GetTagMode()),
+		Tags: models.Tags{
+			Title:  req.GetTags().GetTitle(),
+			Artist: req.GetTags().GetArtist(),
+			Album:  req.GetTags().GetAlbum(),
+			Year:   req.GetTags().GetYear(),
+		},
+	}
+
+	if err := storage.Download(ctx, int(req.GetFileId()), opts, downloadStream); err != nil {
 		if errors.Is(err, service.ErrFileNotExist) {
 			return status.Error(codes.NotFound, "file not exists")
 		}
+		if errors.Is(err, service.ErrInvalidDownloadOptions) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrConversionUnsupported) {
+			return status.Error(codes.FailedPrecondition, "conversion is not supported for file format")
+		}
+		if errors.Is(err, service.ErrDecodeFailed) {
+			return status.Error(codes.FailedPrecondition, "failed to decode file")
+		}
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return nil
+}
+
+func (s *serverAPI) DownloadSequence(
+	req *ssov1.DownloadSequenceRequest,
+	stream grpc.ServerStreamingServer[ssov1.DownloadSequenceResponse],
+) error {
+	ctx := stream.Context()
+	if !isAllowedPeer(ctx, s.allowedIps) {
+		return status.Error(codes.PermissionDenied, "ip is not allowed")
+	}
+
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, len(req.GetFileIds()))
+	for _, id := range req.GetFileIds() {
+		ids = append(ids, int(id))
+	}
+
+	sequenceStream := &grpcModels.DownloadSequenceStreamWrapper{Stream: stream}
+
+	if err := storage.DownloadSequence(ctx, ids, sequenceStream); err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, service.ErrInvalidSequence) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrSampleRateMismatch) {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(err, service.ErrConversionUnsupported) {
+			return status.Error(codes.FailedPrecondition, "sequence of non-MP3 files is not supported")
+		}
+		if errors.Is(err, service.ErrDecodeFailed) {
+			return status.Error(codes.FailedPrecondition, "failed to parse file")
+		}
 		return status.Error(codes.Internal, "internal server error")
 	}
 
@@ -91,18 +201,65 @@ func (s *serverAPI) Delete(
 	ctx context.Context,
 	req *ssov1.DeleteRequest,
 ) (*ssov1.DeleteResponse, error) {
-	p, _ := peer.FromContext(ctx)
-	ip := strings.FieldsFunc(p.Addr.String(), func(r rune) bool { return r == ':' })[0]
-	if !slices.Contains(s.allowedIps, ip) {
+	if !isAllowedPeer(ctx, s.allowedIps) {
 		return nil, status.Error(codes.PermissionDenied, "ip is not allowed")
 	}
 
-	if err := s.storage.Delete(ctx, int(req.GetFileId())); err != nil {
+	storage, err := s.storage.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.Delete(ctx, int(req.GetFileId())); err != nil {
 		if errors.Is(err, service.ErrFileNotExist) {
 			return nil, status.Error(codes.NotFound, "file not exists")
 		}
+		if errors.Is(err, service.ErrReadOnly) {
+			return nil, status.Error(codes.FailedPrecondition, "storage is read-only")
+		}
 		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
 	return &ssov1.DeleteResponse{Success: true}, nil
 }
+
+// validationStatus returns InvalidArgument status
+// with violated rules as details.
+func validationStatus(err *service.ValidationError) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(err.Violations))
+	for _, v := range err.Violations {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Rule,
+			Description: v.Description,
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(&errdetails.BadRequest{
+		FieldViolations: violations,
+	})
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return st.Err()
+}
+
+// isAllowedPeer checks if request came from allowed ip.
+func isAllowedPeer(ctx context.Context, allowedIps []string) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	return allowlist.Allowed(p.Addr.String(), allowedIps)
+}
+
+// peerIP returns address of client without port.
+func peerIP(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	return allowlist.Host(p.Addr.String()), true
+}
@@ -0,0 +1,24 @@
+// Package allowlist checks client addresses
+// against list of allowed ips.
+package allowlist
+
+import (
+	"net"
+	"slices"
+)
+
+// Host returns address without port.
+// Address without port, like one of in-memory
+// listener, is returned as is.
+func Host(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
+
+// Allowed reports whether host of the address is in ips.
+func Allowed(addr string, ips []string) bool {
+	return slices.Contains(ips, Host(addr))
+}
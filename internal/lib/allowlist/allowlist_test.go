@@ -0,0 +1,18 @@
+package allowlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowed(t *testing.T) {
+	ips := []string{"127.0.0.1", "::1", "bufconn"}
+
+	require.True(t, Allowed("127.0.0.1:5000", ips))
+	require.True(t, Allowed("[::1]:5000", ips))
+	require.True(t, Allowed("bufconn", ips))
+
+	require.False(t, Allowed("10.0.0.1:5000", ips))
+	require.False(t, Allowed("", ips))
+}
@@ -0,0 +1,113 @@
+// Package artwork decodes cover art and scales it down
+// to standard sizes.
+package artwork
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"slices"
+)
+
+// Quality is JPEG quality of scaled images.
+const Quality = 85
+
+// Sizes are standard sizes of artwork,
+// which is scaled to fit a square of the size.
+var Sizes = []int{100, 300, 600}
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// IsSize reports whether size is a standard one.
+func IsSize(size int) bool {
+	return slices.Contains(Sizes, size)
+}
+
+// Decode decodes JPEG or PNG image and returns its MIME type.
+func Decode(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	return img, "image/" + format, nil
+}
+
+// Fit returns dimensions of image scaled to fit
+// a square of given size. Images are never scaled up.
+func Fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+
+	if width >= height {
+		return size, max(1, (height*size+width/2)/width)
+	}
+	return max(1, (width*size+height/2)/height), size
+}
+
+// Scale returns image scaled to fit a square of given size
+// encoded as JPEG, and its dimensions.
+func Scale(img image.Image, size int) ([]byte, int, int, error) {
+	b := img.Bounds()
+	width, height := Fit(b.Dx(), b.Dy(), size)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resize(img, width, height), &jpeg.Options{Quality: Quality}); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return buf.Bytes(), width, height, nil
+}
+
+// resize scales image down by averaging source pixels
+// covered by every destination pixel.
+func resize(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+
+	// Working on RGBA avoids converting colors for every access.
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		src = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[(sy-src.Rect.Min.Y)*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[4*(sx-src.Rect.Min.X):]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					bl += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(bl / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+
+	return dst
+}
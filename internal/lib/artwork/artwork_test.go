@@ -0,0 +1,72 @@
+package artwork
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height, size int
+		wantW, wantH        int
+	}{
+		{1000, 1000, 300, 300, 300},
+		{1200, 600, 300, 300, 150},
+		{600, 1200, 100, 50, 100},
+		{200, 150, 300, 200, 150},
+		{1000, 1, 100, 100, 1},
+	}
+
+	for _, tt := range tests {
+		w, h := Fit(tt.width, tt.height, tt.size)
+		require.Equal(t, tt.wantW, w)
+		require.Equal(t, tt.wantH, h)
+	}
+}
+
+func TestScale(t *testing.T) {
+	// Left half red, right half blue.
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 200 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	img, mime, err := Decode(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "image/png", mime)
+
+	data, w, h, err := Scale(img, 100)
+	require.NoError(t, err)
+	require.Equal(t, 100, w)
+	require.Equal(t, 50, h)
+
+	res, mime, err := Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", mime)
+	require.Equal(t, image.Rect(0, 0, 100, 50), res.Bounds())
+
+	r, _, b, _ := res.At(10, 25).RGBA()
+	require.Greater(t, r, b)
+	r, _, b, _ = res.At(90, 25).RGBA()
+	require.Less(t, r, b)
+
+	require.True(t, IsSize(300))
+	require.False(t, IsSize(301))
+
+	_, _, err = Decode([]byte("not an image"))
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
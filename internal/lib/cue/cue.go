@@ -0,0 +1,173 @@
+// Package cue detects silence and segue points of audio.
+package cue
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// SilenceThreshold is RMS level below which audio is silent, dBFS.
+	SilenceThreshold = -60.0
+	// BodyTolerance is how far below typical level of the track
+	// audio may stay and still be considered its body, dB.
+	BodyTolerance = 3.0
+
+	windowLen = 10 * time.Millisecond
+	// blockWindows is number of windows in block
+	// used to follow track level.
+	blockWindows = 25
+
+	bufferFrames = 4096
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Points are detected cue points of audio.
+type Points struct {
+	// Start is end of leading silence.
+	Start time.Duration
+	// End is start of trailing silence.
+	End time.Duration
+	// FadeOut is where level starts falling
+	// below the body of the track before End.
+	FadeOut time.Duration
+	// IntroEnd is where level first reaches
+	// the body of the track after Start.
+	IntroEnd time.Duration
+}
+
+// Detector accumulates audio and detects its cue points.
+type Detector struct {
+	channels      int
+	windowSamples int
+
+	energy float64
+	count  int
+
+	// windows hold mean energy of every window.
+	windows []float64
+}
+
+// NewDetector returns detector of audio
+// with given sample rate and channels.
+func NewDetector(sampleRate, channels int) (*Detector, error) {
+	windowSamples := int(int64(sampleRate) * int64(windowLen) / int64(time.Second))
+	if windowSamples == 0 || channels < 1 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	return &Detector{
+		channels:      channels,
+		windowSamples: windowSamples,
+	}, nil
+}
+
+// Write adds interleaved 16-bit samples.
+func (d *Detector) Write(samples []int16) {
+	for i := 0; i+d.channels <= len(samples); i += d.channels {
+		for _, s := range samples[i : i+d.channels] {
+			x := float64(s) / 32768
+			d.energy += x * x
+		}
+		d.count++
+
+		if d.count == d.windowSamples {
+			d.flush()
+		}
+	}
+}
+
+// ReadFrom reads 16-bit signed little-endian
+// interleaved PCM until EOF.
+func (d *Detector) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, bufferFrames*2*d.channels)
+	samples := make([]int16, bufferFrames*d.channels)
+
+	var total int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		total += int64(n)
+
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+		}
+		d.Write(samples[:n])
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return total, nil
+		case err != nil:
+			return total, err
+		}
+	}
+}
+
+func (d *Detector) flush() {
+	d.windows = append(d.windows, d.energy/float64(d.count*d.channels))
+	d.energy, d.count = 0, 0
+}
+
+// Points returns cue points of audio written so far.
+// All points are zero for silent audio.
+func (d *Detector) Points() Points {
+	if d.count > 0 {
+		d.flush()
+	}
+
+	first := slices.IndexFunc(d.windows, loud)
+	if first < 0 {
+		return Points{}
+	}
+	last := len(d.windows) - 1
+	for !loud(d.windows[last]) {
+		last--
+	}
+
+	p := Points{
+		Start: time.Duration(first) * windowLen,
+		End:   time.Duration(last+1) * windowLen,
+	}
+
+	// Follow level of the track in blocks
+	// between leading and trailing silence.
+	blocks := make([]float64, 0, (last-first)/blockWindows+1)
+	for i := first; i <= last; i += blockWindows {
+		var sum float64
+		n := min(blockWindows, last+1-i)
+		for _, e := range d.windows[i : i+n] {
+			sum += e
+		}
+		blocks = append(blocks, level(sum/float64(n)))
+	}
+
+	sorted := slices.Clone(blocks)
+	slices.Sort(sorted)
+	body := sorted[len(sorted)/2] - BodyTolerance
+	inBody := func(l float64) bool { return l >= body }
+
+	intro := slices.IndexFunc(blocks, inBody)
+	fade := len(blocks) - 1
+	for !inBody(blocks[fade]) {
+		fade--
+	}
+
+	p.IntroEnd = p.Start + time.Duration(intro*blockWindows)*windowLen
+	p.FadeOut = min(p.Start+time.Duration((fade+1)*blockWindows)*windowLen, p.End)
+
+	return p
+}
+
+func loud(energy float64) bool {
+	return level(energy) > SilenceThreshold
+}
+
+// level converts mean energy to dBFS.
+func level(energy float64) float64 {
+	return 10 * math.Log10(energy)
+}
@@ -0,0 +1,94 @@
+package cue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const rate = 8000
+
+// tone returns mono sine with amplitude changing
+// linearly from one level to another, dBFS.
+func tone(d time.Duration, from, to float64) []int16 {
+	n := int(d.Seconds() * rate)
+	res := make([]int16, n)
+	for i := range res {
+		amp := math.Pow(10, from/20) + (math.Pow(10, to/20)-math.Pow(10, from/20))*float64(i)/float64(n)
+		res[i] = int16(32767 * amp * math.Sin(2*math.Pi*440*float64(i)/rate))
+	}
+	return res
+}
+
+func silence(d time.Duration) []int16 {
+	return make([]int16, int(d.Seconds()*rate))
+}
+
+func detect(t *testing.T, parts ...[]int16) Points {
+	t.Helper()
+
+	d, err := NewDetector(rate, 1)
+	require.NoError(t, err)
+	for _, p := range parts {
+		d.Write(p)
+	}
+
+	return d.Points()
+}
+
+func requireNear(t *testing.T, want, got, delta time.Duration) {
+	t.Helper()
+	require.InDelta(t, want.Seconds(), got.Seconds(), delta.Seconds(), "want %v, got %v", want, got)
+}
+
+func TestPoints(t *testing.T) {
+	p := detect(t,
+		silence(time.Second),
+		tone(2*time.Second, -30, -30),
+		tone(10*time.Second, -10, -10),
+		tone(3*time.Second, -10, -200),
+		silence(time.Second),
+	)
+
+	requireNear(t, time.Second, p.Start, 10*time.Millisecond)
+	requireNear(t, 3*time.Second, p.IntroEnd, 300*time.Millisecond)
+	requireNear(t, 13*time.Second, p.FadeOut, time.Second)
+	require.Greater(t, p.FadeOut, 13*time.Second-300*time.Millisecond)
+	require.Less(t, p.End, 16*time.Second)
+	require.Greater(t, p.End, 15*time.Second)
+}
+
+func TestPointsWithoutSilence(t *testing.T) {
+	p := detect(t, tone(5*time.Second, -10, -10))
+
+	require.Equal(t, time.Duration(0), p.Start)
+	require.Equal(t, time.Duration(0), p.IntroEnd)
+	require.Equal(t, 5*time.Second, p.End)
+	require.Equal(t, 5*time.Second, p.FadeOut)
+}
+
+func TestSilence(t *testing.T) {
+	require.Equal(t, Points{}, detect(t, silence(3*time.Second)))
+	require.Equal(t, Points{}, detect(t))
+}
+
+func TestReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	for _, s := range append(silence(time.Second), tone(time.Second, -10, -10)...) {
+		binary.Write(&buf, binary.LittleEndian, s)
+		binary.Write(&buf, binary.LittleEndian, s)
+	}
+
+	d, err := NewDetector(rate, 2)
+	require.NoError(t, err)
+	_, err = d.ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, Points{Start: time.Second, End: 2 * time.Second, FadeOut: 2 * time.Second, IntroEnd: time.Second}, d.Points())
+
+	_, err = NewDetector(50, 1)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,25 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	x := make([]complex128, 8)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*float64(i)/8), 0)
+	}
+	Transform(x)
+
+	for k, v := range x {
+		want := 0.0
+		if k == 1 || k == 7 {
+			want = 4
+		}
+		require.InDelta(t, want, cmplx.Abs(v), 1e-9, k)
+	}
+}
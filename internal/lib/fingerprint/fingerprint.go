@@ -0,0 +1,247 @@
+// Package fingerprint computes acoustic fingerprints of audio,
+// which survive re-encoding, and finds matching ones.
+//
+// Fingerprint is a sequence of 32-bit subfingerprints, one per
+// frame, every bit being sign of energy difference of adjacent
+// frequency bands changing over time, as proposed by Haitsma
+// and Kalker in "A Highly Robust Audio Fingerprinting System".
+package fingerprint
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+
+	"radio-storage/internal/lib/fft"
+)
+
+const (
+	// SampleRate is rate audio is downsampled to.
+	SampleRate = 5512
+	// frameLen is length of analysed frame, 0.37 s.
+	frameLen = 2048
+	// hopLen is distance between frames, about 93 ms.
+	hopLen = 512
+
+	bands   = 33
+	minFreq = 300.0
+	maxFreq = 2000.0
+
+	bufferFrames = 4096
+)
+
+// FrameDuration is time between subfingerprints.
+const FrameDuration = time.Duration(hopLen) * time.Second / SampleRate
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	ErrInvalidData       = errors.New("invalid fingerprint data")
+)
+
+// Fingerprint is a sequence of subfingerprints.
+type Fingerprint []uint32
+
+// Duration returns duration of fingerprinted audio.
+func (f Fingerprint) Duration() time.Duration {
+	return time.Duration(len(f)) * FrameDuration
+}
+
+// MarshalBinary encodes fingerprint as little-endian values.
+func (f Fingerprint) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 4*len(f))
+	for _, v := range f {
+		b = binary.LittleEndian.AppendUint32(b, v)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes fingerprint encoded by MarshalBinary.
+func (f *Fingerprint) UnmarshalBinary(b []byte) error {
+	if len(b)%4 != 0 {
+		return ErrInvalidData
+	}
+
+	res := make(Fingerprint, len(b)/4)
+	for i := range res {
+		res[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	*f = res
+
+	return nil
+}
+
+// BitErrorRate returns share of differing bits of f and other
+// starting offset subfingerprints later, and number of compared
+// subfingerprints. Offset may be negative.
+func (f Fingerprint) BitErrorRate(other Fingerprint, offset int) (float64, int) {
+	a, b := f, other
+	if offset >= 0 {
+		b = b[min(offset, len(b)):]
+	} else {
+		a = a[min(-offset, len(a)):]
+	}
+
+	n := min(len(a), len(b))
+	if n == 0 {
+		return 1, 0
+	}
+
+	var errs int
+	for i := 0; i < n; i++ {
+		errs += bits.OnesCount32(a[i] ^ b[i])
+	}
+
+	return float64(errs) / float64(32*n), n
+}
+
+// Builder computes fingerprint of PCM audio.
+// Channels are mixed to mono by averaging.
+type Builder struct {
+	channels int
+	// ratio is number of input samples per downsampled one.
+	ratio float64
+
+	// sum and count accumulate input samples of
+	// current downsampled one, pos is its end.
+	sum   float64
+	count int
+	pos   float64
+	in    float64
+
+	window []float64
+	// edges are FFT bins bounding bands.
+	edges []int
+
+	samples []float64
+	spec    []complex128
+	prev    []float64
+	energy  []float64
+
+	fp Fingerprint
+}
+
+// NewBuilder returns builder of audio
+// with given sample rate and channels.
+func NewBuilder(sampleRate, channels int) (*Builder, error) {
+	if sampleRate < SampleRate || channels < 1 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	b := &Builder{
+		channels: channels,
+		ratio:    float64(sampleRate) / SampleRate,
+		window:   make([]float64, frameLen),
+		edges:    make([]int, bands+1),
+		spec:     make([]complex128, frameLen),
+		energy:   make([]float64, bands),
+	}
+	b.pos = b.ratio
+
+	// Hann window.
+	for i := range b.window {
+		b.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/frameLen)
+	}
+
+	// Logarithmically spaced bands.
+	for i := range b.edges {
+		freq := minFreq * math.Pow(maxFreq/minFreq, float64(i)/bands)
+		b.edges[i] = int(math.Round(freq * frameLen / SampleRate))
+	}
+
+	return b, nil
+}
+
+// Write adds interleaved 16-bit samples.
+func (b *Builder) Write(samples []int16) {
+	for i := 0; i+b.channels <= len(samples); i += b.channels {
+		var sum int
+		for _, s := range samples[i : i+b.channels] {
+			sum += int(s)
+		}
+
+		// Box filter suppresses frequencies
+		// aliased by downsampling.
+		b.sum += float64(sum) / float64(b.channels)
+		b.count++
+		b.in++
+		if b.in >= b.pos {
+			b.pos += b.ratio
+			b.add(b.sum / float64(b.count))
+			b.sum, b.count = 0, 0
+		}
+	}
+}
+
+// ReadFrom reads 16-bit signed little-endian
+// interleaved PCM until EOF.
+func (b *Builder) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, bufferFrames*2*b.channels)
+	samples := make([]int16, bufferFrames*b.channels)
+
+	var total int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		total += int64(n)
+
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+		}
+		b.Write(samples[:n])
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return total, nil
+		case err != nil:
+			return total, err
+		}
+	}
+}
+
+// Fingerprint returns fingerprint of audio written so far.
+// Audio shorter than a frame has empty fingerprint.
+func (b *Builder) Fingerprint() Fingerprint {
+	return b.fp
+}
+
+// add appends downsampled sample and
+// processes frame once it is complete.
+func (b *Builder) add(v float64) {
+	b.samples = append(b.samples, v)
+	if len(b.samples) < frameLen {
+		return
+	}
+
+	for i, s := range b.samples {
+		b.spec[i] = complex(s*b.window[i], 0)
+	}
+	fft.Transform(b.spec)
+
+	for i := range b.energy {
+		var e float64
+		for k := b.edges[i]; k < b.edges[i+1]; k++ {
+			re, im := real(b.spec[k]), imag(b.spec[k])
+			e += re*re + im*im
+		}
+		b.energy[i] = e
+	}
+
+	if b.prev != nil {
+		var v uint32
+		for m := 0; m < bands-1; m++ {
+			d := b.energy[m] - b.energy[m+1] - (b.prev[m] - b.prev[m+1])
+			if d > 0 {
+				v |= 1 << (31 - m)
+			}
+		}
+		b.fp = append(b.fp, v)
+	} else {
+		b.prev = make([]float64, bands)
+	}
+	copy(b.prev, b.energy)
+
+	b.samples = append(b.samples[:0], b.samples[hopLen:]...)
+}
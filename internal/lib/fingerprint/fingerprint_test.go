@@ -0,0 +1,136 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSampleRate = 44100
+
+// melody returns seconds of mono audio of random notes
+// with harmonics, every seed giving other tune.
+func melody(seed int64, seconds int) []float64 {
+	rnd := rand.New(rand.NewSource(seed))
+
+	samples := make([]float64, seconds*testSampleRate)
+	noteLen := testSampleRate / 4
+	for start := 0; start < len(samples); start += noteLen {
+		freq := 220 * math.Pow(2, float64(rnd.Intn(36))/12)
+		for i := start; i < min(start+noteLen, len(samples)); i++ {
+			t := float64(i) / testSampleRate
+			for h := 1; h <= 4; h++ {
+				samples[i] += math.Sin(2*math.Pi*freq*float64(h)*t) / float64(h)
+			}
+		}
+	}
+
+	return samples
+}
+
+// pcm converts audio to 16-bit stereo with given gain and noise level.
+func pcm(samples []float64, gain, noise float64) []int16 {
+	rnd := rand.New(rand.NewSource(1))
+
+	res := make([]int16, 0, 2*len(samples))
+	for _, s := range samples {
+		v := int16(math.Max(-32768, math.Min(32767, (s*gain+rnd.NormFloat64()*noise)*8000)))
+		res = append(res, v, v)
+	}
+
+	return res
+}
+
+func fingerprint(t *testing.T, samples []int16) Fingerprint {
+	t.Helper()
+
+	b, err := NewBuilder(testSampleRate, 2)
+	require.NoError(t, err)
+	b.Write(samples)
+	return b.Fingerprint()
+}
+
+func TestBuilder(t *testing.T) {
+	audio := pcm(melody(1, 30), 1, 0)
+	fp := fingerprint(t, audio)
+
+	require.InDelta(t, 30*time.Second, fp.Duration(), float64(time.Second))
+
+	// Writing in parts gives the same result.
+	b, err := NewBuilder(testSampleRate, 2)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, audio))
+	_, err = b.ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, fp, b.Fingerprint())
+
+	// Quieter noisy copy is close.
+	ber, n := fp.BitErrorRate(fingerprint(t, pcm(melody(1, 30), 0.5, 0.02)), 0)
+	require.Equal(t, len(fp), n)
+	require.Less(t, ber, 0.15)
+
+	// Other tune is not.
+	ber, _ = fp.BitErrorRate(fingerprint(t, pcm(melody(2, 30), 1, 0)), 0)
+	require.Greater(t, ber, 0.35)
+
+	_, err = NewBuilder(4000, 2)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestMarshalBinary(t *testing.T) {
+	fp := Fingerprint{1, 0xDEADBEEF, 0}
+
+	data, err := fp.MarshalBinary()
+	require.NoError(t, err)
+
+	var res Fingerprint
+	require.NoError(t, res.UnmarshalBinary(data))
+	require.Equal(t, fp, res)
+
+	require.ErrorIs(t, res.UnmarshalBinary([]byte{1, 2, 3}), ErrInvalidData)
+}
+
+func TestIndex(t *testing.T) {
+	x := NewIndex()
+	for seed := int64(1); seed <= 5; seed++ {
+		x.Add(int(seed), fingerprint(t, pcm(melody(seed, 30), 1, 0)))
+	}
+	require.Equal(t, 5, x.Len())
+
+	// Other encode of the whole track.
+	matches := x.Search(fingerprint(t, pcm(melody(3, 30), 0.7, 0.02)), 0.5)
+	require.Len(t, matches, 1)
+	require.Equal(t, 3, matches[0].ID)
+	require.Greater(t, matches[0].Confidence, 0.7)
+	require.Zero(t, matches[0].Offset)
+
+	// Clip from the middle of the track.
+	clip := melody(4, 30)[10*testSampleRate : 18*testSampleRate]
+	matches = x.Search(fingerprint(t, pcm(clip, 1, 0.01)), 0.5)
+	require.Len(t, matches, 1)
+	require.Equal(t, 4, matches[0].ID)
+	require.InDelta(t, 10*time.Second, matches[0].Offset, float64(FrameDuration))
+
+	require.Empty(t, x.Search(fingerprint(t, pcm(melody(6, 30), 1, 0)), 0.5))
+
+	// Silence matches nothing, even other silence.
+	silence := make([]float64, 10*testSampleRate)
+	x.Add(8, fingerprint(t, pcm(silence, 1, 0)))
+	require.Empty(t, x.Search(fingerprint(t, pcm(silence, 1, 0)), 0.5))
+	x.Remove(8)
+
+	x.Remove(4)
+	require.Equal(t, 4, x.Len())
+	require.Empty(t, x.Search(fingerprint(t, pcm(clip, 1, 0.01)), 0.5))
+
+	// Replaced fingerprint is not found by old audio.
+	x.Add(3, fingerprint(t, pcm(melody(7, 30), 1, 0)))
+	require.Equal(t, 4, x.Len())
+	require.Empty(t, x.Search(fingerprint(t, pcm(melody(3, 30), 1, 0)), 0.5))
+}
@@ -0,0 +1,191 @@
+package fingerprint
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPostings limits number of occurrences of subfingerprint
+	// used for lookup, frequent ones like silence are skipped.
+	maxPostings = 1024
+	// minVotes is number of matching subfingerprints
+	// at the same offset to consider a candidate.
+	minVotes = 2
+	// minOverlap is number of subfingerprints, about 3 s,
+	// audio must overlap to be compared.
+	minOverlap = 32
+	// candidatesPerID limits number of offsets
+	// compared for every fingerprint.
+	candidatesPerID = 3
+
+	// silence is subfingerprint of digital silence,
+	// which is never used for lookup.
+	silence = 0
+)
+
+// Match is fingerprint of the index matching the query.
+type Match struct {
+	ID int
+	// Offset is time in matched audio
+	// where query audio starts, may be negative.
+	Offset time.Duration
+	// Confidence is from 0 for unrelated audio
+	// to 1 for identical one.
+	Confidence float64
+}
+
+type posting struct {
+	id  int
+	pos int32
+}
+
+// Index finds fingerprints by their parts.
+// It is safe for concurrent use.
+type Index struct {
+	mu           sync.RWMutex
+	fingerprints map[int]Fingerprint
+	postings     map[uint32][]posting
+}
+
+func NewIndex() *Index {
+	return &Index{
+		fingerprints: make(map[int]Fingerprint),
+		postings:     make(map[uint32][]posting),
+	}
+}
+
+// Add adds fingerprint with given id,
+// replacing previous one.
+func (x *Index) Add(id int, fp Fingerprint) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+
+	x.fingerprints[id] = fp
+	for i, v := range fp {
+		if v == silence {
+			continue
+		}
+		x.postings[v] = append(x.postings[v], posting{id: id, pos: int32(i)})
+	}
+}
+
+// Remove removes fingerprint with given id.
+func (x *Index) Remove(id int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+}
+
+func (x *Index) remove(id int) {
+	fp, ok := x.fingerprints[id]
+	if !ok {
+		return
+	}
+
+	for _, v := range fp {
+		if v == silence {
+			continue
+		}
+		postings := slices.DeleteFunc(x.postings[v], func(p posting) bool {
+			return p.id == id
+		})
+		if len(postings) == 0 {
+			delete(x.postings, v)
+		} else {
+			x.postings[v] = postings
+		}
+	}
+	delete(x.fingerprints, id)
+}
+
+// Len returns number of fingerprints.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.fingerprints)
+}
+
+// Search returns fingerprints matching query with confidence
+// at least minConfidence, best match first.
+//
+// Candidates are offsets at which subfingerprints match exactly,
+// every candidate is then scored by bit error rate of overlap.
+func (x *Index) Search(query Fingerprint, minConfidence float64) []Match {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	type candidate struct {
+		id     int
+		offset int
+	}
+
+	votes := make(map[candidate]int)
+	for q, v := range query {
+		if v == silence {
+			continue
+		}
+		postings := x.postings[v]
+		if len(postings) > maxPostings {
+			continue
+		}
+		for _, p := range postings {
+			votes[candidate{id: p.id, offset: int(p.pos) - q}]++
+		}
+	}
+
+	// Best voted offsets of every fingerprint.
+	offsets := make(map[int][]candidate)
+	for c, n := range votes {
+		if n < minVotes {
+			continue
+		}
+		offsets[c.id] = append(offsets[c.id], c)
+	}
+
+	var matches []Match
+	for id, candidates := range offsets {
+		slices.SortFunc(candidates, func(a, b candidate) int {
+			if d := votes[b] - votes[a]; d != 0 {
+				return d
+			}
+			return a.offset - b.offset
+		})
+
+		best := Match{ID: id, Confidence: -1}
+		for _, c := range candidates[:min(len(candidates), candidatesPerID)] {
+			ber, n := query.BitErrorRate(x.fingerprints[id], c.offset)
+			if n < min(minOverlap, len(query)) {
+				continue
+			}
+
+			// Unrelated audio differs in half of bits.
+			confidence := max(0, 1-2*ber)
+			if confidence > best.Confidence {
+				best.Confidence = confidence
+				best.Offset = time.Duration(c.offset) * FrameDuration
+			}
+		}
+
+		if best.Confidence >= minConfidence {
+			matches = append(matches, best)
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Confidence > b.Confidence:
+			return -1
+		case a.Confidence < b.Confidence:
+			return 1
+		}
+		return a.ID - b.ID
+	})
+
+	return matches
+}
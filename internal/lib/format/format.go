@@ -0,0 +1,149 @@
+// Package format detects audio container format by magic bytes.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"radio-storage/internal/lib/mp3"
+)
+
+// sniffLen is number of bytes inspected after leading ID3v2 tag.
+const sniffLen = 512
+
+// Format is audio file format.
+type Format struct {
+	// Name is used in configuration.
+	Name        string
+	Ext         string
+	ContentType string
+}
+
+var (
+	MP3    = Format{Name: "mp3", Ext: "mp3", ContentType: "audio/mpeg"}
+	FLAC   = Format{Name: "flac", Ext: "flac", ContentType: "audio/flac"}
+	WAV    = Format{Name: "wav", Ext: "wav", ContentType: "audio/wav"}
+	AIFF   = Format{Name: "aiff", Ext: "aiff", ContentType: "audio/aiff"}
+	Vorbis = Format{Name: "vorbis", Ext: "ogg", ContentType: "audio/ogg"}
+	Opus   = Format{Name: "opus", Ext: "opus", ContentType: "audio/ogg; codecs=opus"}
+	AAC    = Format{Name: "aac", Ext: "aac", ContentType: "audio/aac"}
+	M4A    = Format{Name: "m4a", Ext: "m4a", ContentType: "audio/mp4"}
+)
+
+var formats = []Format{MP3, FLAC, WAV, AIFF, Vorbis, Opus, AAC, M4A}
+
+// All returns all known formats.
+func All() []Format {
+	return formats
+}
+
+// ByName returns format by its name.
+func ByName(name string) (Format, bool) {
+	for _, f := range formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// ByExt returns format by file extension without dot.
+func ByExt(ext string) (Format, bool) {
+	for _, f := range formats {
+		if f.Ext == ext {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Sniff detects format of the file by magic bytes
+// following optional ID3v2 tag.
+//
+// MPEG audio has no reliable signature,
+// so content not matching any other format is reported as MP3.
+func Sniff(r io.ReaderAt) (Format, error) {
+	head := make([]byte, mp3.ID3v2HeaderLen)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Format{}, err
+	}
+
+	offset := int64(mp3.ID3v2Size(head[:n]))
+
+	head = make([]byte, sniffLen)
+	n, err = r.ReadAt(head, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Format{}, err
+	}
+
+	return sniff(head[:n]), nil
+}
+
+func sniff(b []byte) Format {
+	switch {
+	case bytes.HasPrefix(b, []byte("fLaC")):
+		return FLAC
+	case len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WAVE":
+		return WAV
+	case len(b) >= 12 && string(b[:4]) == "FORM" && (string(b[8:12]) == "AIFF" || string(b[8:12]) == "AIFC"):
+		return AIFF
+	case bytes.HasPrefix(b, []byte("OggS")):
+		return sniffOgg(b)
+	case len(b) >= 12 && string(b[4:8]) == "ftyp":
+		return M4A
+	case isADTS(b):
+		return AAC
+	default:
+		return MP3
+	}
+}
+
+// isADTS checks for AAC ADTS frame followed by another one.
+// Its sync has layer bits zero, which are reserved for MPEG audio.
+func isADTS(b []byte) bool {
+	const headerLen = 7
+
+	header := func(b []byte) (int, bool) {
+		if len(b) < headerLen || b[0] != 0xFF || b[1]&0xF6 != 0xF0 || (b[2]>>2)&0x0F > 12 {
+			return 0, false
+		}
+		n := int(b[3]&0x03)<<11 | int(b[4])<<3 | int(b[5])>>5
+		return n, n >= headerLen
+	}
+
+	n, ok := header(b)
+	if !ok {
+		return false
+	}
+	if len(b) < n+2 {
+		return true
+	}
+
+	_, ok = header(b[n:])
+
+	return ok
+}
+
+// sniffOgg detects codec by the first packet of Ogg stream.
+func sniffOgg(b []byte) Format {
+	const pageHeaderLen = 27
+
+	if len(b) < pageHeaderLen {
+		return Vorbis
+	}
+
+	packet := b[pageHeaderLen:]
+	segments := int(b[pageHeaderLen-1])
+	if len(packet) < segments {
+		return Vorbis
+	}
+	packet = packet[segments:]
+
+	if bytes.HasPrefix(packet, []byte("OpusHead")) {
+		return Opus
+	}
+
+	return Vorbis
+}
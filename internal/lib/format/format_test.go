@@ -0,0 +1,46 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+)
+
+func TestSniff(t *testing.T) {
+	oggPage := func(packet string) []byte {
+		page := append([]byte("OggS"), make([]byte, 22)...)
+		page = append(page, 1, byte(len(packet)))
+		return append(page, packet...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want Format
+	}{
+		{name: "mp3", data: mp3test.Silence(2), want: MP3},
+		{name: "mp3 with id3", data: append(mp3test.ID3v2(map[string]string{"TIT2": "title"}), mp3test.Silence(2)...), want: MP3},
+		{name: "flac", data: []byte("fLaC\x00\x00\x00\x22"), want: FLAC},
+		{name: "flac with id3", data: append(mp3test.ID3v2(map[string]string{"TIT2": "title"}), "fLaC"...), want: FLAC},
+		{name: "wav", data: []byte("RIFF\x24\x00\x00\x00WAVEfmt "), want: WAV},
+		{name: "aiff", data: []byte("FORM\x00\x00\x00\x00AIFFCOMM"), want: AIFF},
+		{name: "vorbis", data: oggPage("\x01vorbis"), want: Vorbis},
+		{name: "opus", data: oggPage("OpusHead"), want: Opus},
+		{name: "m4a", data: []byte("\x00\x00\x00\x20ftypM4A "), want: M4A},
+		{name: "aac", data: []byte{0xFF, 0xF1, 0x50, 0x80, 0x01, 0x1F, 0xFC}, want: AAC},
+		{name: "aac sync only", data: append([]byte{0xFF, 0xF1, 0x50, 0x80, 0x01, 0x1F, 0xFC}, make([]byte, 10)...), want: MP3},
+		{name: "unknown", data: []byte("plain text"), want: MP3},
+		{name: "empty", data: nil, want: MP3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Sniff(bytes.NewReader(tt.data))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, f)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package hls packages audio stream as HTTP Live Streaming
+// media playlist of raw MP3 segments.
+package hls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// PlaylistContentType is MIME type of media playlist.
+	PlaylistContentType = "application/vnd.apple.mpegurl"
+
+	// timestampOwner is owner of ID3 PRIV frame carrying
+	// timestamp of packed audio segment.
+	timestampOwner = "com.apple.streaming.transportStreamTimestamp"
+	// timestampClock is rate of MPEG-2 transport stream clock.
+	timestampClock = 90000
+)
+
+// Segment is part of the stream cut on frame boundaries.
+type Segment struct {
+	// Offset and Size locate segment frames in the stream.
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+	// Start is time of the first sample of the segment.
+	Start    time.Duration `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Segmenter groups consecutive frames into segments
+// no shorter than target duration, except the last one.
+type Segmenter struct {
+	target     time.Duration
+	sampleRate int64
+
+	segments []Segment
+	samples  int64
+	// cur is number of samples in the last segment.
+	cur int64
+}
+
+func NewSegmenter(target time.Duration, sampleRate int) *Segmenter {
+	return &Segmenter{
+		target:     target,
+		sampleRate: int64(sampleRate),
+	}
+}
+
+// Add appends frame to the current segment,
+// starting a new one if current is long enough.
+func (s *Segmenter) Add(offset int64, size int, samples int) {
+	if len(s.segments) == 0 || s.duration(s.cur) >= s.target {
+		s.segments = append(s.segments, Segment{
+			Offset: offset,
+			Start:  s.duration(s.samples),
+		})
+		s.cur = 0
+	}
+
+	seg := &s.segments[len(s.segments)-1]
+	seg.Size = offset + int64(size) - seg.Offset
+
+	s.samples += int64(samples)
+	s.cur += int64(samples)
+	seg.Duration = s.duration(s.samples) - seg.Start
+}
+
+// Segments returns segments of frames added so far.
+func (s *Segmenter) Segments() []Segment {
+	return s.segments
+}
+
+func (s *Segmenter) duration(samples int64) time.Duration {
+	return time.Duration(samples * int64(time.Second) / s.sampleRate)
+}
+
+// Playlist returns VOD media playlist of segments,
+// uri returns URI of segment with given index.
+func Playlist(segments []Segment, uri func(i int) string) []byte {
+	var target time.Duration
+	for _, seg := range segments {
+		target = max(target, seg.Duration)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(target.Seconds())))
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	for i, seg := range segments {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n", seg.Duration.Seconds())
+		b.WriteString(uri(i))
+		b.WriteByte('\n')
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+
+	return b.Bytes()
+}
+
+// TimestampTag returns ID3v2.4 tag which must precede
+// packed audio segment, so player knows its start time.
+func TimestampTag(start time.Duration) []byte {
+	// 33-bit timestamp in 90 kHz clock.
+	ts := uint64(start*timestampClock/time.Second) & (1<<33 - 1)
+
+	frameLen := len(timestampOwner) + 1 + 8
+
+	b := make([]byte, 0, 10+10+frameLen)
+	b = append(b, "ID3"...)
+	b = append(b, 4, 0, 0)
+	b = appendSyncsafe(b, 10+frameLen)
+
+	b = append(b, "PRIV"...)
+	b = appendSyncsafe(b, frameLen)
+	b = append(b, 0, 0)
+	b = append(b, timestampOwner...)
+	b = append(b, 0)
+	b = binary.BigEndian.AppendUint64(b, ts)
+
+	return b
+}
+
+func appendSyncsafe(b []byte, n int) []byte {
+	return append(b,
+		byte(n>>21&0x7F),
+		byte(n>>14&0x7F),
+		byte(n>>7&0x7F),
+		byte(n&0x7F),
+	)
+}
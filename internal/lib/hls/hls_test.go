@@ -0,0 +1,68 @@
+package hls
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/lib/mp3"
+)
+
+func TestSegmenter(t *testing.T) {
+	// 1000 samples per frame at 1 kHz, so every frame is a second.
+	s := NewSegmenter(3*time.Second, 1000)
+	for i := 0; i < 7; i++ {
+		s.Add(100+int64(i)*10, 10, 1000)
+	}
+
+	require.Equal(t, []Segment{
+		{Offset: 100, Size: 30, Start: 0, Duration: 3 * time.Second},
+		{Offset: 130, Size: 30, Start: 3 * time.Second, Duration: 3 * time.Second},
+		{Offset: 160, Size: 10, Start: 6 * time.Second, Duration: time.Second},
+	}, s.Segments())
+
+	require.Empty(t, NewSegmenter(time.Second, 1000).Segments())
+}
+
+func TestPlaylist(t *testing.T) {
+	segments := []Segment{
+		{Duration: 6 * time.Second},
+		{Start: 6 * time.Second, Duration: 6026 * time.Millisecond},
+		{Start: 12026 * time.Millisecond, Duration: 1500 * time.Millisecond},
+	}
+
+	playlist := Playlist(segments, func(i int) string {
+		return strconv.Itoa(i) + ".mp3"
+	})
+
+	require.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:3\n"+
+		"#EXT-X-TARGETDURATION:7\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-PLAYLIST-TYPE:VOD\n"+
+		"#EXTINF:6.000,\n0.mp3\n"+
+		"#EXTINF:6.026,\n1.mp3\n"+
+		"#EXTINF:1.500,\n2.mp3\n"+
+		"#EXT-X-ENDLIST\n", string(playlist))
+}
+
+func TestTimestampTag(t *testing.T) {
+	tag := TimestampTag(2 * time.Second)
+
+	require.Equal(t, len(tag), mp3.ID3v2Size(tag))
+
+	var (
+		owner string
+		data  []byte
+	)
+	require.NoError(t, mp3.ID3v2Frames(tag, func(id string, frame []byte) {
+		require.Equal(t, "PRIV", id)
+		owner = string(frame[:len(timestampOwner)])
+		data = frame[len(timestampOwner)+1:]
+	}))
+	require.Equal(t, timestampOwner, owner)
+	require.Equal(t, uint64(180000), binary.BigEndian.Uint64(data))
+}
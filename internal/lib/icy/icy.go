@@ -0,0 +1,103 @@
+// Package icy interleaves SHOUTcast/Icecast
+// metadata with audio stream.
+package icy
+
+import (
+	"io"
+	"strings"
+)
+
+// DefaultMetaInt is number of audio bytes
+// between metadata blocks used by Icecast.
+const DefaultMetaInt = 16000
+
+// maxMetadataLen is the longest metadata
+// its length byte can describe.
+const maxMetadataLen = 255 * 16
+
+// Writer writes audio with metadata block
+// after every metaInt bytes.
+type Writer struct {
+	w       io.Writer
+	metaInt int
+
+	title string
+	// sent is set once current title is sent.
+	sent bool
+	// left is number of audio bytes until next block.
+	left int
+}
+
+// NewWriter returns writer inserting metadata every metaInt bytes.
+// Zero metaInt disables metadata, so audio is passed as is.
+func NewWriter(w io.Writer, metaInt int) *Writer {
+	return &Writer{
+		w:       w,
+		metaInt: metaInt,
+		left:    metaInt,
+	}
+}
+
+// SetTitle sets stream title sent in the next metadata block.
+func (w *Writer) SetTitle(title string) {
+	if title == w.title {
+		return
+	}
+	w.title = title
+	w.sent = false
+}
+
+// Write writes audio, inserting metadata blocks where needed.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.metaInt == 0 {
+		return w.w.Write(p)
+	}
+
+	var written int
+	for len(p) > 0 {
+		n := min(len(p), w.left)
+		n, err := w.w.Write(p[:n])
+		written += n
+		w.left -= n
+		p = p[n:]
+		if err != nil {
+			return written, err
+		}
+
+		if w.left == 0 {
+			if _, err := w.w.Write(w.block()); err != nil {
+				return written, err
+			}
+			w.left = w.metaInt
+		}
+	}
+
+	return written, nil
+}
+
+// block returns next metadata block, which is
+// empty unless title has changed since the last one.
+func (w *Writer) block() []byte {
+	if w.sent {
+		return []byte{0}
+	}
+	w.sent = true
+
+	return Metadata(w.title)
+}
+
+// Metadata returns metadata block with given stream title.
+func Metadata(title string) []byte {
+	// Quote ends the value, since it is not escaped.
+	meta := "StreamTitle='" + strings.ReplaceAll(title, "'", "’") + "';"
+	if len(meta) > maxMetadataLen {
+		meta = meta[:maxMetadataLen-2] + "';"
+	}
+
+	n := (len(meta) + 15) / 16
+	block := make([]byte, 1+n*16)
+	block[0] = byte(n)
+	copy(block[1:], meta)
+
+	return block
+}
@@ -0,0 +1,59 @@
+package icy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	block := Metadata("Artist - Title")
+	require.Len(t, block, 1+32)
+	require.Equal(t, byte(2), block[0])
+	require.Equal(t, "StreamTitle='Artist - Title';", string(bytes.TrimRight(block[1:], "\x00")))
+
+	require.Equal(t, "StreamTitle='Rock’n’Roll';", string(bytes.TrimRight(Metadata("Rock'n'Roll")[1:], "\x00")))
+
+	long := Metadata(strings.Repeat("a", 5000))
+	require.Len(t, long, 1+255*16)
+	require.True(t, strings.HasSuffix(string(long[1:]), "';"))
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 4)
+	w.SetTitle("A")
+
+	n, err := w.Write([]byte("012345"))
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+
+	// Title is sent once.
+	_, err = w.Write([]byte("67"))
+	require.NoError(t, err)
+
+	w.SetTitle("B")
+	_, err = w.Write([]byte("89ab"))
+	require.NoError(t, err)
+
+	var want []byte
+	want = append(want, "0123"...)
+	want = append(want, Metadata("A")...)
+	want = append(want, "4567"...)
+	want = append(want, 0)
+	want = append(want, "89ab"...)
+	want = append(want, Metadata("B")...)
+	require.Equal(t, want, buf.Bytes())
+}
+
+func TestWriterDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 0)
+	w.SetTitle("A")
+
+	_, err := w.Write([]byte("0123456789"))
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", buf.String())
+}
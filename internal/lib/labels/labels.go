@@ -0,0 +1,268 @@
+// Package labels validates key-value labels
+// and selects them by label selectors.
+//
+// Selector is comma separated list of requirements,
+// all of which must hold:
+//
+//	key=value, key==value, key!=value,
+//	key in (v1,v2), key notin (v1,v2), key, !key
+//
+// Inequality and notin also hold for missing labels.
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxLen is maximum length of keys and values.
+const maxLen = 63
+
+var (
+	ErrInvalidLabel    = errors.New("invalid label")
+	ErrInvalidSelector = errors.New("invalid label selector")
+)
+
+// Validate checks keys and values of labels.
+func Validate(labels map[string]string) error {
+	for k, v := range labels {
+		if !validKey(k) {
+			return fmt.Errorf("%w: key %q must be up to %d letters, digits, '.', '_' and '-' starting with letter or digit", ErrInvalidLabel, k, maxLen)
+		}
+		if !validValue(v) {
+			return fmt.Errorf("%w: value %q must be up to %d letters, digits, '.', '_' and '-'", ErrInvalidLabel, v, maxLen)
+		}
+	}
+
+	return nil
+}
+
+func validKey(k string) bool {
+	return k != "" && isAlnum(k[0]) && validValue(k)
+}
+
+func validValue(v string) bool {
+	if len(v) > maxLen {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if !isLabelChar(v[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isLabelChar(c byte) bool {
+	return isAlnum(c) || c == '.' || c == '_' || c == '-'
+}
+
+// Operator is relation of requirement.
+type Operator int
+
+const (
+	Exists Operator = iota
+	NotExists
+	Equals
+	NotEquals
+	In
+	NotIn
+)
+
+// Requirement is single condition on labels.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	// Values has single value for Equals and NotEquals
+	// and is empty for Exists and NotExists.
+	Values []string
+}
+
+// Matches reports whether labels satisfy the requirement.
+func (r Requirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+
+	switch r.Operator {
+	case Exists:
+		return ok
+	case NotExists:
+		return !ok
+	case Equals, In:
+		return ok && slices.Contains(r.Values, v)
+	case NotEquals, NotIn:
+		return !ok || !slices.Contains(r.Values, v)
+	}
+
+	return false
+}
+
+// Selector is conjunction of requirements.
+// Empty selector matches any labels.
+type Selector []Requirement
+
+// Matches reports whether labels satisfy all requirements.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse parses label selector.
+func Parse(selector string) (Selector, error) {
+	p := parser{s: selector}
+
+	var res Selector
+	if p.skipSpace(); p.eof() {
+		return res, nil
+	}
+
+	for {
+		r, err := p.requirement()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+		}
+		res = append(res, r)
+
+		if p.skipSpace(); p.eof() {
+			return res, nil
+		}
+		if !p.consume(",") {
+			return nil, fmt.Errorf("%w: expected ',' at %d", ErrInvalidSelector, p.pos)
+		}
+	}
+}
+
+// parser is recursive descent parser of selectors.
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// consume skips token if selector continues with it.
+func (p *parser) consume(token string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.s[p.pos:], token) {
+		return false
+	}
+	p.pos += len(token)
+	return true
+}
+
+// word returns following run of label characters, may be empty.
+func (p *parser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for !p.eof() && isLabelChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) key() (string, error) {
+	k := p.word()
+	if !validKey(k) {
+		return "", fmt.Errorf("invalid key %q at %d", k, p.pos-len(k))
+	}
+	return k, nil
+}
+
+func (p *parser) value() (string, error) {
+	v := p.word()
+	if !validValue(v) {
+		return "", fmt.Errorf("invalid value %q at %d", v, p.pos-len(v))
+	}
+	return v, nil
+}
+
+func (p *parser) requirement() (Requirement, error) {
+	if p.consume("!") {
+		k, err := p.key()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: k, Operator: NotExists}, nil
+	}
+
+	k, err := p.key()
+	if err != nil {
+		return Requirement{}, err
+	}
+	r := Requirement{Key: k}
+
+	switch {
+	case p.consume("!="):
+		r.Operator = NotEquals
+	case p.consume("=="), p.consume("="):
+		r.Operator = Equals
+	default:
+		// Set operators are words, so they
+		// are read as such and restored otherwise.
+		pos := p.pos
+		switch p.word() {
+		case "in":
+			r.Operator = In
+		case "notin":
+			r.Operator = NotIn
+		default:
+			p.pos = pos
+			r.Operator = Exists
+			return r, nil
+		}
+
+		r.Values, err = p.set()
+		return r, err
+	}
+
+	v, err := p.value()
+	if err != nil {
+		return Requirement{}, err
+	}
+	r.Values = []string{v}
+
+	return r, nil
+}
+
+// set parses parenthesized list of values.
+func (p *parser) set() ([]string, error) {
+	if !p.consume("(") {
+		return nil, fmt.Errorf("expected '(' at %d", p.pos)
+	}
+	if p.consume(")") {
+		return nil, fmt.Errorf("empty set at %d", p.pos)
+	}
+
+	var values []string
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		if p.consume(")") {
+			return values, nil
+		}
+		if !p.consume(",") {
+			return nil, fmt.Errorf("expected ',' or ')' at %d", p.pos)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package labels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(map[string]string{
+		"type":            "jingle",
+		"license_expires": "2027-01-01",
+		"draft":           "",
+	}))
+
+	for _, labels := range []map[string]string{
+		{"": "x"},
+		{"_type": "x"},
+		{"type": "a b"},
+		{"show/name": "x"},
+		{"type": strings.Repeat("a", 64)},
+	} {
+		require.ErrorIs(t, Validate(labels), ErrInvalidLabel, labels)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	jingle := map[string]string{"type": "jingle", "show": "morning"}
+	ad := map[string]string{"type": "ad", "license_expires": "2027-01-01"}
+	none := map[string]string{}
+
+	tests := []struct {
+		selector string
+		want     []map[string]string
+	}{
+		{"", []map[string]string{jingle, ad, none}},
+		{"type=jingle", []map[string]string{jingle}},
+		{" type == jingle , show=morning ", []map[string]string{jingle}},
+		{"type!=jingle", []map[string]string{ad, none}},
+		{"type in (ad, jingle)", []map[string]string{jingle, ad}},
+		{"type notin (ad)", []map[string]string{jingle, none}},
+		{"license_expires", []map[string]string{ad}},
+		{"!license_expires", []map[string]string{jingle, none}},
+		{"in in (x)", nil},
+		{"show=", nil},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.selector)
+		require.NoError(t, err, tt.selector)
+
+		var got []map[string]string
+		for _, labels := range []map[string]string{jingle, ad, none} {
+			if s.Matches(labels) {
+				got = append(got, labels)
+			}
+		}
+		require.Equal(t, tt.want, got, tt.selector)
+	}
+
+	for _, selector := range []string{
+		"type=jingle,",
+		"type jingle",
+		"type in jingle",
+		"type in (jingle",
+		"type in ()",
+		"=jingle",
+		"!",
+		"type=a b",
+		"type=(a)",
+	} {
+		_, err := Parse(selector)
+		require.ErrorIs(t, err, ErrInvalidSelector, selector)
+	}
+}
@@ -0,0 +1,151 @@
+package loudness
+
+import "math"
+
+// biquad is second order IIR filter
+// in transposed direct form II.
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+
+	z1, z2 float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// kWeighting is K-weighting filter of BS.1770:
+// high shelf modelling head followed by high pass.
+type kWeighting struct {
+	shelf    biquad
+	highPass biquad
+}
+
+// newKWeighting returns K-weighting filter for sample rate fs.
+//
+// Coefficients are derived from analog prototype,
+// so they match reference ones at 48 kHz and
+// stay correct for other sample rates.
+func newKWeighting(fs float64) kWeighting {
+	var k kWeighting
+
+	{
+		const (
+			f0   = 1681.974450955533
+			gain = 3.999843853973347
+			q    = 0.7071752369554196
+		)
+
+		K := math.Tan(math.Pi * f0 / fs)
+		vh := math.Pow(10, gain/20)
+		vb := math.Pow(vh, 0.4996667741545416)
+		a0 := 1 + K/q + K*K
+
+		k.shelf = biquad{
+			b0: (vh + vb*K/q + K*K) / a0,
+			b1: 2 * (K*K - vh) / a0,
+			b2: (vh - vb*K/q + K*K) / a0,
+			a1: 2 * (K*K - 1) / a0,
+			a2: (1 - K/q + K*K) / a0,
+		}
+	}
+
+	{
+		const (
+			f0 = 38.13547087602444
+			q  = 0.5003270373238773
+		)
+
+		K := math.Tan(math.Pi * f0 / fs)
+		a0 := 1 + K/q + K*K
+
+		k.highPass = biquad{
+			b0: 1,
+			b1: -2,
+			b2: 1,
+			a1: 2 * (K*K - 1) / a0,
+			a2: (1 - K/q + K*K) / a0,
+		}
+	}
+
+	return k
+}
+
+func (k *kWeighting) process(x float64) float64 {
+	return k.highPass.process(k.shelf.process(x))
+}
+
+// truePeak tracks maximum of oversampled signal.
+type truePeak struct {
+	// taps hold interpolation filter for every phase.
+	taps    [][truePeakTaps]float64
+	history [truePeakTaps]float64
+	pos     int
+
+	max float64
+}
+
+// newTruePeak returns true peak meter.
+// Signals of 96 kHz and higher are oversampled less,
+// since their intersample peaks are smaller.
+func newTruePeak(sampleRate int) *truePeak {
+	ratio := truePeakRatio
+	for ratio > 1 && sampleRate*ratio > 4*48000 {
+		ratio /= 2
+	}
+
+	p := &truePeak{
+		taps: make([][truePeakTaps]float64, ratio),
+	}
+
+	// Phase i interpolates signal i/ratio samples
+	// after the middle of history.
+	const half = truePeakTaps / 2
+	for i := range p.taps {
+		for k := 0; k < truePeakTaps; k++ {
+			x := float64(half-1-k) + float64(i)/float64(ratio)
+			p.taps[i][k] = sinc(x) * blackman(x/half)
+		}
+	}
+
+	return p
+}
+
+func (p *truePeak) add(x float64) {
+	p.max = math.Max(p.max, math.Abs(x))
+
+	p.history[p.pos] = x
+	p.pos = (p.pos + 1) % truePeakTaps
+
+	// Phase 0 is the sample itself.
+	for _, taps := range p.taps[1:] {
+		var y float64
+		for k, tap := range taps {
+			y += tap * p.history[(p.pos+k)%truePeakTaps]
+		}
+		p.max = math.Max(p.max, math.Abs(y))
+	}
+}
+
+func (p *truePeak) peak() float64 {
+	return p.max
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is Blackman window on [-1, 1].
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
@@ -0,0 +1,252 @@
+// Package loudness measures loudness of audio
+// according to ITU-R BS.1770 and EBU R128.
+package loudness
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"slices"
+)
+
+const (
+	// Floor is the lowest reported loudness and peak level,
+	// returned for silent audio.
+	Floor = -70.0
+
+	// ReplayGainReference is ReplayGain 2.0 reference loudness in LUFS.
+	ReplayGainReference = -18.0
+
+	absoluteGate  = -70.0
+	relativeGate  = -10.0 // integrated loudness, LU
+	rangeGate     = -20.0 // loudness range, LU
+	rangeLow      = 0.10
+	rangeHigh     = 0.95
+	subblockRate  = 10 // subblocks per second
+	momentaryLen  = 4  // 400 ms
+	shortTermLen  = 30 // 3 s
+	bufferFrames  = 4096
+	truePeakTaps  = 12 // per phase
+	truePeakRatio = 4
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Result is measured loudness of audio.
+type Result struct {
+	// Integrated is gated loudness of the whole audio, LUFS.
+	Integrated float64
+	// Range is loudness range, LU.
+	Range float64
+	// TruePeak is maximum level of 4x oversampled signal, dBTP.
+	TruePeak float64
+}
+
+// TrackGain returns ReplayGain 2.0 track gain
+// for integrated loudness, dB.
+func TrackGain(integrated float64) float64 {
+	return ReplayGainReference - integrated
+}
+
+// Meter accumulates audio and measures its loudness.
+type Meter struct {
+	sampleRate int
+	channels   int
+
+	filters []kWeighting
+	peaks   []*truePeak
+
+	// energy is sum of squared K-weighted samples
+	// of current subblock over all channels.
+	energy  float64
+	samples int
+	// frame is index of next frame.
+	frame int64
+
+	// subblocks hold mean energy of every 100 ms subblock.
+	subblocks []float64
+}
+
+// NewMeter returns meter of audio
+// with given sample rate and channels.
+func NewMeter(sampleRate, channels int) (*Meter, error) {
+	if sampleRate < 8000 || channels < 1 || channels > 2 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	m := &Meter{
+		sampleRate: sampleRate,
+		channels:   channels,
+		filters:    make([]kWeighting, channels),
+		peaks:      make([]*truePeak, channels),
+	}
+	for ch := range m.filters {
+		m.filters[ch] = newKWeighting(float64(sampleRate))
+		m.peaks[ch] = newTruePeak(sampleRate)
+	}
+
+	return m, nil
+}
+
+// Write adds interleaved samples in [-1, 1].
+// Incomplete trailing frame is ignored.
+func (m *Meter) Write(samples []float64) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		for ch := 0; ch < m.channels; ch++ {
+			x := samples[i+ch]
+			m.peaks[ch].add(x)
+
+			y := m.filters[ch].process(x)
+			m.energy += y * y
+		}
+		m.samples++
+		m.frame++
+
+		// Subblock boundaries are rounded to whole frames,
+		// so any sample rate is supported.
+		if m.frame == int64(len(m.subblocks)+1)*int64(m.sampleRate)/subblockRate {
+			m.subblocks = append(m.subblocks, m.energy/float64(m.samples))
+			m.energy, m.samples = 0, 0
+		}
+	}
+}
+
+// ReadFrom reads 16-bit signed little-endian
+// interleaved PCM until EOF.
+func (m *Meter) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, bufferFrames*2*m.channels)
+	samples := make([]float64, bufferFrames*m.channels)
+
+	var total int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		total += int64(n)
+
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(buf[2*i:]))) / 32768
+		}
+		m.Write(samples[:n])
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return total, nil
+		case err != nil:
+			return total, err
+		}
+	}
+}
+
+// Result returns loudness of audio written so far.
+func (m *Meter) Result() Result {
+	res := Result{
+		Integrated: Floor,
+		TruePeak:   Floor,
+	}
+
+	if integrated, ok := integrated(blocks(m.subblocks, momentaryLen)); ok {
+		res.Integrated = math.Max(integrated, Floor)
+	}
+	res.Range = loudnessRange(blocks(m.subblocks, shortTermLen))
+
+	var peak float64
+	for _, p := range m.peaks {
+		peak = math.Max(peak, p.peak())
+	}
+	if peak > 0 {
+		res.TruePeak = math.Max(20*math.Log10(peak), Floor)
+	}
+
+	return res
+}
+
+// blocks returns mean energies of overlapping blocks
+// of n subblocks with one subblock step.
+func blocks(subblocks []float64, n int) []float64 {
+	if len(subblocks) < n {
+		return nil
+	}
+
+	res := make([]float64, 0, len(subblocks)-n+1)
+	var sum float64
+	for i, e := range subblocks {
+		sum += e
+		if i >= n {
+			sum -= subblocks[i-n]
+		}
+		if i >= n-1 {
+			res = append(res, sum/float64(n))
+		}
+	}
+
+	return res
+}
+
+// integrated returns gated loudness of blocks.
+func integrated(blocks []float64) (float64, bool) {
+	gated := gate(blocks, absoluteGate)
+	if len(gated) == 0 {
+		return 0, false
+	}
+
+	m, _ := mean(gated)
+	return mean(gate(gated, m+relativeGate))
+}
+
+// loudnessRange returns difference between high and low
+// percentiles of gated short-term loudness.
+func loudnessRange(blocks []float64) float64 {
+	gated := gate(blocks, absoluteGate)
+	if len(gated) == 0 {
+		return 0
+	}
+
+	m, _ := mean(gated)
+	gated = gate(gated, m+rangeGate)
+	if len(gated) == 0 {
+		return 0
+	}
+
+	levels := make([]float64, len(gated))
+	for i, e := range gated {
+		levels[i] = loudness(e)
+	}
+	slices.Sort(levels)
+
+	percentile := func(p float64) float64 {
+		return levels[int(math.Round(p*float64(len(levels)-1)))]
+	}
+
+	return percentile(rangeHigh) - percentile(rangeLow)
+}
+
+// gate returns blocks louder than threshold.
+func gate(blocks []float64, threshold float64) []float64 {
+	res := make([]float64, 0, len(blocks))
+	for _, e := range blocks {
+		if loudness(e) > threshold {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// mean returns loudness of mean energy of blocks.
+func mean(blocks []float64) (float64, bool) {
+	if len(blocks) == 0 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, e := range blocks {
+		sum += e
+	}
+
+	return loudness(sum / float64(len(blocks))), true
+}
+
+// loudness converts mean energy to LUFS.
+func loudness(energy float64) float64 {
+	return -0.691 + 10*math.Log10(energy)
+}
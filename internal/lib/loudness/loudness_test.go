@@ -0,0 +1,105 @@
+package loudness
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sine returns interleaved sine of given level in dBFS
+// with equal content in every channel.
+func sine(sampleRate, channels int, freq, level, phase float64, d float64) []float64 {
+	amp := math.Pow(10, level/20)
+	n := int(d * float64(sampleRate))
+
+	res := make([]float64, 0, n*channels)
+	for i := 0; i < n; i++ {
+		x := amp * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)+phase)
+		for ch := 0; ch < channels; ch++ {
+			res = append(res, x)
+		}
+	}
+
+	return res
+}
+
+func measure(t *testing.T, sampleRate, channels int, samples ...[]float64) Result {
+	t.Helper()
+
+	m, err := NewMeter(sampleRate, channels)
+	require.NoError(t, err)
+	for _, s := range samples {
+		m.Write(s)
+	}
+
+	return m.Result()
+}
+
+func TestIntegrated(t *testing.T) {
+	// EBU Tech 3341 case 1: stereo 1 kHz sine at -23 dBFS.
+	for _, rate := range []int{44100, 48000} {
+		res := measure(t, rate, 2, sine(rate, 2, 1000, -23, 0, 20))
+		require.InDelta(t, -23, res.Integrated, 0.1, "rate %d", rate)
+	}
+
+	// EBU Tech 3341 case 3: relative gate drops quiet parts.
+	res := measure(t, 48000, 2,
+		sine(48000, 2, 1000, -36, 0, 10),
+		sine(48000, 2, 1000, -23, 0, 60),
+		sine(48000, 2, 1000, -36, 0, 10),
+	)
+	require.InDelta(t, -23, res.Integrated, 0.1)
+
+	// Mono carries half of the power of the same stereo signal.
+	res = measure(t, 48000, 1, sine(48000, 1, 1000, -23, 0, 20))
+	require.InDelta(t, -26, res.Integrated, 0.1)
+
+	require.Equal(t, 5.0, TrackGain(-23))
+}
+
+func TestRange(t *testing.T) {
+	// EBU Tech 3342 case 1: 20 s at -20 dBFS followed by 20 s at -30 dBFS.
+	res := measure(t, 48000, 2,
+		sine(48000, 2, 1000, -20, 0, 20),
+		sine(48000, 2, 1000, -30, 0, 20),
+	)
+	require.InDelta(t, 10, res.Range, 1)
+
+	res = measure(t, 48000, 2, sine(48000, 2, 1000, -20, 0, 20))
+	require.InDelta(t, 0, res.Range, 0.1)
+}
+
+func TestTruePeak(t *testing.T) {
+	// Samples of fs/4 sine with 45 degrees phase
+	// miss its peaks by 3 dB.
+	s := sine(48000, 2, 12000, -6, math.Pi/4, 1)
+	require.InDelta(t, math.Pow(10, -9.0/20), math.Abs(s[0]), 1e-3)
+
+	res := measure(t, 48000, 2, s)
+	require.InDelta(t, -6, res.TruePeak, 0.5)
+}
+
+func TestSilence(t *testing.T) {
+	res := measure(t, 44100, 2, make([]float64, 44100*2*5))
+	require.Equal(t, Result{Integrated: Floor, Range: 0, TruePeak: Floor}, res)
+}
+
+func TestReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	for _, x := range sine(44100, 2, 1000, -23, 0, 10) {
+		binary.Write(&buf, binary.LittleEndian, int16(math.Round(x*32768)))
+	}
+
+	m, err := NewMeter(44100, 2)
+	require.NoError(t, err)
+	n, err := m.ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(44100*2*2*10), n)
+	require.InDelta(t, -23, m.Result().Integrated, 0.1)
+
+	_, err = NewMeter(44100, 6)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,183 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrNoFrames = errors.New("no audio frames")
+
+// BitrateMode is bitrate mode of a track.
+type BitrateMode int
+
+const (
+	BitrateUnknown BitrateMode = iota
+	BitrateCBR
+	BitrateVBR
+	BitrateABR
+)
+
+const apeFooterLen = 32
+
+// Info describes audio track.
+type Info struct {
+	Tags Tags
+
+	// Header is header of the first audio frame.
+	Header      Header
+	Frames      int
+	Duration    time.Duration
+	Bitrate     int // average kbps
+	BitrateMode BitrateMode
+
+	// Xing is VBR header if track has one.
+	Xing *Xing
+
+	// AudioOffset and AudioSize bound audio data between tags.
+	AudioOffset int64
+	AudioSize   int64
+	// FrameBytes is size of valid frames within audio data.
+	FrameBytes int64
+}
+
+// Analyze parses tags and frame headers of the track.
+//
+// Returns ErrNoFrames along with parsed tags
+// if track contains no audio frames.
+func Analyze(r io.ReaderAt, size int64) (Info, error) {
+	const op = "mp3.Analyze"
+
+	var info Info
+
+	start, end, err := audioBounds(r, size, &info.Tags)
+	if err != nil {
+		return Info{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info.AudioOffset = start
+	info.AudioSize = end - start
+
+	frames := NewReader(io.NewSectionReader(r, start, end-start))
+
+	var samples int64
+	for first := true; ; first = false {
+		frame, err := frames.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return Info{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if first {
+			info.Header = frame.Header
+
+			if x, ok := ParseXing(frame); ok {
+				info.Xing = &x
+				info.FrameBytes += int64(len(frame.Data))
+				continue
+			}
+		}
+
+		if info.Frames > 0 && frame.Header.Bitrate != info.Header.Bitrate && info.BitrateMode != BitrateVBR {
+			info.BitrateMode = BitrateVBR
+		}
+
+		info.Frames++
+		info.FrameBytes += int64(len(frame.Data))
+		samples += int64(frame.Header.Samples())
+	}
+
+	if info.Frames == 0 && info.Xing == nil {
+		return info, ErrNoFrames
+	}
+
+	sampleRate := int64(info.Header.SampleRate)
+	audioBytes := info.FrameBytes
+
+	if x := info.Xing; x != nil {
+		if x.Frames > 0 {
+			samples = int64(x.Frames) * int64(info.Header.Samples())
+		}
+		if x.Bytes > 0 {
+			audioBytes = int64(x.Bytes)
+		}
+		if samples > int64(x.Delay+x.Padding) {
+			samples -= int64(x.Delay + x.Padding)
+		}
+
+		switch {
+		case x.Method == lameCBR || x.Method == lameCBR2Pass:
+			info.BitrateMode = BitrateCBR
+		case x.Method == lameABR || x.Method == lameABR2Pass:
+			info.BitrateMode = BitrateABR
+		case x.CBR:
+			info.BitrateMode = BitrateCBR
+		default:
+			info.BitrateMode = BitrateVBR
+		}
+	} else if info.BitrateMode != BitrateVBR {
+		info.BitrateMode = BitrateCBR
+	}
+
+	info.Duration = time.Duration(samples * int64(time.Second) / sampleRate)
+	if samples > 0 {
+		info.Bitrate = int((audioBytes*8*sampleRate/samples + 500) / 1000)
+	}
+
+	return info, nil
+}
+
+// audioBounds parses tags surrounding audio data
+// and returns its bounds.
+func audioBounds(r io.ReaderAt, size int64, tags *Tags) (int64, int64, error) {
+	var start, end int64 = 0, size
+
+	header := make([]byte, ID3v2HeaderLen)
+	if _, err := r.ReadAt(header, 0); err != nil && !errors.Is(err, io.EOF) {
+		return 0, 0, err
+	}
+
+	if n := int64(ID3v2Size(header)); n > 0 && n <= size {
+		tag := make([]byte, n)
+		if _, err := r.ReadAt(tag, 0); err != nil {
+			return 0, 0, err
+		}
+		// Broken tag is skipped as a whole.
+		*tags, _ = ParseID3v2(tag)
+		start = n
+	}
+
+	if end-start >= ID3v1Len {
+		tag := make([]byte, ID3v1Len)
+		if _, err := r.ReadAt(tag, end-ID3v1Len); err != nil {
+			return 0, 0, err
+		}
+		if v1, ok := ParseID3v1(tag); ok {
+			*tags = tags.Merge(v1)
+			end -= ID3v1Len
+		}
+	}
+
+	if end-start >= apeFooterLen {
+		footer := make([]byte, apeFooterLen)
+		if _, err := r.ReadAt(footer, end-apeFooterLen); err != nil {
+			return 0, 0, err
+		}
+		if string(footer[:8]) == "APETAGEX" {
+			n := int64(binary.LittleEndian.Uint32(footer[12:]))
+			// Header present.
+			if binary.LittleEndian.Uint32(footer[20:])&(1<<31) != 0 {
+				n += apeFooterLen
+			}
+			if n <= end-start {
+				end -= n
+			}
+		}
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,164 @@
+// Package mp3 parses MPEG audio frames and ID3 tags
+// without decoding audio.
+package mp3
+
+import "errors"
+
+// HeaderLen is length of frame header.
+const HeaderLen = 4
+
+var ErrInvalidHeader = errors.New("invalid frame header")
+
+// Version is MPEG audio version.
+type Version int
+
+const (
+	MPEG1 Version = iota + 1
+	MPEG2
+	MPEG25
+)
+
+// ChannelMode is frame channel mode.
+type ChannelMode int
+
+const (
+	Stereo ChannelMode = iota
+	JointStereo
+	DualChannel
+	Mono
+)
+
+var bitrates = [...][16]int{
+	// MPEG1 layer I, II, III.
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, -1},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, -1},
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, -1},
+	// MPEG2 and MPEG2.5 layer I, II and III.
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, -1},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, -1},
+}
+
+var sampleRates = map[Version][3]int{
+	MPEG1:  {44100, 48000, 32000},
+	MPEG2:  {22050, 24000, 16000},
+	MPEG25: {11025, 12000, 8000},
+}
+
+// Header is parsed frame header.
+type Header struct {
+	Version     Version
+	Layer       int
+	Protected   bool
+	Bitrate     int // kbps
+	SampleRate  int
+	Padding     bool
+	ChannelMode ChannelMode
+}
+
+// ParseHeader parses frame header from first 4 bytes of b.
+//
+// Free format bitrate is not supported.
+func ParseHeader(b []byte) (Header, error) {
+	if len(b) < HeaderLen || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return Header{}, ErrInvalidHeader
+	}
+
+	var h Header
+
+	switch (b[1] >> 3) & 0x03 {
+	case 0:
+		h.Version = MPEG25
+	case 2:
+		h.Version = MPEG2
+	case 3:
+		h.Version = MPEG1
+	default:
+		return Header{}, ErrInvalidHeader
+	}
+
+	layer := (b[1] >> 1) & 0x03
+	if layer == 0 {
+		return Header{}, ErrInvalidHeader
+	}
+	h.Layer = 4 - int(layer)
+
+	h.Protected = b[1]&0x01 == 0
+
+	table := h.Layer - 1
+	if h.Version != MPEG1 {
+		table = 3
+		if h.Layer > 1 {
+			table = 4
+		}
+	}
+	h.Bitrate = bitrates[table][b[2]>>4]
+	if h.Bitrate <= 0 {
+		return Header{}, ErrInvalidHeader
+	}
+
+	rate := (b[2] >> 2) & 0x03
+	if rate == 3 {
+		return Header{}, ErrInvalidHeader
+	}
+	h.SampleRate = sampleRates[h.Version][rate]
+
+	h.Padding = b[2]&0x02 != 0
+	h.ChannelMode = ChannelMode(b[3] >> 6)
+
+	// Reserved emphasis.
+	if b[3]&0x03 == 2 {
+		return Header{}, ErrInvalidHeader
+	}
+
+	return h, nil
+}
+
+// Samples returns number of samples per channel in the frame.
+func (h Header) Samples() int {
+	switch {
+	case h.Layer == 1:
+		return 384
+	case h.Layer == 3 && h.Version != MPEG1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// Size returns frame size in bytes including header.
+func (h Header) Size() int {
+	if h.Layer == 1 {
+		size := 12 * h.Bitrate * 1000 / h.SampleRate
+		if h.Padding {
+			size++
+		}
+		return size * 4
+	}
+
+	size := h.Samples() / 8 * h.Bitrate * 1000 / h.SampleRate
+	if h.Padding {
+		size++
+	}
+
+	return size
+}
+
+// Channels returns number of audio channels.
+func (h Header) Channels() int {
+	if h.ChannelMode == Mono {
+		return 1
+	}
+	return 2
+}
+
+// sideInfoLen returns length of layer III side information.
+func (h Header) sideInfoLen() int {
+	switch {
+	case h.Version == MPEG1 && h.ChannelMode != Mono:
+		return 32
+	case h.Version == MPEG1, h.ChannelMode != Mono:
+		return 17
+	default:
+		return 9
+	}
+}
@@ -0,0 +1,357 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// ID3v2HeaderLen is length of ID3v2 tag header.
+	ID3v2HeaderLen = 10
+	// ID3v1Len is length of ID3v1 tag.
+	ID3v1Len = 128
+)
+
+var ErrInvalidTag = errors.New("invalid id3 tag")
+
+// Tags are textual tags of a track.
+type Tags struct {
+	Title    string
+	Artist   string
+	Album    string
+	Year     string
+	HasCover bool
+}
+
+// ID3v2Size returns full size of ID3v2 tag starting with header,
+// or 0 if header does not start ID3v2 tag.
+func ID3v2Size(header []byte) int {
+	if len(header) < ID3v2HeaderLen || string(header[:3]) != "ID3" ||
+		header[3] == 0xFF || header[4] == 0xFF {
+		return 0
+	}
+
+	size, ok := syncsafe(header[6:10])
+	if !ok {
+		return 0
+	}
+
+	size += ID3v2HeaderLen
+	// Footer.
+	if header[3] == 4 && header[5]&0x10 != 0 {
+		size += ID3v2HeaderLen
+	}
+
+	return size
+}
+
+// ParseID3v2 parses tags from complete ID3v2 tag.
+func ParseID3v2(tag []byte) (Tags, error) {
+	var tags Tags
+
+	err := ID3v2Frames(tag, func(id string, data []byte) {
+		switch id {
+		case "TIT2":
+			tags.Title = decodeText(data)
+		case "TPE1":
+			tags.Artist = decodeText(data)
+		case "TALB":
+			tags.Album = decodeText(data)
+		case "TYER", "TDRC":
+			if year := decodeText(data); len(year) >= 4 {
+				tags.Year = year[:4]
+			}
+		case "APIC":
+			tags.HasCover = true
+		}
+	})
+
+	return tags, err
+}
+
+// v22Frames maps ID3v2.2 frame ids to ID3v2.3 ones.
+var v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TAL": "TALB",
+	"TYE": "TYER",
+	"PIC": "APIC",
+}
+
+// ID3v2Frames calls fn for every frame of complete ID3v2 tag.
+//
+// Frame ids of ID3v2.2 are converted to ID3v2.3 ones,
+// unsynchronisation is removed, compressed and
+// encrypted frames are skipped.
+func ID3v2Frames(tag []byte, fn func(id string, data []byte)) error {
+	return walkID3v2(tag, func(major byte, f id3v2Frame) {
+		id := f.id
+		if major == 2 {
+			var ok bool
+			if id, ok = v22Frames[id]; !ok {
+				return
+			}
+		}
+
+		if data, ok := f.content(major); ok {
+			fn(id, data)
+		}
+	})
+}
+
+// id3v2Frame is frame of ID3v2 tag as it is stored.
+type id3v2Frame struct {
+	id    string
+	flags uint16
+	// raw is the whole frame including header.
+	raw  []byte
+	data []byte
+}
+
+// content returns data of the frame without frame unsynchronisation
+// and data length indicator, it is false for compressed
+// or encrypted frames.
+func (f id3v2Frame) content(major byte) ([]byte, bool) {
+	data := f.data
+
+	switch major {
+	case 3:
+		// Compressed or encrypted.
+		if f.flags&0x00C0 != 0 {
+			return nil, false
+		}
+	case 4:
+		// Compressed or encrypted.
+		if f.flags&0x000C != 0 {
+			return nil, false
+		}
+		// Data length indicator.
+		if f.flags&0x0001 != 0 {
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:]
+		}
+		if f.flags&0x0002 != 0 {
+			data = unsync(data)
+		}
+	}
+
+	return data, true
+}
+
+// walkID3v2 calls fn for every frame of complete ID3v2 tag
+// with unsynchronisation of the whole tag removed.
+func walkID3v2(tag []byte, fn func(major byte, f id3v2Frame)) error {
+	size := ID3v2Size(tag)
+	if size == 0 || len(tag) < size {
+		return ErrInvalidTag
+	}
+
+	major, flags := tag[3], tag[5]
+	body := tag[ID3v2HeaderLen : size-footerLen(tag)]
+
+	if major < 2 || major > 4 {
+		return ErrInvalidTag
+	}
+
+	// Whole tag unsynchronisation before ID3v2.4.
+	if major < 4 && flags&0x80 != 0 {
+		body = unsync(body)
+	}
+
+	// Extended header.
+	if major > 2 && flags&0x40 != 0 {
+		if len(body) < 4 {
+			return ErrInvalidTag
+		}
+
+		var n int
+		if major == 3 {
+			n = int(binary.BigEndian.Uint32(body)) + 4
+		} else {
+			var ok bool
+			if n, ok = syncsafe(body[:4]); !ok {
+				return ErrInvalidTag
+			}
+		}
+
+		if n > len(body) {
+			return ErrInvalidTag
+		}
+		body = body[n:]
+	}
+
+	idLen, headerLen := 4, 10
+	if major == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	for len(body) >= headerLen && body[0] != 0 {
+		f := id3v2Frame{id: string(body[:idLen])}
+
+		var n int
+		switch major {
+		case 2:
+			n = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			n = int(binary.BigEndian.Uint32(body[4:8]))
+		default:
+			var ok bool
+			if n, ok = syncsafe(body[4:8]); !ok {
+				return ErrInvalidTag
+			}
+		}
+
+		if n > len(body)-headerLen {
+			return ErrInvalidTag
+		}
+
+		if major > 2 {
+			f.flags = binary.BigEndian.Uint16(body[8:10])
+		}
+
+		f.raw = body[:headerLen+n]
+		f.data = body[headerLen : headerLen+n]
+		body = body[headerLen+n:]
+
+		fn(major, f)
+	}
+
+	return nil
+}
+
+// ParseID3v1 parses ID3v1 tag from its 128 bytes.
+func ParseID3v1(tag []byte) (Tags, bool) {
+	if len(tag) != ID3v1Len || string(tag[:3]) != "TAG" {
+		return Tags{}, false
+	}
+
+	return Tags{
+		Title:  latin1(tag[3:33]),
+		Artist: latin1(tag[33:63]),
+		Album:  latin1(tag[63:93]),
+		Year:   latin1(tag[93:97]),
+	}, true
+}
+
+// Merge fills empty fields of t with fields of other.
+func (t Tags) Merge(other Tags) Tags {
+	if t.Title == "" {
+		t.Title = other.Title
+	}
+	if t.Artist == "" {
+		t.Artist = other.Artist
+	}
+	if t.Album == "" {
+		t.Album = other.Album
+	}
+	if t.Year == "" {
+		t.Year = other.Year
+	}
+	t.HasCover = t.HasCover || other.HasCover
+
+	return t
+}
+
+func footerLen(tag []byte) int {
+	if tag[3] == 4 && tag[5]&0x10 != 0 {
+		return ID3v2HeaderLen
+	}
+	return 0
+}
+
+// syncsafe decodes 28-bit syncsafe integer.
+func syncsafe(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b[:4] {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | int(c)
+	}
+	return n, true
+}
+
+// unsync removes unsynchronisation: zero byte following 0xFF.
+func unsync(b []byte) []byte {
+	if !bytes.Contains(b, []byte{0xFF, 0x00}) {
+		return b
+	}
+
+	res := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		res = append(res, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+
+	return res
+}
+
+// decodeText decodes first value of text frame.
+func decodeText(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	enc, data := data[0], data[1:]
+
+	var text string
+	switch enc {
+	case 0:
+		text = latin1(data)
+	case 1, 2:
+		bigEndian := enc == 2
+		if len(data) >= 2 {
+			switch {
+			case data[0] == 0xFF && data[1] == 0xFE:
+				bigEndian, data = false, data[2:]
+			case data[0] == 0xFE && data[1] == 0xFF:
+				bigEndian, data = true, data[2:]
+			}
+		}
+
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			var u uint16
+			if bigEndian {
+				u = binary.BigEndian.Uint16(data[i:])
+			} else {
+				u = binary.LittleEndian.Uint16(data[i:])
+			}
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		text = string(utf16.Decode(units))
+	case 3:
+		text = string(data)
+	default:
+		return ""
+	}
+
+	// Multiple values are separated by zero.
+	text, _, _ = strings.Cut(text, "\x00")
+
+	return strings.TrimSpace(text)
+}
+
+// latin1 decodes zero terminated ISO-8859-1 string.
+func latin1(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+
+	return strings.TrimSpace(string(runes))
+}
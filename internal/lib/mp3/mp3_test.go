@@ -0,0 +1,410 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   Header
+		size   int
+		err    bool
+	}{
+		{
+			name:   "mpeg1 layer3",
+			header: []byte{0xFF, 0xFB, 0x90, 0x00},
+			want:   Header{Version: MPEG1, Layer: 3, Bitrate: 128, SampleRate: 44100},
+			size:   417,
+		},
+		{
+			name:   "mpeg1 layer3 padding mono",
+			header: []byte{0xFF, 0xFB, 0x92, 0xC0},
+			want:   Header{Version: MPEG1, Layer: 3, Bitrate: 128, SampleRate: 44100, Padding: true, ChannelMode: Mono},
+			size:   418,
+		},
+		{
+			name:   "mpeg2 layer3 crc",
+			header: []byte{0xFF, 0xF2, 0x44, 0x00},
+			want:   Header{Version: MPEG2, Layer: 3, Protected: true, Bitrate: 32, SampleRate: 24000},
+			size:   96,
+		},
+		{
+			name:   "mpeg1 layer1",
+			header: []byte{0xFF, 0xFF, 0x90, 0x00},
+			want:   Header{Version: MPEG1, Layer: 1, Bitrate: 288, SampleRate: 44100},
+			size:   312,
+		},
+		{name: "no sync", header: []byte{0xFF, 0x0B, 0x90, 0x00}, err: true},
+		{name: "reserved version", header: []byte{0xFF, 0xEB, 0x90, 0x00}, err: true},
+		{name: "reserved layer", header: []byte{0xFF, 0xF9, 0x90, 0x00}, err: true},
+		{name: "free bitrate", header: []byte{0xFF, 0xFB, 0x00, 0x00}, err: true},
+		{name: "bad bitrate", header: []byte{0xFF, 0xFB, 0xF0, 0x00}, err: true},
+		{name: "reserved sample rate", header: []byte{0xFF, 0xFB, 0x9C, 0x00}, err: true},
+		{name: "short", header: []byte{0xFF, 0xFB}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := ParseHeader(tt.header)
+			if tt.err {
+				require.ErrorIs(t, err, ErrInvalidHeader)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, h)
+			require.Equal(t, tt.size, h.Size())
+		})
+	}
+}
+
+func TestAnalyzeCBR(t *testing.T) {
+	var track []byte
+	track = append(track, mp3test.ID3v2(map[string]string{
+		"TIT2": "Title",
+		"TPE1": "Artist",
+		"APIC": "cover",
+	})...)
+	track = append(track, mp3test.Silence(100)...)
+	track = append(track, mp3test.ID3v1("v1 title", "v1 artist", "Album", "1999")...)
+
+	info, err := Analyze(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+
+	require.Equal(t, Tags{Title: "Title", Artist: "Artist", Album: "Album", Year: "1999", HasCover: true}, info.Tags)
+	require.Equal(t, 100, info.Frames)
+	require.Equal(t, BitrateCBR, info.BitrateMode)
+	require.Equal(t, 128, info.Bitrate)
+	require.Equal(t, 44100, info.Header.SampleRate)
+	require.Equal(t, 2, info.Header.Channels())
+	require.Equal(t, time.Duration(100*1152)*time.Second/44100, info.Duration)
+	require.Equal(t, int64(100*mp3test.FrameLen), info.AudioSize)
+	require.Equal(t, info.AudioSize, info.FrameBytes)
+	require.Nil(t, info.Xing)
+}
+
+func TestAnalyzeXing(t *testing.T) {
+	// Xing header claims 1000 frames with LAME delay and padding.
+	first := mp3test.Silence(1)
+	offset := HeaderLen + 32
+	copy(first[offset:], "Xing")
+	binary.BigEndian.PutUint32(first[offset+4:], xingFrames|xingBytes)
+	binary.BigEndian.PutUint32(first[offset+8:], 1000)
+	binary.BigEndian.PutUint32(first[offset+12:], 1000*mp3test.FrameLen)
+	ext := first[offset+16:]
+	copy(ext, "LAME3.100")
+	ext[9] = 0x04
+	// Delay 576, padding 1152.
+	ext[21], ext[22], ext[23] = 0x24, 0x04, 0x80
+
+	track := append(first, mp3test.Silence(10)...)
+
+	info, err := Analyze(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+	require.NotNil(t, info.Xing)
+	require.Equal(t, Xing{Frames: 1000, Bytes: 1000 * mp3test.FrameLen, Encoder: "LAME3.100", Method: 4, Delay: 576, Padding: 1152}, *info.Xing)
+	require.Equal(t, 10, info.Frames)
+	require.Equal(t, BitrateVBR, info.BitrateMode)
+	require.Equal(t, time.Duration(1000*1152-576-1152)*time.Second/44100, info.Duration)
+}
+
+func TestAnalyzeResync(t *testing.T) {
+	var track []byte
+	track = append(track, []byte("<html>garbage</html>")...)
+	track = append(track, mp3test.Silence(5)...)
+	track = append(track, 0xFF, 0xFB, 0x90)
+	track = append(track, mp3test.Silence(5)...)
+	// Truncated frame.
+	track = append(track, mp3test.Silence(1)[:100]...)
+
+	info, err := Analyze(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+	require.Equal(t, 10, info.Frames)
+	require.Equal(t, int64(10*mp3test.FrameLen), info.FrameBytes)
+	require.Equal(t, int64(len(track)), info.AudioSize)
+}
+
+func TestAnalyzeNoFrames(t *testing.T) {
+	track := append(mp3test.ID3v2(map[string]string{"TIT2": "Title"}), []byte("not an mp3")...)
+
+	info, err := Analyze(bytes.NewReader(track), int64(len(track)))
+	require.True(t, errors.Is(err, ErrNoFrames))
+	require.Equal(t, "Title", info.Tags.Title)
+}
+
+func TestParseID3v2(t *testing.T) {
+	utf16 := []byte{1, 0xFF, 0xFE, 'T', 0, 0x16, 0x04, 0, 0}
+
+	tests := []struct {
+		name string
+		tag  []byte
+		want Tags
+	}{
+		{
+			name: "v2.2",
+			tag: append([]byte{'I', 'D', '3', 2, 0, 0, 0, 0, 0, 18},
+				'T', 'T', '2', 0, 0, 6, 0, 'T', 'i', 't', 'l', 'e',
+				'P', 'I', 'C', 0, 0, 0),
+			want: Tags{Title: "Title", HasCover: true},
+		},
+		{
+			name: "v2.3 utf16",
+			tag: append(append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 19},
+				'T', 'P', 'E', '1', 0, 0, 0, byte(len(utf16)), 0, 0), utf16...),
+			want: Tags{Artist: "TЖ"},
+		},
+		{
+			name: "v2.4 recording time",
+			tag: append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 22},
+				'T', 'D', 'R', 'C', 0, 0, 0, 12, 0, 0, 3, '2', '0', '0', '1', '-', '0', '5', '-', '0', '3', 0),
+			want: Tags{Year: "2001"},
+		},
+		{
+			name: "v2.3 unsynchronised",
+			tag: append([]byte{'I', 'D', '3', 3, 0, 0x80, 0, 0, 0, 16},
+				'T', 'A', 'L', 'B', 0, 0, 0, 5, 0, 0, 0, 'A', 0xFF, 0x00, 'b', 0),
+			want: Tags{Album: "Aÿb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := ParseID3v2(tt.tag)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, tags)
+		})
+	}
+
+	_, err := ParseID3v2([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 20, 'T', 'I', 'T', '2', 0, 0, 1, 0})
+	require.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestID3v2Pictures(t *testing.T) {
+	cover := []byte{0x89, 'P', 'N', 'G', 0, 1, 2}
+
+	tag := mp3test.ID3v2Picture(map[string]string{"TIT2": "Title"}, "image/png", cover)
+	pictures, err := ID3v2Pictures(tag)
+	require.NoError(t, err)
+	require.Equal(t, []Picture{{MIME: "image/png", Type: PictureFrontCover, Data: cover}}, pictures)
+
+	// ID3v2.2 with format instead of MIME type
+	// and UTF-16 description.
+	v22 := append([]byte{'I', 'D', '3', 2, 0, 0, 0, 0, 0, 21},
+		'P', 'I', 'C', 0, 0, 15, 1, 'J', 'P', 'G', 0, 0xFF, 0xFE, 'D', 0, 0, 0, 0xFF, 0xD8, 0xFF, 0)
+	pictures, err = ID3v2Pictures(v22)
+	require.NoError(t, err)
+	require.Equal(t, []Picture{{MIME: "image/jpeg", Data: []byte{0xFF, 0xD8, 0xFF, 0}}}, pictures)
+
+	// Linked picture.
+	link := mp3test.ID3v2Picture(nil, "-->", []byte("http://example.com/cover.jpg"))
+	pictures, err = ID3v2Pictures(link)
+	require.NoError(t, err)
+	require.Empty(t, pictures)
+}
+
+func TestStripID3v2Pictures(t *testing.T) {
+	frames := map[string]string{"TIT2": "Title", "TPE1": "Artist"}
+
+	tag := mp3test.ID3v2Picture(frames, "image/png", []byte{1, 2, 3})
+	res, err := StripID3v2Pictures(tag)
+	require.NoError(t, err)
+	require.Equal(t, mp3test.ID3v2(frames), res)
+
+	tags, err := ParseID3v2(res)
+	require.NoError(t, err)
+	require.Equal(t, Tags{Title: "Title", Artist: "Artist"}, tags)
+
+	// Tag without pictures is kept with its padding.
+	padded := append(mp3test.ID3v2(frames), make([]byte, 16)...)
+	padded[9] += 16
+	res, err = StripID3v2Pictures(padded)
+	require.NoError(t, err)
+	require.Equal(t, padded, res)
+
+	// Unsynchronisation of the whole tag is removed.
+	unsynced := append([]byte{'I', 'D', '3', 3, 0, 0x80, 0, 0, 0, 28},
+		'T', 'A', 'L', 'B', 0, 0, 0, 3, 0, 0, 0, 0xFF, 0x00, 'b',
+		'A', 'P', 'I', 'C', 0, 0, 0, 4, 0, 0, 0, 0, 0, 3, 0)
+	res, err = StripID3v2Pictures(unsynced)
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 13},
+		'T', 'A', 'L', 'B', 0, 0, 0, 3, 0, 0, 0, 0xFF, 'b'), res)
+
+	_, err = StripID3v2Pictures([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 20})
+	require.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestBuildID3v2(t *testing.T) {
+	tags := Tags{Title: "Café", Artist: "Кино", Year: "1988"}
+
+	tag := BuildID3v2(tags)
+	require.Equal(t, len(tag), ID3v2Size(tag))
+
+	res, err := ParseID3v2(tag)
+	require.NoError(t, err)
+	require.Equal(t, tags, res)
+
+	require.Nil(t, BuildID3v2(Tags{HasCover: true}))
+}
+
+func TestRewriteTags(t *testing.T) {
+	audio := append(mp3test.Silence(2), mp3test.Sound(3)...)
+
+	var data []byte
+	data = append(data, mp3test.ID3v2(map[string]string{"TIT2": "Junk"})...)
+	data = append(data, audio...)
+	data = append(data, mp3test.ID3v1("Junk", "", "", "")...)
+
+	read := func(r io.ReaderAt, size int64) []byte {
+		t.Helper()
+		res, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		require.NoError(t, err)
+		return res
+	}
+
+	r, size, err := RewriteTags(bytes.NewReader(data), int64(len(data)), nil)
+	require.NoError(t, err)
+	require.Equal(t, audio, read(r, size))
+
+	tag := BuildID3v2(Tags{Title: "Song"})
+	r, size, err = RewriteTags(bytes.NewReader(data), int64(len(data)), tag)
+	require.NoError(t, err)
+	require.Equal(t, append(tag, audio...), read(r, size))
+
+	// Reads crossing the tag.
+	p := make([]byte, 4)
+	n, err := r.ReadAt(p, int64(len(tag))-2)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, append(tag[len(tag)-2:], audio[:2]...), p)
+
+	_, err = r.ReadAt(p, size-2)
+	require.ErrorIs(t, err, io.EOF)
+
+	// Rewritten stream is trimmed as stored one.
+	trimmed, _, err := TrimSilence(r, size)
+	require.NoError(t, err)
+	res, err := io.ReadAll(trimmed)
+	require.NoError(t, err)
+	require.Equal(t, append(tag, mp3test.Sound(3)...), res)
+}
+
+func TestTrimSilence(t *testing.T) {
+	tagV2 := mp3test.ID3v2(map[string]string{"TIT2": "Title"})
+	tagV1 := mp3test.ID3v1("Title", "Artist", "Album", "1999")
+
+	var track []byte
+	track = append(track, tagV2...)
+	track = append(track, mp3test.Silence(20)...)
+	track = append(track, mp3test.Sound(10)...)
+	track = append(track, mp3test.Silence(30)...)
+	track = append(track, tagV1...)
+
+	// One silent frame is kept after audio.
+	var want []byte
+	want = append(want, tagV2...)
+	want = append(want, mp3test.Sound(10)...)
+	want = append(want, mp3test.Silence(1)...)
+	want = append(want, tagV1...)
+
+	r, res, err := TrimSilence(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+	require.Equal(t, Trimmed{Leading: 20, Trailing: 29, Size: int64(len(want))}, res)
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestTrimSilenceReservoir(t *testing.T) {
+	sound := mp3test.Sound(1)
+	// Main data begins 500 bytes before the frame,
+	// which takes main data of two preceding frames.
+	sound[4], sound[5] = 500>>1, 500&1<<7
+
+	track := append(mp3test.Silence(5), sound...)
+	track = append(track, mp3test.Sound(2)...)
+
+	r, res, err := TrimSilence(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+	require.Equal(t, 3, res.Leading)
+	require.Equal(t, 0, res.Trailing)
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, track[3*mp3test.FrameLen:], got)
+}
+
+func TestTrimSilenceXing(t *testing.T) {
+	first := mp3test.Silence(1)
+	offset := HeaderLen + 32
+	copy(first[offset:], "Xing")
+	binary.BigEndian.PutUint32(first[offset+4:], xingFrames|xingBytes|xingTOC)
+	binary.BigEndian.PutUint32(first[offset+8:], 14)
+	binary.BigEndian.PutUint32(first[offset+12:], 14*mp3test.FrameLen)
+	extOffset := offset + 16 + 100
+	ext := first[extOffset:]
+	copy(ext, "LAME3.100")
+	ext[9] = 0x04
+	// Delay 576, padding 1152.
+	ext[21], ext[22], ext[23] = 0x24, 0x04, 0x80
+
+	track := append(first, mp3test.Silence(5)...)
+	track = append(track, mp3test.Sound(3)...)
+	track = append(track, mp3test.Silence(5)...)
+
+	r, res, err := TrimSilence(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+	require.Equal(t, 5, res.Leading)
+	require.Equal(t, 4, res.Trailing)
+	require.Equal(t, int64(5*mp3test.FrameLen), res.Size)
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Len(t, got, 5*mp3test.FrameLen)
+	require.Equal(t, track[6*mp3test.FrameLen:10*mp3test.FrameLen], got[mp3test.FrameLen:])
+
+	info, err := Analyze(bytes.NewReader(got), int64(len(got)))
+	require.NoError(t, err)
+	require.Equal(t, Xing{Frames: 4, Bytes: 5 * mp3test.FrameLen, Encoder: "LAME3.100", Method: 4}, *info.Xing)
+	require.Equal(t, time.Duration(4*1152)*time.Second/44100, info.Duration)
+
+	// Frames are evenly spread over the stream.
+	toc := got[offset+16 : offset+16+100]
+	require.Equal(t, byte(51), toc[0])
+	require.Equal(t, byte(102), toc[25])
+	require.Equal(t, byte(204), toc[99])
+
+	gotExt := got[extOffset:]
+	require.Equal(t, uint32(5*mp3test.FrameLen), binary.BigEndian.Uint32(gotExt[28:]))
+
+	var music crc16
+	music.Write(got[mp3test.FrameLen:])
+	require.Equal(t, uint16(music), binary.BigEndian.Uint16(gotExt[32:]))
+
+	var tag crc16
+	tag.Write(got[:extOffset+lameTagCRCLen])
+	require.Equal(t, uint16(tag), binary.BigEndian.Uint16(gotExt[lameTagCRCLen:]))
+}
+
+func TestTrimSilenceOnly(t *testing.T) {
+	track := mp3test.Silence(10)
+
+	r, res, err := TrimSilence(bytes.NewReader(track), int64(len(track)))
+	require.NoError(t, err)
+	require.Equal(t, Trimmed{Size: int64(len(track))}, res)
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, track, got)
+}
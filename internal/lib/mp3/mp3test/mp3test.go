@@ -0,0 +1,245 @@
+// Package mp3test builds MP3 streams for tests.
+package mp3test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+)
+
+const (
+	// FrameLen is length of frames built by Silence.
+	FrameLen = 417
+	// FrameSamples is number of samples per channel in a frame.
+	FrameSamples = 1152
+	// SampleRate of frames built by Silence.
+	SampleRate = 44100
+)
+
+// Silence returns n silent MPEG1 Layer III frames,
+// 128 kbps, 44.1 kHz, stereo.
+func Silence(n int) []byte {
+	frame := make([]byte, FrameLen)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+
+	return bytes.Repeat(frame, n)
+}
+
+// Sound returns n MPEG1 Layer III frames of the same format
+// as Silence, which carry main data and so are not silent.
+// Their audio is not meant to be decoded.
+func Sound(n int) []byte {
+	frame := make([]byte, FrameLen)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	// part2_3_length of the first granule is 256 bits.
+	frame[6] = 0x01
+	for i := 36; i < len(frame); i++ {
+		frame[i] = byte(i)
+	}
+
+	return bytes.Repeat(frame, n)
+}
+
+// ID3v2 returns ID3v2.3 tag with given text frames
+// encoded as ISO-8859-1.
+func ID3v2(frames map[string]string) []byte {
+	return ID3v2Picture(frames, "", nil)
+}
+
+// ID3v2Picture returns ID3v2.3 tag with given text frames
+// and front cover picture of given MIME type, which is
+// omitted if picture is empty.
+func ID3v2Picture(frames map[string]string, mime string, picture []byte) []byte {
+	ids := make([]string, 0, len(frames))
+	for id := range frames {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var body bytes.Buffer
+	for _, id := range ids {
+		body.WriteString(id)
+		binary.Write(&body, binary.BigEndian, uint32(len(frames[id])+1))
+		body.Write([]byte{0, 0, 0})
+		body.WriteString(frames[id])
+	}
+
+	if len(picture) > 0 {
+		// Encoding, MIME type, picture type and empty description.
+		body.WriteString("APIC")
+		binary.Write(&body, binary.BigEndian, uint32(len(mime)+4+len(picture)))
+		body.Write([]byte{0, 0, 0})
+		body.WriteString(mime)
+		body.Write([]byte{0, 3, 0})
+		body.Write(picture)
+	}
+
+	size := body.Len()
+	header := []byte{
+		'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F),
+	}
+
+	return append(header, body.Bytes()...)
+}
+
+// ID3v1 returns ID3v1 tag.
+func ID3v1(title, artist, album, year string) []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:33], title)
+	copy(tag[33:63], artist)
+	copy(tag[63:93], album)
+	copy(tag[93:97], year)
+
+	return tag
+}
+
+const (
+	// GranuleSamples is number of samples per channel in a granule,
+	// frames carry two of them.
+	GranuleSamples = FrameSamples / 2
+	// LineFreq is frequency step of MDCT lines in granules
+	// built by Tones.
+	LineFreq = SampleRate / 2.0 / GranuleSamples
+	// FullGain is gain of Granule giving lines unit amplitude.
+	FullGain = 210
+)
+
+// Granule is content of a granule built by Tones.
+type Granule struct {
+	// Lines are MDCT lines played, line k sounds
+	// at about (k+0.5)*LineFreq Hz.
+	Lines []int
+	// Gain is global gain of the granule, step of which is 1.5 dB.
+	Gain int
+}
+
+// Tones returns MPEG1 Layer III frames of the same format
+// as Silence playing given granules, last frame is padded
+// with silent granule. Unlike Sound, frames decode to audio.
+//
+// Line played in adjacent granules sounds as steady tone.
+func Tones(granules []Granule) []byte {
+	if len(granules)%2 != 0 {
+		granules = append(granules, Granule{})
+	}
+
+	var res []byte
+	for i := 0; i < len(granules); i += 2 {
+		res = append(res, tonesFrame(granules[i:i+2], i)...)
+	}
+
+	return res
+}
+
+// tonesFrame builds frame of two granules starting at given index.
+// Every line is coded as 1 in count1 region with table B, which
+// codes quadruple of values as inverted 4 bits followed by signs
+// of nonzero ones, so no Huffman tables are needed.
+// Both channels are the same.
+func tonesFrame(granules []Granule, index int) []byte {
+	var side, main bitWriter
+
+	// main_data_begin, private_bits and scfsi of both channels.
+	side.write(0, 9)
+	side.write(0, 3)
+	side.write(0, 8)
+
+	for i, gr := range granules {
+		values := make([]int, 0)
+		for _, line := range gr.Lines {
+			for len(values) <= line|3 {
+				values = append(values, 0)
+			}
+			values[line] = 1
+			if negative(line, index+i) {
+				values[line] = -1
+			}
+		}
+
+		var data bitWriter
+		for q := 0; q < len(values); q += 4 {
+			var code uint32
+			for _, v := range values[q : q+4] {
+				code <<= 1
+				if v == 0 {
+					code |= 1
+				}
+			}
+			data.write(code, 4)
+			for _, v := range values[q : q+4] {
+				switch v {
+				case 1:
+					data.write(0, 1)
+				case -1:
+					data.write(1, 1)
+				}
+			}
+		}
+
+		for ch := 0; ch < 2; ch++ {
+			// part2_3_length, big_values, global_gain, scalefac_compress,
+			// window_switching_flag, table_select, region0_count,
+			// region1_count, preflag, scalefac_scale and count1table_select.
+			side.write(uint32(data.n), 12)
+			side.write(0, 9)
+			side.write(uint32(gr.Gain), 8)
+			side.write(0, 4)
+			side.write(0, 1)
+			side.write(0, 15)
+			side.write(0, 4)
+			side.write(0, 3)
+			side.write(0, 1)
+			side.write(0, 1)
+			side.write(1, 1)
+
+			main.append(data)
+		}
+	}
+
+	frame := make([]byte, FrameLen)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	copy(frame[4:], side.bytes())
+	if n := copy(frame[4+len(side.bytes()):], main.bytes()); n < len(main.bytes()) {
+		panic("mp3test: granules do not fit frame")
+	}
+
+	return frame
+}
+
+// negative reports sign of line in granule. Phase of tone
+// at the line advances by (2*line+1)*pi/2 every granule,
+// so signs follow cos(pi/4 + granule*(2*line+1)*pi/2).
+func negative(line, granule int) bool {
+	step := granule * (2*line + 1) % 4
+	return step == 1 || step == 2
+}
+
+// bitWriter writes bits most significant first.
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) write(v uint32, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>i&1 != 0 {
+			w.buf[w.n/8] |= 0x80 >> (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+func (w *bitWriter) append(other bitWriter) {
+	for i := 0; i < other.n; i++ {
+		w.write(uint32(other.buf[i/8]>>(7-i%8)&1), 1)
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	return w.buf
+}
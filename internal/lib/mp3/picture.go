@@ -0,0 +1,166 @@
+package mp3
+
+import (
+	"bytes"
+	"strings"
+)
+
+// PictureFrontCover is ID3v2 picture type of front cover.
+const PictureFrontCover = 3
+
+// Picture is picture attached to ID3v2 tag.
+type Picture struct {
+	// MIME is MIME type of Data, may be empty.
+	MIME string
+	Type byte
+	Data []byte
+}
+
+// v22ImageFormats maps image formats of ID3v2.2 to MIME types.
+var v22ImageFormats = map[string]string{
+	"JPG": "image/jpeg",
+	"PNG": "image/png",
+}
+
+// ID3v2Pictures returns pictures attached to complete ID3v2 tag.
+// Linked pictures and ones of compressed or encrypted frames are skipped.
+func ID3v2Pictures(tag []byte) ([]Picture, error) {
+	var pictures []Picture
+
+	err := walkID3v2(tag, func(major byte, f id3v2Frame) {
+		if f.id != "APIC" && (major != 2 || f.id != "PIC") {
+			return
+		}
+
+		data, ok := f.content(major)
+		if !ok {
+			return
+		}
+		if p, ok := parsePicture(major, data); ok {
+			pictures = append(pictures, p)
+		}
+	})
+
+	return pictures, err
+}
+
+// parsePicture parses body of APIC frame, or PIC one of ID3v2.2.
+func parsePicture(major byte, data []byte) (Picture, bool) {
+	if len(data) < 1 {
+		return Picture{}, false
+	}
+	encoding := data[0]
+	data = data[1:]
+
+	var p Picture
+	if major == 2 {
+		if len(data) < 3 {
+			return Picture{}, false
+		}
+		p.MIME = v22ImageFormats[strings.ToUpper(string(data[:3]))]
+		data = data[3:]
+	} else {
+		i := bytes.IndexByte(data, 0)
+		if i < 0 {
+			return Picture{}, false
+		}
+		p.MIME = strings.ToLower(string(data[:i]))
+		data = data[i+1:]
+		// Some taggers omit "image/".
+		if p.MIME != "" && p.MIME != "-->" && !strings.Contains(p.MIME, "/") {
+			p.MIME = "image/" + p.MIME
+		}
+	}
+	// Picture is a link.
+	if p.MIME == "-->" {
+		return Picture{}, false
+	}
+
+	if len(data) < 1 {
+		return Picture{}, false
+	}
+	p.Type = data[0]
+	data = data[1:]
+
+	// Description is terminated by null character
+	// of its encoding, which is two bytes for UTF-16.
+	if encoding == 1 || encoding == 2 {
+		i := 0
+		for ; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				break
+			}
+		}
+		if i+1 >= len(data) {
+			return Picture{}, false
+		}
+		data = data[i+2:]
+	} else {
+		i := bytes.IndexByte(data, 0)
+		if i < 0 {
+			return Picture{}, false
+		}
+		data = data[i+1:]
+	}
+
+	if len(data) == 0 {
+		return Picture{}, false
+	}
+	p.Data = data
+
+	return p, true
+}
+
+// StripID3v2Pictures returns complete ID3v2 tag without
+// attached pictures. Tag without pictures is returned as is,
+// otherwise padding, extended header and footer are dropped
+// and so is unsynchronisation of the whole tag.
+func StripID3v2Pictures(tag []byte) ([]byte, error) {
+	var (
+		frames   [][]byte
+		stripped bool
+		major    byte
+	)
+	if err := walkID3v2(tag, func(m byte, f id3v2Frame) {
+		major = m
+		if f.id == "APIC" || (m == 2 && f.id == "PIC") {
+			stripped = true
+			return
+		}
+		frames = append(frames, f.raw)
+	}); err != nil {
+		return nil, err
+	}
+
+	if !stripped {
+		return tag, nil
+	}
+
+	var size int
+	for _, f := range frames {
+		size += len(f)
+	}
+
+	res := make([]byte, ID3v2HeaderLen, ID3v2HeaderLen+size)
+	copy(res, tag[:5])
+	res[5] = tag[5] &^ 0xD0
+	// ID3v2.2 uses the first flag bit for
+	// unsynchronisation too and the second for compression.
+	if major == 2 {
+		res[5] = tag[5] &^ 0x80
+	}
+	putSyncsafe(res[6:], size)
+	for _, f := range frames {
+		res = append(res, f...)
+	}
+
+	return res, nil
+}
+
+// putSyncsafe encodes 28-bit syncsafe integer.
+func putSyncsafe(b []byte, n int) {
+	b[0] = byte(n >> 21 & 0x7F)
+	b[1] = byte(n >> 14 & 0x7F)
+	b[2] = byte(n >> 7 & 0x7F)
+	b[3] = byte(n & 0x7F)
+}
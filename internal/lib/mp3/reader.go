@@ -0,0 +1,111 @@
+package mp3
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Frame is single MPEG audio frame.
+type Frame struct {
+	Header Header
+	// Offset of the frame in the stream.
+	Offset int64
+	// Data is the whole frame including header.
+	Data []byte
+}
+
+// Reader reads MPEG audio frames from stream,
+// skipping any data between them.
+type Reader struct {
+	r       *bufio.Reader
+	offset  int64
+	skipped int64
+	synced  bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r: bufio.NewReaderSize(r, 1024*16),
+	}
+}
+
+// Next returns next frame or io.EOF at the end of stream.
+//
+// Frame data is valid until the next call.
+func (r *Reader) Next() (Frame, error) {
+	for {
+		b, err := r.r.Peek(HeaderLen)
+		if err != nil {
+			return Frame{}, r.end(err)
+		}
+
+		h, err := ParseHeader(b)
+		if err != nil {
+			r.skip(1)
+			continue
+		}
+
+		size := h.Size()
+
+		// After losing sync accept frame only if
+		// next one is consistent with it.
+		data, err := r.r.Peek(size + HeaderLen)
+		if err != nil && len(data) < size {
+			if errors.Is(err, io.EOF) {
+				// Truncated frame.
+				r.skip(1)
+				continue
+			}
+			return Frame{}, err
+		}
+		if !r.synced && len(data) == size+HeaderLen {
+			next, err := ParseHeader(data[size:])
+			if err != nil || next.Version != h.Version || next.Layer != h.Layer || next.SampleRate != h.SampleRate {
+				r.skip(1)
+				continue
+			}
+		}
+
+		frame := Frame{
+			Header: h,
+			Offset: r.offset,
+			Data:   data[:size],
+		}
+
+		r.r.Discard(size)
+		r.offset += int64(size)
+		r.synced = true
+
+		return frame, nil
+	}
+}
+
+// Skipped returns number of bytes skipped
+// between frames so far.
+func (r *Reader) Skipped() int64 {
+	return r.skipped
+}
+
+func (r *Reader) skip(n int) {
+	n, _ = r.r.Discard(n)
+	r.offset += int64(n)
+	r.skipped += int64(n)
+	r.synced = false
+}
+
+// end skips trailing data.
+func (r *Reader) end(err error) error {
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	n, err := io.Copy(io.Discard, r.r)
+	r.offset += n
+	r.skipped += n
+	if err != nil {
+		return err
+	}
+
+	return io.EOF
+}
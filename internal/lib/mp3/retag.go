@@ -0,0 +1,118 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+// BuildID3v2 returns ID3v2.3 tag with text frames of tags.
+// HasCover is ignored and empty fields are omitted,
+// so empty tags give no tag at all.
+//
+// Text which fits ISO-8859-1 is encoded as such,
+// other text as UTF-16 for compatibility with old players.
+func BuildID3v2(tags Tags) []byte {
+	frames := []struct {
+		id, text string
+	}{
+		{"TIT2", tags.Title},
+		{"TPE1", tags.Artist},
+		{"TALB", tags.Album},
+		{"TYER", tags.Year},
+	}
+
+	tag := make([]byte, ID3v2HeaderLen)
+	copy(tag, []byte{'I', 'D', '3', 3, 0, 0})
+
+	for _, f := range frames {
+		if f.text == "" {
+			continue
+		}
+
+		data := encodeText(f.text)
+		tag = append(tag, f.id...)
+		tag = binary.BigEndian.AppendUint32(tag, uint32(len(data)))
+		tag = append(tag, 0, 0)
+		tag = append(tag, data...)
+	}
+
+	if len(tag) == ID3v2HeaderLen {
+		return nil
+	}
+	putSyncsafe(tag[6:], len(tag)-ID3v2HeaderLen)
+
+	return tag
+}
+
+// encodeText encodes value of ID3v2.3 text frame.
+func encodeText(text string) []byte {
+	runes := []rune(text)
+
+	latin := true
+	for _, r := range runes {
+		if r > 0xFF {
+			latin = false
+			break
+		}
+	}
+
+	if latin {
+		data := make([]byte, 1, len(runes)+1)
+		for _, r := range runes {
+			data = append(data, byte(r))
+		}
+		return data
+	}
+
+	// UTF-16 with little-endian byte order mark.
+	data := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode(runes) {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	return data
+}
+
+// RewriteTags returns MP3 stream with its tags replaced by tag,
+// and size of the result. Leading ID3v2 tag and trailing ID3v1
+// and APEv2 tags are dropped, so nil tag strips all of them.
+// Audio is read from r on demand, it is never copied.
+func RewriteTags(r io.ReaderAt, size int64, tag []byte) (io.ReaderAt, int64, error) {
+	var tags Tags
+	start, end, err := audioBounds(r, size, &tags)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return sections{
+		io.NewSectionReader(bytes.NewReader(tag), 0, int64(len(tag))),
+		io.NewSectionReader(r, start, end-start),
+	}, int64(len(tag)) + end - start, nil
+}
+
+// sections reads concatenated sections.
+type sections []*io.SectionReader
+
+func (s sections) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for _, section := range s {
+		if off >= section.Size() {
+			off -= section.Size()
+			continue
+		}
+
+		m, err := section.ReadAt(p[n:], off)
+		n += m
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if n == len(p) {
+			return n, nil
+		}
+		off = 0
+	}
+
+	return n, io.EOF
+}
@@ -0,0 +1,87 @@
+package mp3
+
+// sideInfo is part of layer III side information
+// needed to cut stream on frame boundaries.
+type sideInfo struct {
+	// MainDataBegin is number of bytes main data
+	// of the frame starts before the frame.
+	MainDataBegin int
+	// Silent is set if no granule carries main data,
+	// so the frame decodes to silence.
+	Silent bool
+}
+
+// parseSideInfo parses side information of layer III frame.
+func parseSideInfo(frame Frame) (sideInfo, bool) {
+	h := frame.Header
+	if h.Layer != 3 {
+		return sideInfo{}, false
+	}
+
+	offset := HeaderLen
+	if h.Protected {
+		offset += 2
+	}
+	if len(frame.Data) < offset+h.sideInfoLen() {
+		return sideInfo{}, false
+	}
+
+	b := bitReader{data: frame.Data[offset : offset+h.sideInfoLen()]}
+	channels := h.Channels()
+
+	var si sideInfo
+	granules, granuleBits := 1, 63
+	if h.Version == MPEG1 {
+		si.MainDataBegin = b.read(9)
+		if channels == 1 {
+			b.skip(5)
+		} else {
+			b.skip(3)
+		}
+		// scfsi.
+		b.skip(4 * channels)
+		granules, granuleBits = 2, 59
+	} else {
+		si.MainDataBegin = b.read(8)
+		b.skip(channels)
+	}
+
+	si.Silent = true
+	for i := 0; i < granules*channels; i++ {
+		if b.read(12) != 0 {
+			si.Silent = false
+		}
+		b.skip(granuleBits - 12)
+	}
+
+	return si, true
+}
+
+// mainDataLen returns number of bytes of layer III frame
+// available for main data.
+func (f Frame) mainDataLen() int {
+	n := len(f.Data) - HeaderLen - f.Header.sideInfoLen()
+	if f.Header.Protected {
+		n -= 2
+	}
+	return max(n, 0)
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (b *bitReader) read(n int) int {
+	var v int
+	for i := 0; i < n; i++ {
+		bit := b.data[b.pos/8] >> (7 - b.pos%8) & 1
+		v = v<<1 | int(bit)
+		b.pos++
+	}
+	return v
+}
+
+func (b *bitReader) skip(n int) {
+	b.pos += n
+}
@@ -0,0 +1,234 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// lameTagCRCLen is length of LAME extension
+// covered by its tag CRC.
+const lameTagCRCLen = 34
+
+// Trimmed describes stream with silence trimmed.
+type Trimmed struct {
+	// Leading and Trailing are numbers of dropped frames.
+	Leading  int
+	Trailing int
+	// Size is size of trimmed stream.
+	Size int64
+}
+
+// frameInfo is position and side information of a frame.
+type frameInfo struct {
+	offset        int64
+	size          int
+	silent        bool
+	mainDataBegin int
+	mainDataLen   int
+}
+
+// TrimSilence drops whole frames of leading and trailing
+// digital silence from the track without re-encoding.
+//
+// Frames holding bit reservoir of the first audible frame are kept,
+// and so is one silent frame after audio, since its decoded samples
+// overlap the last audible frame. Tags are kept, Xing header is
+// rewritten to match trimmed audio and VBRI header is dropped.
+//
+// Track consisting of silence only is returned as is.
+// Trimmed track is read from r, it is not copied.
+func TrimSilence(r io.ReaderAt, size int64) (*io.SectionReader, Trimmed, error) {
+	const op = "mp3.TrimSilence"
+
+	var tags Tags
+	start, end, err := audioBounds(r, size, &tags)
+	if err != nil {
+		return nil, Trimmed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		header *Header
+		xing   *Xing
+		tag    []byte
+		frames []frameInfo
+	)
+
+	reader := NewReader(io.NewSectionReader(r, start, end-start))
+	for {
+		frame, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, Trimmed{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if header == nil {
+			header = &frame.Header
+			if x, ok := ParseXing(frame); ok {
+				xing = &x
+				tag = bytes.Clone(frame.Data)
+				continue
+			}
+		}
+
+		info := frameInfo{
+			offset:      start + frame.Offset,
+			size:        len(frame.Data),
+			mainDataLen: frame.mainDataLen(),
+		}
+		if si, ok := parseSideInfo(frame); ok {
+			info.silent = si.Silent
+			info.mainDataBegin = si.MainDataBegin
+		}
+		frames = append(frames, info)
+	}
+
+	if len(frames) == 0 {
+		return nil, Trimmed{}, ErrNoFrames
+	}
+
+	first, last := -1, -1
+	for i, f := range frames {
+		if !f.silent {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return io.NewSectionReader(r, 0, size), Trimmed{Size: size}, nil
+	}
+
+	for need := frames[first].mainDataBegin; need > 0 && first > 0; {
+		first--
+		need -= frames[first].mainDataLen
+	}
+	last = min(last+1, len(frames)-1)
+
+	res := Trimmed{
+		Leading:  first,
+		Trailing: len(frames) - 1 - last,
+	}
+	if res.Leading == 0 && res.Trailing == 0 {
+		res.Size = size
+		return io.NewSectionReader(r, 0, size), res, nil
+	}
+
+	kept := frames[first : last+1]
+	audioStart := kept[0].offset
+	audioEnd := kept[len(kept)-1].offset + int64(kept[len(kept)-1].size)
+	audio := io.NewSectionReader(r, audioStart, audioEnd-audioStart)
+
+	switch {
+	case xing == nil:
+	case xing.VBRI:
+		tag = nil
+	default:
+		var music crc16
+		if _, err := io.Copy(&music, audio); err != nil {
+			return nil, Trimmed{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := audio.Seek(0, io.SeekStart); err != nil {
+			return nil, Trimmed{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		rewriteXing(tag, *header, *xing, kept, res, uint16(music))
+	}
+
+	res.Size = start + int64(len(tag)) + (audioEnd - audioStart) + (size - end)
+
+	return io.NewSectionReader(sections{
+		io.NewSectionReader(r, 0, start),
+		io.NewSectionReader(bytes.NewReader(tag), 0, int64(len(tag))),
+		audio,
+		io.NewSectionReader(r, end, size-end),
+	}, 0, res.Size), res, nil
+}
+
+// rewriteXing updates Xing header in frame
+// to describe kept frames.
+func rewriteXing(frame []byte, h Header, x Xing, kept []frameInfo, trimmed Trimmed, musicCRC uint16) {
+	audioBytes := kept[len(kept)-1].offset + int64(kept[len(kept)-1].size) - kept[0].offset
+	total := uint32(int64(len(frame)) + audioBytes)
+
+	p := HeaderLen + h.sideInfoLen()
+	flags := binary.BigEndian.Uint32(frame[p+4:])
+	p += 8
+
+	if flags&xingFrames != 0 {
+		binary.BigEndian.PutUint32(frame[p:], uint32(len(kept)))
+		p += 4
+	}
+	if flags&xingBytes != 0 {
+		binary.BigEndian.PutUint32(frame[p:], total)
+		p += 4
+	}
+	if flags&xingTOC != 0 {
+		// Position of frame at every percent of duration
+		// relative to total size, scaled to 256.
+		for i := 0; i < 100; i++ {
+			pos := int64(len(frame)) + kept[i*len(kept)/100].offset - kept[0].offset
+			frame[p+i] = byte(min(pos*256/int64(total), 255))
+		}
+		p += 100
+	}
+	if flags&xingQuality != 0 {
+		p += 4
+	}
+
+	if x.Encoder == "" {
+		return
+	}
+
+	// Encoder delay and padding are within dropped frames.
+	delay, padding := x.Delay, x.Padding
+	if trimmed.Leading > 0 {
+		delay = 0
+	}
+	if trimmed.Trailing > 0 {
+		padding = 0
+	}
+
+	ext := frame[p:]
+	ext[21] = byte(delay >> 4)
+	ext[22] = byte(delay<<4) | byte(padding>>8&0x0F)
+	ext[23] = byte(padding)
+	binary.BigEndian.PutUint32(ext[28:], total)
+	binary.BigEndian.PutUint16(ext[32:], musicCRC)
+
+	var tagCRC crc16
+	tagCRC.Write(frame[:p+lameTagCRCLen])
+	binary.BigEndian.PutUint16(ext[lameTagCRCLen:], uint16(tagCRC))
+}
+
+// crc16 is CRC-16 used by LAME tag.
+type crc16 uint16
+
+var crc16Table = func() (table [256]uint16) {
+	for i := range table {
+		crc := uint16(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func (c *crc16) Write(p []byte) (int, error) {
+	crc := uint16(*c)
+	for _, b := range p {
+		crc = crc>>8 ^ crc16Table[byte(crc)^b]
+	}
+	*c = crc16(crc)
+	return len(p), nil
+}
@@ -0,0 +1,134 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// Xing is VBR header stored in first frame
+// by Xing, LAME and VBRI compatible encoders.
+type Xing struct {
+	// CBR is set for "Info" header written for constant bitrate.
+	CBR bool
+	// VBRI is set for Fraunhofer VBRI header.
+	VBRI   bool
+	Frames int
+	Bytes  int
+
+	// LAME extension.
+	Encoder string
+	Method  int
+	Delay   int
+	Padding int
+}
+
+// Xing header flags.
+const (
+	xingFrames  = 0x1
+	xingBytes   = 0x2
+	xingTOC     = 0x4
+	xingQuality = 0x8
+)
+
+// LAME VBR methods.
+const (
+	lameCBR       = 1
+	lameABR       = 2
+	lameCBR2Pass  = 8
+	lameABR2Pass  = 9
+	lameExtLength = 36
+)
+
+// ParseXing parses Xing, Info or VBRI header from frame.
+func ParseXing(frame Frame) (Xing, bool) {
+	if x, ok := parseXing(frame); ok {
+		return x, true
+	}
+	return parseVBRI(frame)
+}
+
+func parseXing(frame Frame) (Xing, bool) {
+	if frame.Header.Layer != 3 {
+		return Xing{}, false
+	}
+
+	data := frame.Data
+	offset := HeaderLen + frame.Header.sideInfoLen()
+	if len(data) < offset+8 {
+		return Xing{}, false
+	}
+
+	var x Xing
+	switch string(data[offset : offset+4]) {
+	case "Xing":
+	case "Info":
+		x.CBR = true
+	default:
+		return Xing{}, false
+	}
+
+	flags := binary.BigEndian.Uint32(data[offset+4:])
+	p := offset + 8
+
+	field := func(flag uint32, n int) ([]byte, bool) {
+		if flags&flag == 0 {
+			return nil, true
+		}
+		if len(data) < p+n {
+			return nil, false
+		}
+		p += n
+		return data[p-n : p], true
+	}
+
+	b, ok := field(xingFrames, 4)
+	if !ok {
+		return Xing{}, false
+	}
+	if b != nil {
+		x.Frames = int(binary.BigEndian.Uint32(b))
+	}
+
+	if b, ok = field(xingBytes, 4); !ok {
+		return Xing{}, false
+	}
+	if b != nil {
+		x.Bytes = int(binary.BigEndian.Uint32(b))
+	}
+
+	if _, ok = field(xingTOC, 100); !ok {
+		return Xing{}, false
+	}
+	if _, ok = field(xingQuality, 4); !ok {
+		return Xing{}, false
+	}
+
+	// LAME extension.
+	if len(data) >= p+lameExtLength {
+		ext := data[p:]
+		encoder := strings.TrimRight(string(ext[:9]), "\x00 ")
+		if strings.HasPrefix(encoder, "LAME") || strings.HasPrefix(encoder, "Lavc") || strings.HasPrefix(encoder, "Lavf") {
+			x.Encoder = encoder
+			x.Method = int(ext[9] & 0x0F)
+			x.Delay = int(ext[21])<<4 | int(ext[22])>>4
+			x.Padding = int(ext[22]&0x0F)<<8 | int(ext[23])
+		}
+	}
+
+	return x, true
+}
+
+func parseVBRI(frame Frame) (Xing, bool) {
+	const offset = HeaderLen + 32
+
+	data := frame.Data
+	if len(data) < offset+18 || string(data[offset:offset+4]) != "VBRI" {
+		return Xing{}, false
+	}
+
+	return Xing{
+		VBRI:   true,
+		Bytes:  int(binary.BigEndian.Uint32(data[offset+10:])),
+		Frames: int(binary.BigEndian.Uint32(data[offset+14:])),
+	}, true
+}
@@ -0,0 +1,362 @@
+// Package musical estimates tempo and musical key of audio.
+//
+// Tempo is the strongest periodicity of onset strength,
+// which is spectral flux of the audio, weighted towards
+// usual tempos. Key is the major or minor Krumhansl-Schmuckler
+// profile best correlated with pitch class distribution.
+package musical
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+
+	"radio-storage/internal/lib/fft"
+)
+
+const (
+	// sampleRate is rate audio is downsampled to.
+	sampleRate = 11025
+	frameLen   = 2048
+	// hopLen gives about 43 onset strength values per second.
+	hopLen = 256
+
+	minBPM = 60.0
+	maxBPM = 200.0
+	// preferredBPM is center of log-normal weight of tempos,
+	// bpmOctaves is its deviation, so half and double tempos
+	// are less likely chosen.
+	preferredBPM = 120.0
+	bpmOctaves   = 1.0
+
+	// minChromaFreq and maxChromaFreq bound
+	// frequencies counted in pitch classes.
+	minChromaFreq = 100.0
+	maxChromaFreq = 2000.0
+	// minKeyCorrelation is correlation of pitch classes
+	// with key profile required to report the key.
+	minKeyCorrelation = 0.5
+
+	bufferFrames = 4096
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Krumhansl-Kessler key profiles, tonic first.
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+var pitchClasses = [12]string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+
+// Key is musical key.
+type Key struct {
+	// Tonic is pitch class of the tonic, 0 is C.
+	Tonic int
+	Minor bool
+}
+
+// String returns name of the key like "A minor".
+func (k Key) String() string {
+	if k.Minor {
+		return pitchClasses[k.Tonic] + " minor"
+	}
+	return pitchClasses[k.Tonic] + " major"
+}
+
+// Camelot returns code of the key on Camelot wheel like "8A",
+// keys with adjacent codes mix harmonically.
+func (k Key) Camelot() string {
+	tonic, letter := k.Tonic, "B"
+	if k.Minor {
+		// Code of relative major.
+		tonic, letter = (k.Tonic+3)%12, "A"
+	}
+
+	// Wheel goes by fifths, C major being 8B.
+	n := (7*tonic+7)%12 + 1
+	return strconv.Itoa(n) + letter
+}
+
+// Result is estimated tempo and key of audio.
+type Result struct {
+	// BPM is zero if audio has no beat.
+	BPM float64
+	// Key is valid if HasKey is set.
+	Key    Key
+	HasKey bool
+}
+
+// Analyzer accumulates audio and estimates its tempo and key.
+type Analyzer struct {
+	channels int
+	// ratio is number of input samples per downsampled one.
+	ratio float64
+	rate  float64
+
+	// sum and count accumulate input samples of
+	// current downsampled one, pos is its end.
+	sum   float64
+	count int
+	pos   float64
+	in    float64
+
+	window []float64
+	// pitch is pitch class of FFT bins, -1 if not counted.
+	pitch []int
+
+	samples []float64
+	spec    []complex128
+	mag     []float64
+	prev    []float64
+
+	onsets []float64
+	chroma [12]float64
+}
+
+// NewAnalyzer returns analyzer of audio
+// with given sample rate and channels.
+func NewAnalyzer(rate, channels int) (*Analyzer, error) {
+	if rate < 8000 || channels < 1 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	a := &Analyzer{
+		channels: channels,
+		ratio:    max(1, float64(rate)/sampleRate),
+		window:   make([]float64, frameLen),
+		pitch:    make([]int, frameLen/2),
+		spec:     make([]complex128, frameLen),
+		mag:      make([]float64, frameLen/2),
+	}
+	a.rate = float64(rate) / a.ratio
+	a.pos = a.ratio
+
+	// Hann window.
+	for i := range a.window {
+		a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/frameLen)
+	}
+
+	for k := range a.pitch {
+		freq := float64(k) * a.rate / frameLen
+		if freq < minChromaFreq || freq > maxChromaFreq {
+			a.pitch[k] = -1
+			continue
+		}
+		// MIDI note 60 is middle C.
+		note := int(math.Round(69 + 12*math.Log2(freq/440)))
+		a.pitch[k] = note % 12
+	}
+
+	return a, nil
+}
+
+// Write adds interleaved 16-bit samples.
+func (a *Analyzer) Write(samples []int16) {
+	for i := 0; i+a.channels <= len(samples); i += a.channels {
+		var sum int
+		for _, s := range samples[i : i+a.channels] {
+			sum += int(s)
+		}
+
+		// Box filter suppresses frequencies
+		// aliased by downsampling.
+		a.sum += float64(sum) / float64(a.channels)
+		a.count++
+		a.in++
+		if a.in >= a.pos {
+			a.pos += a.ratio
+			a.add(a.sum / float64(a.count) / math.MaxInt16)
+			a.sum, a.count = 0, 0
+		}
+	}
+}
+
+// ReadFrom reads 16-bit signed little-endian
+// interleaved PCM until EOF.
+func (a *Analyzer) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, bufferFrames*2*a.channels)
+	samples := make([]int16, bufferFrames*a.channels)
+
+	var total int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		total += int64(n)
+
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+		}
+		a.Write(samples[:n])
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return total, nil
+		case err != nil:
+			return total, err
+		}
+	}
+}
+
+// add appends downsampled sample and
+// processes frame once it is complete.
+func (a *Analyzer) add(v float64) {
+	a.samples = append(a.samples, v)
+	if len(a.samples) < frameLen {
+		return
+	}
+
+	for i, s := range a.samples {
+		a.spec[i] = complex(s*a.window[i], 0)
+	}
+	fft.Transform(a.spec)
+
+	for k := range a.mag {
+		re, im := real(a.spec[k]), imag(a.spec[k])
+		a.mag[k] = math.Sqrt(re*re + im*im)
+
+		if p := a.pitch[k]; p >= 0 {
+			a.chroma[p] += a.mag[k] * a.mag[k]
+		}
+	}
+
+	// Spectral flux of log compressed magnitudes.
+	if a.prev != nil {
+		var flux float64
+		for k, m := range a.mag {
+			if d := math.Log1p(m) - math.Log1p(a.prev[k]); d > 0 {
+				flux += d
+			}
+		}
+		a.onsets = append(a.onsets, flux)
+	} else {
+		a.prev = make([]float64, len(a.mag))
+	}
+	copy(a.prev, a.mag)
+
+	a.samples = append(a.samples[:0], a.samples[hopLen:]...)
+}
+
+// Result returns estimates for audio written so far.
+func (a *Analyzer) Result() Result {
+	var res Result
+	res.BPM = a.tempo()
+	res.Key, res.HasKey = a.key()
+	return res
+}
+
+// tempo returns the strongest periodicity of onset strength in BPM.
+func (a *Analyzer) tempo() float64 {
+	fps := a.rate / hopLen
+	minLag := int(math.Floor(fps * 60 / maxBPM))
+	maxLag := int(math.Ceil(fps * 60 / minBPM))
+	if len(a.onsets) < 2*maxLag {
+		return 0
+	}
+
+	// Onset strength without its mean.
+	var mean float64
+	for _, v := range a.onsets {
+		mean += v
+	}
+	mean /= float64(len(a.onsets))
+
+	env := make([]float64, len(a.onsets))
+	var energy float64
+	for i, v := range a.onsets {
+		env[i] = v - mean
+		energy += env[i] * env[i]
+	}
+	if energy < 1e-9 {
+		return 0
+	}
+
+	ac := make([]float64, maxLag+2)
+	for lag := max(1, minLag-1); lag < len(ac); lag++ {
+		var sum float64
+		for i := lag; i < len(env); i++ {
+			sum += env[i] * env[i-lag]
+		}
+		ac[lag] = sum / energy
+	}
+
+	best, bestScore := 0, 0.0
+	for lag := max(2, minLag); lag <= maxLag; lag++ {
+		// Only peaks of autocorrelation are considered.
+		if ac[lag] < ac[lag-1] || ac[lag] < ac[lag+1] {
+			continue
+		}
+		bpm := fps * 60 / float64(lag)
+		weight := math.Exp(-0.5 * math.Pow(math.Log2(bpm/preferredBPM)/bpmOctaves, 2))
+		if score := ac[lag] * weight; score > bestScore {
+			best, bestScore = lag, score
+		}
+	}
+	if best == 0 {
+		return 0
+	}
+
+	// Parabolic interpolation of the peak.
+	lag := float64(best)
+	if d := ac[best-1] - 2*ac[best] + ac[best+1]; d < 0 {
+		lag += 0.5 * (ac[best-1] - ac[best+1]) / d
+	}
+
+	return math.Round(fps*60/lag*10) / 10
+}
+
+// key returns key profile best correlated with pitch classes.
+func (a *Analyzer) key() (Key, bool) {
+	var (
+		best    Key
+		bestCor = math.Inf(-1)
+	)
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, minor := range []bool{false, true} {
+			profile := majorProfile
+			if minor {
+				profile = minorProfile
+			}
+
+			var rotated [12]float64
+			for i := range rotated {
+				rotated[(i+tonic)%12] = profile[i]
+			}
+
+			if cor := correlation(a.chroma[:], rotated[:]); cor > bestCor {
+				best, bestCor = Key{Tonic: tonic, Minor: minor}, cor
+			}
+		}
+	}
+
+	return best, bestCor >= minKeyCorrelation
+}
+
+// correlation returns Pearson correlation of x and y.
+func correlation(x, y []float64) float64 {
+	n := float64(len(x))
+
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= n
+	my /= n
+
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+
+	return sxy / math.Sqrt(sxx*syy)
+}
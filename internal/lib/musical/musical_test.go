@@ -0,0 +1,104 @@
+package musical
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSampleRate = 44100
+
+// song returns stereo audio of chords with given
+// root notes played on every beat at given tempo.
+func song(bpm float64, chords [][]int, seconds int) []int16 {
+	rnd := rand.New(rand.NewSource(1))
+
+	beat := int(testSampleRate * 60 / bpm)
+	samples := make([]int16, 0, 2*seconds*testSampleRate)
+	for i := 0; i < seconds*testSampleRate; i++ {
+		n := i / beat
+		chord := chords[n/4%len(chords)]
+
+		// Note decays after the beat.
+		t := float64(i%beat) / testSampleRate
+		env := math.Exp(-8 * t)
+
+		var v float64
+		for _, note := range chord {
+			freq := 440 * math.Pow(2, float64(note-69)/12)
+			for h := 1; h <= 3; h++ {
+				v += math.Sin(2*math.Pi*freq*float64(h)*float64(i)/testSampleRate) / float64(h)
+			}
+		}
+		// Percussive noise on the beat.
+		v = v*env/3 + rnd.NormFloat64()*math.Exp(-60*t)/2
+
+		s := int16(math.Max(-1, math.Min(1, v/2)) * 20000)
+		samples = append(samples, s, s)
+	}
+
+	return samples
+}
+
+func analyze(t *testing.T, samples []int16) Result {
+	t.Helper()
+
+	a, err := NewAnalyzer(testSampleRate, 2)
+	require.NoError(t, err)
+	a.Write(samples)
+	return a.Result()
+}
+
+func TestTempo(t *testing.T) {
+	cMajor := [][]int{{60, 64, 67}}
+
+	for _, bpm := range []float64{90, 128, 174} {
+		res := analyze(t, song(bpm, cMajor, 20))
+		require.InDelta(t, bpm, res.BPM, 1.5, bpm)
+	}
+}
+
+func TestKey(t *testing.T) {
+	// I-IV-V-I in C major.
+	res := analyze(t, song(120, [][]int{{60, 64, 67}, {65, 69, 72}, {67, 71, 74}, {60, 64, 67}}, 20))
+	require.True(t, res.HasKey)
+	require.Equal(t, Key{Tonic: 0}, res.Key)
+
+	// i-iv-V-i in A minor.
+	res = analyze(t, song(120, [][]int{{57, 60, 64}, {62, 65, 69}, {64, 68, 71}, {57, 60, 64}}, 20))
+	require.True(t, res.HasKey)
+	require.Equal(t, Key{Tonic: 9, Minor: true}, res.Key)
+}
+
+func TestSilence(t *testing.T) {
+	a, err := NewAnalyzer(testSampleRate, 2)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, make([]int16, 2*10*testSampleRate)))
+	_, err = a.ReadFrom(&buf)
+	require.NoError(t, err)
+
+	res := a.Result()
+	require.Zero(t, res.BPM)
+	require.False(t, res.HasKey)
+}
+
+func TestKeyNames(t *testing.T) {
+	for key, want := range map[Key][2]string{
+		{Tonic: 0}:               {"C major", "8B"},
+		{Tonic: 7}:               {"G major", "9B"},
+		{Tonic: 5}:               {"F major", "7B"},
+		{Tonic: 6}:               {"F# major", "2B"},
+		{Tonic: 9, Minor: true}:  {"A minor", "8A"},
+		{Tonic: 4, Minor: true}:  {"E minor", "9A"},
+		{Tonic: 10, Minor: true}: {"Bb minor", "3A"},
+	} {
+		require.Equal(t, want[0], key.String())
+		require.Equal(t, want[1], key.Camelot(), want[0])
+	}
+}
@@ -0,0 +1,279 @@
+package pcm
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	// chunkFrames is number of frames read from source at once.
+	chunkFrames = 4096
+
+	// zeroCrossings is number of zero crossings
+	// of resampling kernel on each side.
+	zeroCrossings = 16
+	// rolloff is cutoff frequency relative to Nyquist frequency
+	// of the lower sample rate, leaving room for transition band.
+	rolloff = 0.95
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported pcm format")
+
+// OutputFrames returns number of frames Converter
+// produces from given number of source frames.
+func OutputFrames(frames int64, from, to Format) int64 {
+	return frames * int64(to.SampleRate) / int64(from.SampleRate)
+}
+
+// Converter reads PCM from source, mixes channels
+// and resamples it to target format.
+//
+// Mono is mixed to stereo by duplicating channel,
+// stereo to mono by averaging channels.
+// Resampling uses windowed sinc interpolation.
+type Converter struct {
+	src  io.Reader
+	from Format
+	to   Format
+
+	// kernel is nil when sample rates are equal.
+	kernel *kernel
+
+	raw []byte
+	eof bool
+
+	// in holds source frames in target channel layout,
+	// starting with frame base.
+	in   []float32
+	base int64
+	read int64
+
+	// pos is the next output frame.
+	pos int64
+
+	buf []byte
+	out []byte
+}
+
+// NewConverter returns converter of PCM read from src.
+func NewConverter(src io.Reader, from, to Format) (*Converter, error) {
+	for _, f := range []Format{from, to} {
+		if f.SampleRate <= 0 || f.Channels < 1 || f.Channels > 2 {
+			return nil, ErrUnsupportedFormat
+		}
+	}
+
+	c := &Converter{
+		src:  src,
+		from: from,
+		to:   to,
+		raw:  make([]byte, chunkFrames*from.FrameSize()),
+	}
+	if from.SampleRate != to.SampleRate {
+		c.kernel = newKernel(from.SampleRate, to.SampleRate)
+	}
+
+	return c, nil
+}
+
+// Read reads converted PCM.
+func (c *Converter) Read(p []byte) (int, error) {
+	for len(c.out) == 0 {
+		if err := c.convert(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, c.out)
+	c.out = c.out[n:]
+
+	return n, nil
+}
+
+// convert reads next chunk of source and converts
+// all frames that can be computed. Returns io.EOF
+// when nothing left.
+func (c *Converter) convert() error {
+	if !c.eof {
+		if err := c.fill(); err != nil {
+			return err
+		}
+	}
+
+	c.out = c.buf[:0]
+	if c.kernel == nil {
+		for _, v := range c.in {
+			c.out = binary.LittleEndian.AppendUint16(c.out, uint16(clip(v)))
+		}
+		c.base += int64(len(c.in) / c.to.Channels)
+		c.in = c.in[:0]
+	} else {
+		c.resample()
+	}
+	c.buf = c.out[:0]
+
+	if len(c.out) == 0 && c.eof {
+		return io.EOF
+	}
+
+	return nil
+}
+
+// fill reads chunk of source frames.
+func (c *Converter) fill() error {
+	n, err := io.ReadFull(c.src, c.raw)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		c.eof = true
+	case err != nil:
+		return err
+	}
+
+	// Incomplete trailing frame is dropped.
+	frames := n / c.from.FrameSize()
+	for i := 0; i < frames; i++ {
+		frame := c.raw[i*c.from.FrameSize():]
+		l := float32(int16(binary.LittleEndian.Uint16(frame)))
+		r := l
+		if c.from.Channels == 2 {
+			r = float32(int16(binary.LittleEndian.Uint16(frame[2:])))
+		}
+
+		if c.to.Channels == 1 {
+			c.in = append(c.in, (l+r)/2)
+		} else {
+			c.in = append(c.in, l, r)
+		}
+	}
+	c.read += int64(frames)
+
+	return nil
+}
+
+// resample computes output frames whose
+// kernel window is covered by read frames.
+func (c *Converter) resample() {
+	k := c.kernel
+	channels := c.to.Channels
+	width := int64(k.width)
+
+	total := int64(math.MaxInt64)
+	if c.eof {
+		total = c.read * k.phases / k.step
+	}
+
+	for ; c.pos < total; c.pos++ {
+		center := c.pos * k.step / k.phases
+		if !c.eof && center+width >= c.read {
+			break
+		}
+
+		taps := k.taps[c.pos*k.step%k.phases]
+		first := center - width + 1
+
+		for ch := 0; ch < channels; ch++ {
+			var sum float32
+			for i, tap := range taps {
+				idx := first + int64(i)
+				if idx < c.base || idx >= c.read {
+					continue
+				}
+				sum += tap * c.in[int(idx-c.base)*channels+ch]
+			}
+			c.out = binary.LittleEndian.AppendUint16(c.out, uint16(clip(sum)))
+		}
+	}
+
+	// Drop frames not needed for further output.
+	first := c.pos*k.step/k.phases - width + 1
+	if drop := first - c.base; drop > 0 {
+		if drop > int64(len(c.in)/channels) {
+			drop = int64(len(c.in) / channels)
+		}
+		c.in = c.in[:copy(c.in, c.in[int(drop)*channels:])]
+		c.base += drop
+	}
+}
+
+// kernel is polyphase resampling filter.
+//
+// Output frame n lies at source position n*step/phases,
+// its phase is n*step%phases.
+type kernel struct {
+	step   int64
+	phases int64
+	width  int
+	taps   [][]float32
+}
+
+func newKernel(from, to int) *kernel {
+	g := gcd(from, to)
+	k := &kernel{
+		step:   int64(from / g),
+		phases: int64(to / g),
+	}
+
+	// Cut off above Nyquist frequency of the lower rate.
+	cutoff := rolloff
+	if to < from {
+		cutoff *= float64(to) / float64(from)
+	}
+	k.width = int(math.Ceil(zeroCrossings / cutoff))
+
+	k.taps = make([][]float32, k.phases)
+	for p := range k.taps {
+		frac := float64(p) / float64(k.phases)
+		taps := make([]float64, 2*k.width)
+
+		var sum float64
+		for i := range taps {
+			x := frac + float64(k.width-1-i)
+			taps[i] = cutoff * sinc(cutoff*x) * blackman(x/float64(k.width))
+			sum += taps[i]
+		}
+
+		// Normalize to unity gain.
+		k.taps[p] = make([]float32, len(taps))
+		for i := range taps {
+			k.taps[p][i] = float32(taps[i] / sum)
+		}
+	}
+
+	return k
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is Blackman window on [-1, 1].
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func clip(v float32) int16 {
+	v = float32(math.Round(float64(v)))
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	default:
+		return int16(v)
+	}
+}
@@ -0,0 +1,59 @@
+// Package pcm converts 16-bit PCM audio
+// and wraps it into WAV container.
+package pcm
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+)
+
+// WAVHeaderLen is length of canonical WAV header.
+const WAVHeaderLen = 44
+
+var ErrTooLarge = errors.New("audio is too large for wav")
+
+// Format describes 16-bit signed little-endian interleaved PCM.
+type Format struct {
+	SampleRate int
+	Channels   int
+}
+
+// FrameSize returns size of single frame in bytes.
+func (f Format) FrameSize() int {
+	return 2 * f.Channels
+}
+
+// ContentType returns MIME type of raw PCM in format f.
+func (f Format) ContentType() string {
+	return "audio/pcm;rate=" + strconv.Itoa(f.SampleRate) + ";channels=" + strconv.Itoa(f.Channels) + ";format=s16le"
+}
+
+// WAVHeader returns header of WAV file
+// holding given number of frames.
+func WAVHeader(f Format, frames int64) ([]byte, error) {
+	size := frames * int64(f.FrameSize())
+	if size > math.MaxUint32-WAVHeaderLen {
+		return nil, ErrTooLarge
+	}
+
+	h := make([]byte, WAVHeaderLen)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], uint32(size+WAVHeaderLen-8))
+	copy(h[8:], "WAVE")
+
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], uint16(f.Channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(f.SampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(f.SampleRate*f.FrameSize()))
+	binary.LittleEndian.PutUint16(h[32:], uint16(f.FrameSize()))
+	binary.LittleEndian.PutUint16(h[34:], 16)
+
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], uint32(size))
+
+	return h, nil
+}
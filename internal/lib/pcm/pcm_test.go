@@ -0,0 +1,130 @@
+package pcm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encode(channels [][]int16) []byte {
+	var b []byte
+	for i := range channels[0] {
+		for _, ch := range channels {
+			b = binary.LittleEndian.AppendUint16(b, uint16(ch[i]))
+		}
+	}
+	return b
+}
+
+func decode(b []byte, channels int) [][]int16 {
+	res := make([][]int16, channels)
+	for i := 0; i+2*channels <= len(b); i += 2 * channels {
+		for ch := range res {
+			res[ch] = append(res[ch], int16(binary.LittleEndian.Uint16(b[i+2*ch:])))
+		}
+	}
+	return res
+}
+
+func convert(t *testing.T, data []byte, from, to Format) []byte {
+	t.Helper()
+
+	c, err := NewConverter(bytes.NewReader(data), from, to)
+	require.NoError(t, err)
+
+	// Small reads exercise buffering.
+	var res []byte
+	buf := make([]byte, 1001)
+	for {
+		n, err := c.Read(buf)
+		res = append(res, buf[:n]...)
+		if err == io.EOF {
+			return res
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestWAVHeader(t *testing.T) {
+	h, err := WAVHeader(Format{SampleRate: 48000, Channels: 2}, 100)
+	require.NoError(t, err)
+	require.Len(t, h, WAVHeaderLen)
+
+	require.Equal(t, "RIFF", string(h[0:4]))
+	require.Equal(t, uint32(436), binary.LittleEndian.Uint32(h[4:]))
+	require.Equal(t, "WAVEfmt ", string(h[8:16]))
+	require.Equal(t, uint16(2), binary.LittleEndian.Uint16(h[22:]))
+	require.Equal(t, uint32(48000), binary.LittleEndian.Uint32(h[24:]))
+	require.Equal(t, uint32(192000), binary.LittleEndian.Uint32(h[28:]))
+	require.Equal(t, uint16(4), binary.LittleEndian.Uint16(h[32:]))
+	require.Equal(t, uint16(16), binary.LittleEndian.Uint16(h[34:]))
+	require.Equal(t, "data", string(h[36:40]))
+	require.Equal(t, uint32(400), binary.LittleEndian.Uint32(h[40:]))
+
+	_, err = WAVHeader(Format{SampleRate: 48000, Channels: 2}, math.MaxUint32/4)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestMix(t *testing.T) {
+	l := []int16{1000, -2000, 32767, 0}
+	r := []int16{3000, -4000, 32767, 1}
+	stereo := encode([][]int16{l, r})
+
+	mono := decode(convert(t, stereo, Format{44100, 2}, Format{44100, 1}), 1)
+	require.Equal(t, [][]int16{{2000, -3000, 32767, 1}}, mono)
+
+	res := decode(convert(t, encode([][]int16{l}), Format{44100, 1}, Format{44100, 2}), 2)
+	require.Equal(t, [][]int16{l, l}, res)
+
+	require.Equal(t, stereo, convert(t, stereo, Format{44100, 2}, Format{44100, 2}))
+}
+
+func TestResample(t *testing.T) {
+	const (
+		freq = 1000.0
+		amp  = 10000.0
+	)
+
+	for _, tt := range []struct {
+		from, to int
+	}{
+		{44100, 48000},
+		{48000, 44100},
+		{22050, 44100},
+		{32000, 48000},
+	} {
+		n := tt.from / 2
+		l := make([]int16, n)
+		r := make([]int16, n)
+		for i := range l {
+			l[i] = int16(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(tt.from)))
+			r[i] = -l[i]
+		}
+
+		res := decode(convert(t, encode([][]int16{l, r}), Format{tt.from, 2}, Format{tt.to, 2}), 2)
+
+		frames := OutputFrames(int64(n), Format{tt.from, 2}, Format{tt.to, 2})
+		require.Len(t, res[0], int(frames), "%d -> %d", tt.from, tt.to)
+
+		// Away from the edges output matches ideal sine.
+		var maxErr float64
+		for i := 100; i < len(res[0])-100; i++ {
+			want := amp * math.Sin(2*math.Pi*freq*float64(i)/float64(tt.to))
+			maxErr = math.Max(maxErr, math.Abs(float64(res[0][i])-want))
+			maxErr = math.Max(maxErr, math.Abs(float64(res[1][i])+want))
+		}
+		require.Less(t, maxErr, amp*0.01, "%d -> %d", tt.from, tt.to)
+	}
+}
+
+func TestNewConverter(t *testing.T) {
+	_, err := NewConverter(bytes.NewReader(nil), Format{44100, 3}, Format{44100, 2})
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = NewConverter(bytes.NewReader(nil), Format{44100, 2}, Format{0, 2})
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,311 @@
+// Package waveform computes min/max peaks of audio
+// in formats compatible with audiowaveform.
+package waveform
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	// binaryVersion is version of binary format written,
+	// version 1 holds single channel.
+	binaryVersion   = 1
+	binaryHeaderLen = 20
+	flag8Bit        = 0x1
+
+	jsonVersion = 2
+
+	bufferFrames = 4096
+)
+
+var (
+	ErrInvalidResolution = errors.New("invalid waveform resolution")
+	ErrInvalidBits       = errors.New("waveform bits must be 8 or 16")
+	ErrInvalidData       = errors.New("invalid waveform data")
+)
+
+// Waveform holds min and max sample
+// of every pixel of mono audio.
+type Waveform struct {
+	SampleRate      int
+	SamplesPerPixel int
+	// Bits is 8 or 16.
+	Bits int
+	// Data holds min and max of every pixel in turn.
+	Data []int16
+}
+
+// Len returns number of pixels.
+func (w *Waveform) Len() int {
+	return len(w.Data) / 2
+}
+
+// Builder computes waveform of PCM audio.
+// Channels are mixed to mono by averaging.
+type Builder struct {
+	channels int
+	w        *Waveform
+
+	min, max int16
+	count    int
+}
+
+// NewBuilder returns builder of 16-bit waveform.
+func NewBuilder(sampleRate, channels, samplesPerPixel int) (*Builder, error) {
+	if samplesPerPixel <= 0 {
+		return nil, ErrInvalidResolution
+	}
+
+	return &Builder{
+		channels: channels,
+		w: &Waveform{
+			SampleRate:      sampleRate,
+			SamplesPerPixel: samplesPerPixel,
+			Bits:            16,
+		},
+		min: math.MaxInt16,
+		max: math.MinInt16,
+	}, nil
+}
+
+// Write adds interleaved 16-bit samples.
+func (b *Builder) Write(samples []int16) {
+	for i := 0; i+b.channels <= len(samples); i += b.channels {
+		var sum int
+		for _, s := range samples[i : i+b.channels] {
+			sum += int(s)
+		}
+		v := int16(sum / b.channels)
+
+		b.min = min(b.min, v)
+		b.max = max(b.max, v)
+		b.count++
+
+		if b.count == b.w.SamplesPerPixel {
+			b.flush()
+		}
+	}
+}
+
+// ReadFrom reads 16-bit signed little-endian
+// interleaved PCM until EOF.
+func (b *Builder) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, bufferFrames*2*b.channels)
+	samples := make([]int16, bufferFrames*b.channels)
+
+	var total int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		total += int64(n)
+
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+		}
+		b.Write(samples[:n])
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return total, nil
+		case err != nil:
+			return total, err
+		}
+	}
+}
+
+// Waveform returns waveform of audio written so far,
+// including incomplete last pixel.
+func (b *Builder) Waveform() *Waveform {
+	if b.count > 0 {
+		b.flush()
+	}
+	return b.w
+}
+
+func (b *Builder) flush() {
+	b.w.Data = append(b.w.Data, b.min, b.max)
+	b.min, b.max, b.count = math.MaxInt16, math.MinInt16, 0
+}
+
+// Resample returns waveform with samples per pixel
+// being a multiple of the one of w.
+func (w *Waveform) Resample(samplesPerPixel int) (*Waveform, error) {
+	if samplesPerPixel <= 0 || samplesPerPixel%w.SamplesPerPixel != 0 {
+		return nil, ErrInvalidResolution
+	}
+
+	factor := samplesPerPixel / w.SamplesPerPixel
+	res := &Waveform{
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Bits:            w.Bits,
+		Data:            make([]int16, 0, 2*((w.Len()+factor-1)/factor)),
+	}
+
+	for i := 0; i < w.Len(); i += factor {
+		lo, hi := w.Data[2*i], w.Data[2*i+1]
+		for j := i + 1; j < min(i+factor, w.Len()); j++ {
+			lo = min(lo, w.Data[2*j])
+			hi = max(hi, w.Data[2*j+1])
+		}
+		res.Data = append(res.Data, lo, hi)
+	}
+
+	return res, nil
+}
+
+// WithBits returns waveform with given resolution of values.
+// Only 16-bit waveform may be converted to 8-bit one.
+func (w *Waveform) WithBits(bits int) (*Waveform, error) {
+	switch {
+	case bits == w.Bits:
+		return w, nil
+	case bits == 8 && w.Bits == 16:
+	default:
+		return nil, ErrInvalidBits
+	}
+
+	res := &Waveform{
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: w.SamplesPerPixel,
+		Bits:            bits,
+		Data:            make([]int16, len(w.Data)),
+	}
+	for i, v := range w.Data {
+		res.Data[i] = v >> 8
+	}
+
+	return res, nil
+}
+
+// MarshalBinary encodes waveform in audiowaveform binary format.
+func (w *Waveform) MarshalBinary() ([]byte, error) {
+	var flags uint32
+	valueLen := 2
+	switch w.Bits {
+	case 8:
+		flags, valueLen = flag8Bit, 1
+	case 16:
+	default:
+		return nil, ErrInvalidBits
+	}
+
+	b := make([]byte, binaryHeaderLen, binaryHeaderLen+len(w.Data)*valueLen)
+	binary.LittleEndian.PutUint32(b[0:], binaryVersion)
+	binary.LittleEndian.PutUint32(b[4:], flags)
+	binary.LittleEndian.PutUint32(b[8:], uint32(w.SampleRate))
+	binary.LittleEndian.PutUint32(b[12:], uint32(w.SamplesPerPixel))
+	binary.LittleEndian.PutUint32(b[16:], uint32(w.Len()))
+
+	for _, v := range w.Data {
+		if valueLen == 1 {
+			b = append(b, byte(int8(v)))
+		} else {
+			b = binary.LittleEndian.AppendUint16(b, uint16(v))
+		}
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes waveform in audiowaveform binary format.
+// Multichannel data of version 2 is not supported.
+func (w *Waveform) UnmarshalBinary(b []byte) error {
+	if len(b) < binaryHeaderLen || binary.LittleEndian.Uint32(b) != binaryVersion {
+		return ErrInvalidData
+	}
+
+	res := Waveform{
+		Bits:            16,
+		SampleRate:      int(binary.LittleEndian.Uint32(b[8:])),
+		SamplesPerPixel: int(binary.LittleEndian.Uint32(b[12:])),
+	}
+	valueLen := 2
+	if binary.LittleEndian.Uint32(b[4:])&flag8Bit != 0 {
+		res.Bits, valueLen = 8, 1
+	}
+
+	n := int(binary.LittleEndian.Uint32(b[16:]))
+	data := b[binaryHeaderLen:]
+	if len(data) != 2*n*valueLen {
+		return ErrInvalidData
+	}
+
+	res.Data = make([]int16, 2*n)
+	for i := range res.Data {
+		if valueLen == 1 {
+			res.Data[i] = int16(int8(data[i]))
+		} else {
+			res.Data[i] = int16(binary.LittleEndian.Uint16(data[2*i:]))
+		}
+	}
+
+	*w = res
+
+	return nil
+}
+
+// jsonWaveform is audiowaveform JSON format.
+type jsonWaveform struct {
+	Version         int     `json:"version"`
+	Channels        int     `json:"channels"`
+	SampleRate      int     `json:"sample_rate"`
+	SamplesPerPixel int     `json:"samples_per_pixel"`
+	Bits            int     `json:"bits"`
+	Length          int     `json:"length"`
+	Data            []int16 `json:"data"`
+}
+
+// MarshalJSON encodes waveform in audiowaveform JSON format.
+func (w *Waveform) MarshalJSON() ([]byte, error) {
+	data := w.Data
+	if data == nil {
+		data = []int16{}
+	}
+
+	return json.Marshal(jsonWaveform{
+		Version:         jsonVersion,
+		Channels:        1,
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: w.SamplesPerPixel,
+		Bits:            w.Bits,
+		Length:          w.Len(),
+		Data:            data,
+	})
+}
+
+// Format is encoding of waveform.
+type Format int
+
+const (
+	JSON Format = iota
+	Binary
+)
+
+// Encode encodes waveform with given bits in format f
+// and returns its content type. Zero bits keep ones of w.
+func (w *Waveform) Encode(f Format, bits int) ([]byte, string, error) {
+	if bits == 0 {
+		bits = w.Bits
+	}
+
+	res, err := w.WithBits(bits)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch f {
+	case JSON:
+		data, err := res.MarshalJSON()
+		return data, "application/json", err
+	case Binary:
+		data, err := res.MarshalBinary()
+		return data, "application/octet-stream", err
+	default:
+		return nil, "", errors.New("unknown waveform format")
+	}
+}
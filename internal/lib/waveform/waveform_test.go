@@ -0,0 +1,131 @@
+package waveform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	b, err := NewBuilder(44100, 2, 2)
+	require.NoError(t, err)
+
+	// Channels are averaged, last pixel is incomplete.
+	b.Write([]int16{
+		100, 300, -100, -300,
+		1000, 1000, 0, 0,
+		-32768, -32768,
+	})
+
+	w := b.Waveform()
+	require.Equal(t, 44100, w.SampleRate)
+	require.Equal(t, 2, w.SamplesPerPixel)
+	require.Equal(t, 16, w.Bits)
+	require.Equal(t, []int16{-200, 200, 0, 1000, -32768, -32768}, w.Data)
+	require.Equal(t, 3, w.Len())
+
+	_, err = NewBuilder(44100, 2, 0)
+	require.ErrorIs(t, err, ErrInvalidResolution)
+}
+
+func TestReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	for _, v := range []int16{1, -5, 7, 3, 2} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+
+	b, err := NewBuilder(8000, 1, 4)
+	require.NoError(t, err)
+	n, err := b.ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), n)
+	require.Equal(t, []int16{-5, 7, 2, 2}, b.Waveform().Data)
+}
+
+func TestResample(t *testing.T) {
+	w := &Waveform{
+		SampleRate:      44100,
+		SamplesPerPixel: 256,
+		Bits:            16,
+		Data:            []int16{-1, 1, -5, 2, 0, 9, -3, 3, -2, 2},
+	}
+
+	res, err := w.Resample(512)
+	require.NoError(t, err)
+	require.Equal(t, 512, res.SamplesPerPixel)
+	require.Equal(t, []int16{-5, 2, -3, 9, -2, 2}, res.Data)
+
+	res, err = w.Resample(256)
+	require.NoError(t, err)
+	require.Equal(t, w.Data, res.Data)
+
+	_, err = w.Resample(300)
+	require.ErrorIs(t, err, ErrInvalidResolution)
+}
+
+func TestBinary(t *testing.T) {
+	w := &Waveform{
+		SampleRate:      48000,
+		SamplesPerPixel: 512,
+		Bits:            16,
+		Data:            []int16{-32768, 32767, -256, 511},
+	}
+
+	data, err := w.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, data, 20+8)
+	require.Equal(t, []uint32{1, 0, 48000, 512, 2}, header(data))
+
+	var decoded Waveform
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, *w, decoded)
+
+	w8, err := w.WithBits(8)
+	require.NoError(t, err)
+	require.Equal(t, []int16{-128, 127, -1, 1}, w8.Data)
+
+	data, err = w8.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, data, 20+4)
+	require.Equal(t, []uint32{1, 1, 48000, 512, 2}, header(data))
+
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, *w8, decoded)
+
+	_, err = w8.WithBits(16)
+	require.ErrorIs(t, err, ErrInvalidBits)
+
+	require.ErrorIs(t, decoded.UnmarshalBinary(data[:22]), ErrInvalidData)
+}
+
+func TestJSON(t *testing.T) {
+	w := &Waveform{
+		SampleRate:      44100,
+		SamplesPerPixel: 256,
+		Bits:            8,
+		Data:            []int16{-3, 4},
+	}
+
+	data, err := json.Marshal(w)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"version": 2,
+		"channels": 1,
+		"sample_rate": 44100,
+		"samples_per_pixel": 256,
+		"bits": 8,
+		"length": 1,
+		"data": [-3, 4]
+	}`, string(data))
+}
+
+func header(data []byte) []uint32 {
+	res := make([]uint32, 5)
+	for i := range res {
+		res[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return res
+}
@@ -0,0 +1,223 @@
+// Package analyzer runs analysis of stored files
+// after upload and over the existing archive.
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+// Stage is single kind of file analysis.
+type Stage interface {
+	// Name identifies stage in logs, metrics and backfill options.
+	Name() string
+	// Analyzed reports whether up to date result is stored.
+	Analyzed(ctx context.Context, id int) (bool, error)
+	// Analyze analyzes file and stores result.
+	Analyze(ctx context.Context, id int) error
+}
+
+type FileStorage interface {
+	IDs(ctx context.Context, fn func(id int) error) error
+}
+
+// Analyzer runs stages for enqueued files in background.
+type Analyzer struct {
+	log    *slog.Logger
+	stages []Stage
+	queue  chan int
+}
+
+func New(
+	log *slog.Logger,
+	queueLen int,
+) *Analyzer {
+	return &Analyzer{
+		log:   log,
+		queue: make(chan int, queueLen),
+	}
+}
+
+// Register adds stages run for every file.
+// Must be called before Run.
+func (a *Analyzer) Register(stages ...Stage) {
+	a.stages = append(a.stages, stages...)
+}
+
+// Enqueue schedules file for analysis.
+//
+// File is dropped if queue is full,
+// backfill picks it up later.
+func (a *Analyzer) Enqueue(id int) {
+	select {
+	case a.queue <- id:
+		queueLength.Set(float64(len(a.queue)))
+	default:
+		a.log.Warn("analysis queue is full, file dropped", slog.String("op", "Analyzer.Enqueue"), slog.Int("id", id))
+	}
+}
+
+// Run analyzes enqueued files until ctx is done.
+func (a *Analyzer) Run(ctx context.Context) {
+	const op = "Analyzer.Run"
+
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("analyzer started", slog.Int("stages", len(a.stages)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("analyzer stopped")
+			return
+		case id := <-a.queue:
+			queueLength.Set(float64(len(a.queue)))
+			a.analyze(ctx, id, a.stages, false)
+		}
+	}
+}
+
+// Backfill runs selected stages over stored files.
+// Failures of single files are counted, not returned.
+func (a *Analyzer) Backfill(ctx context.Context, files FileStorage, opts models.BackfillOptions) (models.BackfillStats, error) {
+	const op = "Analyzer.Backfill"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Bool("force", opts.Force),
+	)
+
+	var stats models.BackfillStats
+
+	stages := a.stages
+	if len(opts.Stages) != 0 {
+		stages = make([]Stage, 0, len(opts.Stages))
+		for _, name := range opts.Stages {
+			i := slices.IndexFunc(a.stages, func(s Stage) bool { return s.Name() == name })
+			if i < 0 {
+				log.Warn("unknown stage", slog.String("stage", name))
+				return stats, fmt.Errorf("%w: %s", service.ErrUnknownStage, name)
+			}
+			stages = append(stages, a.stages[i])
+		}
+	}
+
+	if err := files.IDs(ctx, func(id int) error {
+		if id < opts.FromID || (opts.ToID > 0 && id > opts.ToID) {
+			return nil
+		}
+
+		res := a.analyze(ctx, id, stages, opts.Force)
+		stats.Analyzed += res.Analyzed
+		stats.Skipped += res.Skipped
+		stats.Failed += res.Failed
+
+		return ctx.Err()
+	}); err != nil {
+		log.Error("backfill interrupted", sl.Err(err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("backfill finished",
+		slog.Int("analyzed", stats.Analyzed),
+		slog.Int("skipped", stats.Skipped),
+		slog.Int("failed", stats.Failed),
+	)
+
+	return stats, nil
+}
+
+// analyze runs stages for the file. Stages with up to date
+// results are skipped, unless force is set.
+func (a *Analyzer) analyze(ctx context.Context, id int, stages []Stage, force bool) models.BackfillStats {
+	const op = "Analyzer.analyze"
+
+	var (
+		stats   models.BackfillStats
+		batches []*audioBatch
+		batched = make(map[*audioBatch][]Stage)
+	)
+
+	for _, stage := range stages {
+		log := a.log.With(
+			slog.String("op", op),
+			slog.String("stage", stage.Name()),
+			slog.Int("id", id),
+		)
+
+		if !force {
+			done, err := stage.Analyzed(ctx, id)
+			if err != nil && !errors.Is(err, service.ErrFileNotExist) {
+				log.Error("failed to check analysis result", sl.Err(err))
+				stats.Failed++
+				analyzedFiles.WithLabelValues(stage.Name(), resultFailed).Inc()
+				continue
+			}
+			if done || err != nil {
+				stats.Skipped++
+				analyzedFiles.WithLabelValues(stage.Name(), resultSkipped).Inc()
+				continue
+			}
+		}
+
+		if s, ok := stage.(audioStage); ok {
+			if _, ok := batched[s.batch]; !ok {
+				batches = append(batches, s.batch)
+			}
+			batched[s.batch] = append(batched[s.batch], s)
+			continue
+		}
+
+		a.record(&stats, id, stage, stage.Analyze(ctx, id))
+	}
+
+	for _, batch := range batches {
+		names := make([]string, 0, len(batched[batch]))
+		for _, stage := range batched[batch] {
+			names = append(names, stage.Name())
+		}
+
+		err := batch.storage.AnalyzeAudio(ctx, id, names...)
+		for _, stage := range batched[batch] {
+			a.record(&stats, id, stage, err)
+		}
+	}
+
+	return stats
+}
+
+// record counts result of the stage run for the file.
+func (a *Analyzer) record(stats *models.BackfillStats, id int, stage Stage, err error) {
+	const op = "Analyzer.analyze"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("stage", stage.Name()),
+		slog.Int("id", id),
+	)
+
+	switch {
+	case err == nil:
+		log.Debug("file analyzed")
+		stats.Analyzed++
+		analyzedFiles.WithLabelValues(stage.Name(), resultAnalyzed).Inc()
+	case errors.Is(err, service.ErrFileNotExist), errors.Is(err, service.ErrConversionUnsupported):
+		// File is gone or stage does not apply.
+		log.Debug("file skipped", sl.Err(err))
+		stats.Skipped++
+		analyzedFiles.WithLabelValues(stage.Name(), resultSkipped).Inc()
+	default:
+		log.Warn("failed to analyze file", sl.Err(err))
+		stats.Failed++
+		analyzedFiles.WithLabelValues(stage.Name(), resultFailed).Inc()
+	}
+}
@@ -0,0 +1,174 @@
+package analyzer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+	"radio-storage/internal/service/analyzer"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type files []int
+
+func (f files) IDs(ctx context.Context, fn func(id int) error) error {
+	for _, id := range f {
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stage stores analyzed ids and fails for configured ones.
+type stage struct {
+	name string
+	errs map[int]error
+
+	mu   sync.Mutex
+	done map[int]int
+}
+
+func newStage(name string, errs map[int]error) *stage {
+	return &stage{name: name, errs: errs, done: make(map[int]int)}
+}
+
+func (s *stage) Name() string { return s.name }
+
+func (s *stage) Analyzed(_ context.Context, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done[id] > 0, nil
+}
+
+func (s *stage) Analyze(_ context.Context, id int) error {
+	if err := s.errs[id]; err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[id]++
+	return nil
+}
+
+func (s *stage) count(id int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done[id]
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	first := newStage("first", map[int]error{
+		3: service.ErrConversionUnsupported,
+		4: errors.New("broken"),
+	})
+	second := newStage("second", nil)
+
+	a := analyzer.New(discardLog, 0)
+	a.Register(first, second)
+
+	stats, err := a.Backfill(ctx, files{1, 2, 3, 4}, models.BackfillOptions{})
+	require.NoError(t, err)
+	require.Equal(t, models.BackfillStats{Analyzed: 6, Skipped: 1, Failed: 1}, stats)
+
+	// Up to date results are skipped.
+	stats, err = a.Backfill(ctx, files{1, 2, 3, 4}, models.BackfillOptions{Stages: []string{"second"}})
+	require.NoError(t, err)
+	require.Equal(t, models.BackfillStats{Skipped: 4}, stats)
+
+	// Unless forced.
+	stats, err = a.Backfill(ctx, files{1, 2, 3, 4}, models.BackfillOptions{Stages: []string{"second"}, Force: true, FromID: 2, ToID: 3})
+	require.NoError(t, err)
+	require.Equal(t, models.BackfillStats{Analyzed: 2}, stats)
+	require.Equal(t, 1, second.count(1))
+	require.Equal(t, 2, second.count(2))
+
+	_, err = a.Backfill(ctx, files{1}, models.BackfillOptions{Stages: []string{"unknown"}})
+	require.ErrorIs(t, err, service.ErrUnknownStage)
+}
+
+// audio records batches of analyzed audio stages
+// and marks them done in stages.
+type audio struct {
+	stages map[string]*stage
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (a *audio) AnalyzeAudio(ctx context.Context, id int, stages ...string) error {
+	a.mu.Lock()
+	a.batches = append(a.batches, stages)
+	a.mu.Unlock()
+
+	for _, name := range stages {
+		if err := a.stages[name].Analyze(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestAudio(t *testing.T) {
+	ctx := context.Background()
+
+	loudness := newStage("loudness", nil)
+	waveform := newStage("waveform", map[int]error{2: service.ErrConversionUnsupported})
+	other := newStage("other", nil)
+	storage := &audio{stages: map[string]*stage{"loudness": loudness, "waveform": waveform}}
+
+	a := analyzer.New(discardLog, 0)
+	a.Register(analyzer.Audio(storage, loudness, waveform)...)
+	a.Register(other)
+
+	stats, err := a.Backfill(ctx, files{1, 2}, models.BackfillOptions{})
+	require.NoError(t, err)
+	require.Equal(t, models.BackfillStats{Analyzed: 4, Skipped: 2}, stats)
+	require.Equal(t, [][]string{{"loudness", "waveform"}, {"loudness", "waveform"}}, storage.batches)
+	require.Equal(t, 1, other.count(2))
+
+	// Only pending stages are run.
+	storage.batches = nil
+	stats, err = a.Backfill(ctx, files{2}, models.BackfillOptions{})
+	require.NoError(t, err)
+	require.Equal(t, models.BackfillStats{Skipped: 3}, stats)
+	require.Equal(t, [][]string{{"waveform"}}, storage.batches)
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newStage("stage", nil)
+
+	a := analyzer.New(discardLog, 4)
+	a.Register(s)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Run(ctx)
+	}()
+
+	a.Enqueue(1)
+	a.Enqueue(2)
+
+	require.Eventually(t, func() bool {
+		return s.count(1) == 1 && s.count(2) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,27 @@
+package analyzer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	resultAnalyzed = "analyzed"
+	resultSkipped  = "skipped"
+	resultFailed   = "failed"
+)
+
+var (
+	queueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "analysis",
+		Name:      "queue_length",
+		Help:      "Number of files waiting for analysis.",
+	})
+	analyzedFiles = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "storage",
+		Subsystem: "analysis",
+		Name:      "files_total",
+		Help:      "Number of files processed by analysis stage, by result.",
+	}, []string{"stage", "result"})
+)
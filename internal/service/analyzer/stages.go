@@ -0,0 +1,229 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+)
+
+// Storage supports every analysis stage.
+type Storage interface {
+	AudioStorage
+	LoudnessStorage
+	WaveformStorage
+	CueStorage
+	FingerprintStorage
+	TempoKeyStorage
+	ArtworkStorage
+}
+
+// Stages returns all analysis stages.
+func Stages(storage Storage) []Stage {
+	stages := Audio(storage,
+		Loudness(storage),
+		Waveform(storage),
+		Cues(storage),
+		Fingerprint(storage),
+		TempoKey(storage),
+	)
+
+	return append(stages, Artwork(storage))
+}
+
+type AudioStorage interface {
+	// AnalyzeAudio decodes the file once and runs
+	// analyses of named stages on its audio.
+	AnalyzeAudio(ctx context.Context, id int, stages ...string) error
+}
+
+// Audio returns stages analyzing decoded audio of files.
+// Pending ones run together, so the file is decoded once.
+func Audio(storage AudioStorage, stages ...Stage) []Stage {
+	batch := &audioBatch{storage: storage}
+
+	res := make([]Stage, 0, len(stages))
+	for _, stage := range stages {
+		res = append(res, audioStage{Stage: stage, batch: batch})
+	}
+
+	return res
+}
+
+// audioBatch is shared by audio stages run together.
+type audioBatch struct {
+	storage AudioStorage
+}
+
+type audioStage struct {
+	Stage
+	batch *audioBatch
+}
+
+type LoudnessStorage interface {
+	Loudness(ctx context.Context, id int) (models.Loudness, error)
+	AnalyzeLoudness(ctx context.Context, id int) (models.Loudness, error)
+}
+
+// Loudness returns stage measuring loudness of files.
+func Loudness(storage LoudnessStorage) Stage {
+	return loudnessStage{storage: storage}
+}
+
+type loudnessStage struct {
+	storage LoudnessStorage
+}
+
+func (loudnessStage) Name() string {
+	return "loudness"
+}
+
+func (s loudnessStage) Analyzed(ctx context.Context, id int) (bool, error) {
+	_, err := s.storage.Loudness(ctx, id)
+	if errors.Is(err, service.ErrNotAnalyzed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s loudnessStage) Analyze(ctx context.Context, id int) error {
+	_, err := s.storage.AnalyzeLoudness(ctx, id)
+	return err
+}
+
+type WaveformStorage interface {
+	HasWaveform(ctx context.Context, id int) (bool, error)
+	AnalyzeWaveform(ctx context.Context, id int) error
+}
+
+// Waveform returns stage caching waveforms of files.
+func Waveform(storage WaveformStorage) Stage {
+	return waveformStage{storage: storage}
+}
+
+type waveformStage struct {
+	storage WaveformStorage
+}
+
+func (waveformStage) Name() string {
+	return "waveform"
+}
+
+func (s waveformStage) Analyzed(ctx context.Context, id int) (bool, error) {
+	return s.storage.HasWaveform(ctx, id)
+}
+
+func (s waveformStage) Analyze(ctx context.Context, id int) error {
+	return s.storage.AnalyzeWaveform(ctx, id)
+}
+
+type CueStorage interface {
+	HasCuePoints(ctx context.Context, id int) (bool, error)
+	AnalyzeCuePoints(ctx context.Context, id int) (models.CuePoints, error)
+}
+
+// Cues returns stage detecting cue points of files.
+func Cues(storage CueStorage) Stage {
+	return cueStage{storage: storage}
+}
+
+type cueStage struct {
+	storage CueStorage
+}
+
+func (cueStage) Name() string {
+	return "cues"
+}
+
+func (s cueStage) Analyzed(ctx context.Context, id int) (bool, error) {
+	return s.storage.HasCuePoints(ctx, id)
+}
+
+func (s cueStage) Analyze(ctx context.Context, id int) error {
+	_, err := s.storage.AnalyzeCuePoints(ctx, id)
+	return err
+}
+
+type FingerprintStorage interface {
+	HasFingerprint(ctx context.Context, id int) (bool, error)
+	AnalyzeFingerprint(ctx context.Context, id int) error
+}
+
+// Fingerprint returns stage computing acoustic fingerprints of files.
+func Fingerprint(storage FingerprintStorage) Stage {
+	return fingerprintStage{storage: storage}
+}
+
+type fingerprintStage struct {
+	storage FingerprintStorage
+}
+
+func (fingerprintStage) Name() string {
+	return "fingerprint"
+}
+
+func (s fingerprintStage) Analyzed(ctx context.Context, id int) (bool, error) {
+	return s.storage.HasFingerprint(ctx, id)
+}
+
+func (s fingerprintStage) Analyze(ctx context.Context, id int) error {
+	return s.storage.AnalyzeFingerprint(ctx, id)
+}
+
+type TempoKeyStorage interface {
+	TempoKey(ctx context.Context, id int) (models.TempoKey, error)
+	AnalyzeTempoKey(ctx context.Context, id int) (models.TempoKey, error)
+}
+
+// TempoKey returns stage estimating tempo and musical key of files.
+func TempoKey(storage TempoKeyStorage) Stage {
+	return tempoKeyStage{storage: storage}
+}
+
+type tempoKeyStage struct {
+	storage TempoKeyStorage
+}
+
+func (tempoKeyStage) Name() string {
+	return "tempo_key"
+}
+
+func (s tempoKeyStage) Analyzed(ctx context.Context, id int) (bool, error) {
+	_, err := s.storage.TempoKey(ctx, id)
+	if errors.Is(err, service.ErrNotAnalyzed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s tempoKeyStage) Analyze(ctx context.Context, id int) error {
+	_, err := s.storage.AnalyzeTempoKey(ctx, id)
+	return err
+}
+
+type ArtworkStorage interface {
+	HasArtwork(ctx context.Context, id int) (bool, error)
+	AnalyzeArtwork(ctx context.Context, id int) error
+}
+
+// Artwork returns stage extracting cover art of files.
+func Artwork(storage ArtworkStorage) Stage {
+	return artworkStage{storage: storage}
+}
+
+type artworkStage struct {
+	storage ArtworkStorage
+}
+
+func (artworkStage) Name() string {
+	return "artwork"
+}
+
+func (s artworkStage) Analyzed(ctx context.Context, id int) (bool, error) {
+	return s.storage.HasArtwork(ctx, id)
+}
+
+func (s artworkStage) Analyze(ctx context.Context, id int) error {
+	return s.storage.AnalyzeArtwork(ctx, id)
+}
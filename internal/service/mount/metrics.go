@@ -0,0 +1,32 @@
+package mount
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	resultPlayed = "played"
+	resultFailed = "failed"
+)
+
+var (
+	listenersCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "mount",
+		Name:      "listeners",
+		Help:      "Number of connected listeners.",
+	})
+	droppedListeners = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "storage",
+		Subsystem: "mount",
+		Name:      "dropped_listeners_total",
+		Help:      "Number of listeners disconnected for not keeping up with the stream.",
+	})
+	playedTracks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "storage",
+		Subsystem: "mount",
+		Name:      "tracks_total",
+		Help:      "Number of tracks started, by result.",
+	}, []string{"result"})
+)
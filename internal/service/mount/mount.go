@@ -0,0 +1,418 @@
+// Package mount serves continuous MP3 stream of stored
+// tracks to Icecast compatible listeners over HTTP.
+package mount
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/allowlist"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/icy"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/service"
+)
+
+const (
+	// chunkDuration is duration of audio sent to listeners at once.
+	chunkDuration = 100 * time.Millisecond
+	// burstLen is size of recent audio sent to new listener at once,
+	// so its player fills buffer without waiting.
+	burstLen = 64 * 1024
+	// listenerQueueLen is number of chunks listener
+	// may fall behind before it is disconnected.
+	listenerQueueLen = 64
+	// maxLag is how far playback may fall behind real time,
+	// e.g. while waiting for a track, before pacing restarts
+	// instead of catching up.
+	maxLag = time.Second
+
+	// maxFailures is number of tracks failed in a row
+	// after which playback pauses for retryWait.
+	maxFailures = 5
+	retryWait   = 5 * time.Second
+)
+
+type Storage interface {
+	Open(ctx context.Context, id int) (io.ReadCloser, error)
+	Metadata(ctx context.Context, id int) (models.Metadata, error)
+}
+
+// chunk is part of the stream sent to listeners.
+type chunk struct {
+	data  []byte
+	title string
+}
+
+type listener struct {
+	ch chan chunk
+}
+
+// Mount plays tracks from queue in real time
+// and sends the same stream to every listener.
+type Mount struct {
+	log     *slog.Logger
+	storage Storage
+	queue   Queue
+	name    string
+
+	mu        sync.Mutex
+	listeners map[*listener]struct{}
+	// burst holds recent chunks sent to new listeners.
+	burst    []chunk
+	burstLen int
+	// stopped is set once playback ends.
+	stopped bool
+}
+
+// New returns mount with given stream name.
+func New(log *slog.Logger, storage Storage, queue Queue, name string) *Mount {
+	return &Mount{
+		log:       log,
+		storage:   storage,
+		queue:     queue,
+		name:      name,
+		listeners: make(map[*listener]struct{}),
+	}
+}
+
+// Run plays tracks until ctx is done,
+// then disconnects all listeners.
+//
+// Tracks failed to play are skipped.
+func (m *Mount) Run(ctx context.Context) {
+	const op = "Mount.Run"
+
+	log := m.log.With(slog.String("op", op))
+
+	defer m.stop()
+
+	var (
+		p        pacer
+		failures int
+	)
+	for ctx.Err() == nil {
+		id, err := m.queue.Next(ctx)
+		if err != nil {
+			if errors.Is(err, ErrEmptyQueue) {
+				log.Debug("queue is empty")
+			} else {
+				log.Error("failed to pick next track", sl.Err(err))
+			}
+			sleep(ctx, retryWait)
+			continue
+		}
+
+		if err := m.play(ctx, id, &p); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("failed to play track", slog.Int("id", id), sl.Err(err))
+			playedTracks.WithLabelValues(resultFailed).Inc()
+
+			if failures++; failures >= maxFailures {
+				failures = 0
+				sleep(ctx, retryWait)
+			}
+			continue
+		}
+
+		playedTracks.WithLabelValues(resultPlayed).Inc()
+		failures = 0
+	}
+}
+
+// play sends audio frames of the track to listeners.
+// Tags and VBR header are skipped.
+func (m *Mount) play(ctx context.Context, id int, p *pacer) error {
+	metadata, err := m.storage.Metadata(ctx, id)
+	if err != nil {
+		return err
+	}
+	if metadata.Format != format.MP3.Name {
+		return fmt.Errorf("%w: %s", service.ErrConversionUnsupported, metadata.Format)
+	}
+
+	file, err := m.storage.Open(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	title := streamTitle(metadata)
+
+	m.log.Info("playing track", slog.Int("id", id), slog.String("title", title))
+
+	r := bufio.NewReader(file)
+	if header, err := r.Peek(mp3.ID3v2HeaderLen); err == nil {
+		if _, err := r.Discard(mp3.ID3v2Size(header)); err != nil {
+			return err
+		}
+	}
+
+	var (
+		frames   = mp3.NewReader(r)
+		data     []byte
+		duration time.Duration
+	)
+	for first := true; ; first = false {
+		frame, err := frames.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		if first {
+			if _, ok := mp3.ParseXing(frame); ok {
+				continue
+			}
+		}
+
+		data = append(data, frame.Data...)
+		duration += time.Duration(frame.Header.Samples()) * time.Second / time.Duration(frame.Header.SampleRate)
+
+		if duration >= chunkDuration {
+			if err := m.send(ctx, p, chunk{data: data, title: title}, duration); err != nil {
+				return err
+			}
+			data, duration = nil, 0
+		}
+	}
+
+	if len(data) > 0 {
+		return m.send(ctx, p, chunk{data: data, title: title}, duration)
+	}
+
+	return nil
+}
+
+// send broadcasts chunk when it is due.
+func (m *Mount) send(ctx context.Context, p *pacer, c chunk, duration time.Duration) error {
+	if err := p.wait(ctx); err != nil {
+		return err
+	}
+
+	m.broadcast(c)
+	p.advance(duration)
+
+	return nil
+}
+
+func (m *Mount) broadcast(c chunk) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for l := range m.listeners {
+		select {
+		case l.ch <- c:
+		default:
+			// Listener does not keep up with real time.
+			m.log.Warn("listener falls behind, disconnecting")
+			delete(m.listeners, l)
+			close(l.ch)
+			listenersCount.Dec()
+			droppedListeners.Inc()
+		}
+	}
+
+	m.burst = append(m.burst, c)
+	m.burstLen += len(c.data)
+	for m.burstLen-len(m.burst[0].data) >= burstLen {
+		m.burstLen -= len(m.burst[0].data)
+		m.burst = m.burst[1:]
+	}
+}
+
+// subscribe returns listener receiving recent audio and
+// the following stream, its channel is closed if it falls behind.
+func (m *Mount) subscribe() *listener {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := &listener{
+		ch: make(chan chunk, len(m.burst)+listenerQueueLen),
+	}
+	if m.stopped {
+		close(l.ch)
+		return l
+	}
+	for _, c := range m.burst {
+		l.ch <- c
+	}
+
+	m.listeners[l] = struct{}{}
+	listenersCount.Inc()
+
+	return l
+}
+
+// stop disconnects listeners, so
+// HTTP server may shut down.
+func (m *Mount) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for l := range m.listeners {
+		delete(m.listeners, l)
+		close(l.ch)
+		listenersCount.Dec()
+	}
+	m.stopped = true
+}
+
+func (m *Mount) unsubscribe(l *listener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.listeners[l]; ok {
+		delete(m.listeners, l)
+		listenersCount.Dec()
+	}
+}
+
+// Handler returns HTTP handler of the stream.
+// Listener requesting ICY metadata receives current title
+// every icy.DefaultMetaInt bytes.
+//
+// Nil allowedIps allows any client.
+func (m *Mount) Handler(allowedIps []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowedIps != nil && !allowlist.Allowed(r.RemoteAddr, allowedIps) {
+			http.Error(w, "ip is not allowed", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		metaInt := 0
+		if r.Header.Get("Icy-MetaData") == "1" {
+			metaInt = icy.DefaultMetaInt
+		}
+
+		h := w.Header()
+		h.Set("Content-Type", format.MP3.ContentType)
+		h.Set("Cache-Control", "no-cache, no-store")
+		h.Set("icy-name", m.name)
+		h.Set("icy-pub", "0")
+		if metaInt != 0 {
+			h.Set("icy-metaint", strconv.Itoa(metaInt))
+		}
+
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		m.serve(w, r, metaInt)
+	})
+}
+
+func (m *Mount) serve(w http.ResponseWriter, r *http.Request, metaInt int) {
+	const op = "Mount.serve"
+
+	log := m.log.With(
+		slog.String("op", op),
+		slog.String("addr", r.RemoteAddr),
+	)
+
+	l := m.subscribe()
+	defer m.unsubscribe(l)
+
+	log.Info("listener connected")
+	defer log.Info("listener disconnected")
+
+	// Headers are sent at once, as
+	// audio may not be available yet.
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	iw := icy.NewWriter(w, metaInt)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case c, ok := <-l.ch:
+			if !ok {
+				return
+			}
+
+			iw.SetTitle(c.title)
+			if _, err := iw.Write(c.data); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// streamTitle returns title of the track shown to listeners.
+func streamTitle(metadata models.Metadata) string {
+	switch {
+	case metadata.Artist != "" && metadata.Title != "":
+		return metadata.Artist + " - " + metadata.Title
+	default:
+		return metadata.Title
+	}
+}
+
+// pacer keeps stream in real time.
+type pacer struct {
+	start time.Time
+	// sent is duration of audio sent since start.
+	sent time.Duration
+}
+
+// wait blocks until audio sent so far is played.
+func (p *pacer) wait(ctx context.Context) error {
+	now := time.Now()
+	if p.start.IsZero() {
+		p.start = now
+	}
+
+	d := p.start.Add(p.sent).Sub(now)
+	if d < -maxLag {
+		p.start = now.Add(-p.sent)
+		return nil
+	}
+	if d <= 0 {
+		return nil
+	}
+
+	return sleep(ctx, d)
+}
+
+func (p *pacer) advance(d time.Duration) {
+	p.sent += d
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
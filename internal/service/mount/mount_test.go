@@ -0,0 +1,166 @@
+package mount
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/icy"
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/internal/service"
+)
+
+type track struct {
+	data     []byte
+	metadata models.Metadata
+}
+
+type fakeStorage struct {
+	tracks map[int]track
+	pinned []int
+}
+
+func (s *fakeStorage) Open(ctx context.Context, id int) (io.ReadCloser, error) {
+	t, ok := s.tracks[id]
+	if !ok {
+		return nil, service.ErrFileNotExist
+	}
+	return io.NopCloser(bytes.NewReader(t.data)), nil
+}
+
+func (s *fakeStorage) Metadata(ctx context.Context, id int) (models.Metadata, error) {
+	t, ok := s.tracks[id]
+	if !ok {
+		return models.Metadata{}, service.ErrFileNotExist
+	}
+	return t.metadata, nil
+}
+
+func (s *fakeStorage) ListPinned(ctx context.Context) ([]int, error) {
+	return s.pinned, nil
+}
+
+func TestMount(t *testing.T) {
+	tag := mp3test.ID3v2(map[string]string{"TIT2": "News"})
+	storage := &fakeStorage{tracks: map[int]track{
+		1: {
+			data:     append(tag, mp3test.Sound(10)...),
+			metadata: models.Metadata{Format: "mp3", Artist: "Radio", Title: "News"},
+		},
+		2: {
+			data:     mp3test.Silence(5),
+			metadata: models.Metadata{Format: "mp3", Title: "Pause"},
+		},
+		3: {
+			data:     []byte("fLaC"),
+			metadata: models.Metadata{Format: "flac"},
+		},
+	}}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Missing and non-MP3 tracks are skipped.
+	m := New(log, storage, Loop([]int{1, 3, 4, 2}), "Fallback")
+
+	srv := httptest.NewServer(m.Handler(nil))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Icy-MetaData", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "audio/mpeg", resp.Header.Get("Content-Type"))
+	require.Equal(t, "Fallback", resp.Header.Get("icy-name"))
+	require.Equal(t, strconv.Itoa(icy.DefaultMetaInt), resp.Header.Get("icy-metaint"))
+
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.listeners) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	go m.Run(ctx)
+
+	body := bufio.NewReader(resp.Body)
+	audio := make([]byte, icy.DefaultMetaInt)
+	_, err = io.ReadFull(body, audio)
+	require.NoError(t, err)
+
+	// Tags are stripped, tracks are repeated.
+	loop := append(mp3test.Sound(10), mp3test.Silence(5)...)
+	want := bytes.Repeat(loop, icy.DefaultMetaInt/len(loop)+1)[:icy.DefaultMetaInt]
+	require.Equal(t, want, audio)
+
+	// Audio is sent in real time.
+	played := time.Duration(icy.DefaultMetaInt/mp3test.FrameLen*mp3test.FrameSamples) * time.Second / mp3test.SampleRate
+	require.Greater(t, time.Since(start), played-2*chunkDuration)
+
+	// Metadata block follows, third loop plays the first track.
+	length, err := body.ReadByte()
+	require.NoError(t, err)
+	meta := make([]byte, int(length)*16)
+	_, err = io.ReadFull(body, meta)
+	require.NoError(t, err)
+	require.Equal(t, "StreamTitle='Radio - News';", string(bytes.TrimRight(meta, "\x00")))
+}
+
+func TestLoop(t *testing.T) {
+	q := Loop([]int{1, 2})
+
+	var ids []int
+	for i := 0; i < 5; i++ {
+		id, err := q.Next(context.Background())
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	require.Equal(t, []int{1, 2, 1, 2, 1}, ids)
+
+	_, err := Loop(nil).Next(context.Background())
+	require.ErrorIs(t, err, ErrEmptyQueue)
+}
+
+func TestRandom(t *testing.T) {
+	storage := &fakeStorage{pinned: []int{1, 2, 3}}
+	q := Random(storage)
+
+	seen := make(map[int]bool)
+	last := 0
+	for i := 0; i < 100; i++ {
+		id, err := q.Next(context.Background())
+		require.NoError(t, err)
+		require.Contains(t, storage.pinned, id)
+		require.NotEqual(t, last, id)
+		seen[id] = true
+		last = id
+	}
+	require.Len(t, seen, 3)
+
+	storage.pinned = []int{7}
+	for i := 0; i < 3; i++ {
+		id, err := q.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 7, id)
+	}
+
+	storage.pinned = nil
+	_, err := q.Next(context.Background())
+	require.ErrorIs(t, err, ErrEmptyQueue)
+}
@@ -0,0 +1,85 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+)
+
+// ErrEmptyQueue is returned when queue has no tracks to play.
+var ErrEmptyQueue = errors.New("queue is empty")
+
+// Queue picks tracks to play.
+type Queue interface {
+	// Next returns id of the next track.
+	Next(ctx context.Context) (int, error)
+}
+
+// Loop returns queue playing given tracks in a loop.
+func Loop(ids []int) Queue {
+	return &loopQueue{ids: ids}
+}
+
+type loopQueue struct {
+	mu   sync.Mutex
+	ids  []int
+	next int
+}
+
+func (q *loopQueue) Next(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.ids) == 0 {
+		return 0, ErrEmptyQueue
+	}
+
+	id := q.ids[q.next]
+	q.next = (q.next + 1) % len(q.ids)
+
+	return id, nil
+}
+
+type PinStorage interface {
+	ListPinned(ctx context.Context) ([]int, error)
+}
+
+// Random returns queue playing pinned tracks in random order.
+// The same track is not played twice in a row unless it is
+// the only one, and changes of pins apply to the next track.
+func Random(storage PinStorage) Queue {
+	return &randomQueue{
+		storage: storage,
+		last:    -1,
+	}
+}
+
+type randomQueue struct {
+	storage PinStorage
+
+	mu   sync.Mutex
+	last int
+}
+
+func (q *randomQueue) Next(ctx context.Context) (int, error) {
+	ids, err := q.storage.ListPinned(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(ids) == 0 {
+		return 0, ErrEmptyQueue
+	}
+
+	id := ids[rand.Intn(len(ids))]
+	for len(ids) > 1 && id == q.last {
+		id = ids[rand.Intn(len(ids))]
+	}
+	q.last = id
+
+	return id, nil
+}
@@ -0,0 +1,19 @@
+package reaper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	resultDeleted = "deleted"
+	resultPinned  = "pinned"
+	resultFailed  = "failed"
+)
+
+var reapedFiles = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "storage",
+	Subsystem: "reaper",
+	Name:      "expired_files_total",
+	Help:      "Number of expired files handled by reaper, by result.",
+}, []string{"result"})
@@ -0,0 +1,79 @@
+// Package reaper periodically deletes
+// expired files of the storage.
+package reaper
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+)
+
+type Storage interface {
+	Reap(ctx context.Context, now time.Time) (models.ReapStats, error)
+}
+
+// Reaper runs reap of the storage every interval.
+type Reaper struct {
+	log      *slog.Logger
+	storage  Storage
+	interval time.Duration
+}
+
+func New(log *slog.Logger, storage Storage, interval time.Duration) *Reaper {
+	return &Reaper{
+		log:      log,
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+// Run reaps the storage at once and then every interval
+// until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	const op = "Reaper.Run"
+
+	log := r.log.With(slog.String("op", op))
+
+	log.Info("reaper started", slog.Duration("interval", r.interval))
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.reap(ctx, log)
+
+		select {
+		case <-ctx.Done():
+			log.Info("reaper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reaper) reap(ctx context.Context, log *slog.Logger) {
+	stats, err := r.storage.Reap(ctx, time.Now())
+	reapedFiles.WithLabelValues(resultDeleted).Add(float64(stats.Deleted))
+	reapedFiles.WithLabelValues(resultPinned).Add(float64(stats.Pinned))
+	reapedFiles.WithLabelValues(resultFailed).Add(float64(stats.Failed))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("failed to reap expired files", sl.Err(err))
+		}
+		return
+	}
+
+	if stats == (models.ReapStats{}) {
+		log.Debug("no expired files")
+		return
+	}
+
+	log.Info("reaped expired files",
+		slog.Int("deleted", stats.Deleted),
+		slog.Int("pinned", stats.Pinned),
+		slog.Int("failed", stats.Failed),
+	)
+}
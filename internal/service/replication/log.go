@@ -0,0 +1,321 @@
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"radio-storage/internal/domain/models"
+)
+
+// recordSize is a size of a single log record:
+// seq (8) | committed at, unix nano (8) | id (4) | op (1) | namespace (1) | padding (2).
+//
+// Namespace is a number of namespace in namespaces file
+// next to the log, zero for default namespace.
+const recordSize = 24
+
+const (
+	namespacesName = "namespaces"
+	maxNamespaces  = 255
+	// seededName lists namespaces whose files
+	// stored before replication are in the log.
+	seededName = "seeded"
+)
+
+var ErrSeqOutOfRange = errors.New("sequence number out of range")
+
+// Log is a persistent append-only log of committed changes.
+//
+// Records have fixed size, so sequence number
+// directly addresses record position.
+//
+// Log is never compacted: it grows by recordSize bytes
+// per change, and new replica replays it from the first
+// record. Its size is reported as log_bytes metric. To drop
+// log grown too large, remove its directory on primary along
+// with positions of replicas, next start seeds it anew.
+type Log struct {
+	mu      sync.RWMutex
+	f       *os.File
+	head    uint64
+	changed chan struct{}
+
+	// namespaces are named namespaces by their
+	// number in records minus one, never reordered.
+	namespacesPath string
+	namespaces     []string
+
+	seededPath string
+	seeded     []string
+}
+
+// OpenLog opens log file, creating it if needed.
+// Incomplete trailing record is discarded.
+func OpenLog(path string) (*Log, error) {
+	const op = "replication.OpenLog"
+
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	size := info.Size() - info.Size()%recordSize
+	if size != info.Size() {
+		if err := f.Truncate(size); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	l := &Log{
+		f:              f,
+		head:           uint64(size / recordSize),
+		changed:        make(chan struct{}),
+		namespacesPath: filepath.Join(filepath.Dir(path), namespacesName),
+		seededPath:     filepath.Join(filepath.Dir(path), seededName),
+	}
+
+	l.namespaces, err = readList(l.namespacesPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.seeded, err = readList(l.seededPath)
+	if errors.Is(err, os.ErrNotExist) {
+		if l.head == 0 {
+			// New log, nothing seeded yet.
+			err = writeList(l.seededPath, nil)
+		} else {
+			// Log written before seeded namespaces were listed
+			// was seeded with all namespaces having records.
+			l.seeded, err = l.recorded()
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l, nil
+}
+
+// readList reads names stored one per line.
+func readList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(data)), nil
+}
+
+// writeList atomically replaces names stored in file.
+func writeList(path string, names []string) error {
+	tmp := path + ".tmp"
+	var data []byte
+	for _, name := range names {
+		data = append(data, name+"\n"...)
+	}
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// recorded returns namespaces having records in the log.
+func (l *Log) recorded() ([]string, error) {
+	var names []string
+	for seq := uint64(1); seq <= l.head; seq++ {
+		change, err := l.Read(seq)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(names, change.Namespace) {
+			names = append(names, change.Namespace)
+		}
+	}
+
+	return names, nil
+}
+
+// Seeded reports whether files of namespace
+// stored before replication are in the log.
+func (l *Log) Seeded(namespace string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return slices.Contains(l.seeded, namespace)
+}
+
+// MarkSeeded persists that namespace is seeded.
+func (l *Log) MarkSeeded(namespace string) error {
+	const fn = "Log.MarkSeeded"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if slices.Contains(l.seeded, namespace) {
+		return nil
+	}
+
+	seeded := append(slices.Clone(l.seeded), namespace)
+	if err := writeList(l.seededPath, seeded); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	l.seeded = seeded
+
+	return nil
+}
+
+// Journal records changes of single namespace to the log.
+type Journal struct {
+	log       *Log
+	namespace byte
+}
+
+// Journal returns journal of namespace,
+// registering namespace in the log if it is new.
+func (l *Log) Journal(namespace string) (*Journal, error) {
+	const fn = "Log.Journal"
+
+	if namespace == models.DefaultNamespace {
+		return &Journal{log: l}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if i := slices.Index(l.namespaces, namespace); i >= 0 {
+		return &Journal{log: l, namespace: byte(i + 1)}, nil
+	}
+
+	if len(l.namespaces) == maxNamespaces {
+		return nil, fmt.Errorf("%s: too many namespaces", fn)
+	}
+
+	namespaces := append(slices.Clone(l.namespaces), namespace)
+
+	if err := writeList(l.namespacesPath, namespaces); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	l.namespaces = namespaces
+
+	return &Journal{log: l, namespace: byte(len(namespaces))}, nil
+}
+
+// Append writes change of the namespace to the log.
+func (j *Journal) Append(op models.ChangeOp, id int) (uint64, error) {
+	return j.log.append(j.namespace, op, id)
+}
+
+// Append writes change of default namespace to the log
+// and returns its sequence number.
+func (l *Log) Append(op models.ChangeOp, id int) (uint64, error) {
+	return l.append(0, op, id)
+}
+
+func (l *Log) append(namespace byte, op models.ChangeOp, id int) (uint64, error) {
+	const fn = "Log.Append"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seq := l.head + 1
+
+	var buf [recordSize]byte
+	binary.LittleEndian.PutUint64(buf[0:], seq)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(time.Now().UnixNano()))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(id))
+	buf[20] = byte(op)
+	buf[21] = namespace
+
+	if _, err := l.f.WriteAt(buf[:], int64(l.head)*recordSize); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	l.head = seq
+
+	// Wake up waiters.
+	close(l.changed)
+	l.changed = make(chan struct{})
+
+	return seq, nil
+}
+
+// Head returns sequence number of the last record.
+func (l *Log) Head() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.head
+}
+
+// Changed returns channel closed on next append.
+func (l *Log) Changed() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.changed
+}
+
+// Read returns record with given sequence number.
+func (l *Log) Read(seq uint64) (models.Change, error) {
+	const fn = "Log.Read"
+
+	if seq == 0 || seq > l.Head() {
+		return models.Change{}, ErrSeqOutOfRange
+	}
+
+	var buf [recordSize]byte
+	if _, err := l.f.ReadAt(buf[:], int64(seq-1)*recordSize); err != nil {
+		return models.Change{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	namespace := models.DefaultNamespace
+	if n := int(buf[21]); n != 0 {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+
+		if n > len(l.namespaces) {
+			return models.Change{}, fmt.Errorf("%s: unknown namespace %d", fn, n)
+		}
+		namespace = l.namespaces[n-1]
+	}
+
+	return models.Change{
+		Seq:         binary.LittleEndian.Uint64(buf[0:]),
+		CommittedAt: time.Unix(0, int64(binary.LittleEndian.Uint64(buf[8:]))),
+		ID:          int(binary.LittleEndian.Uint32(buf[16:])),
+		Op:          models.ChangeOp(buf[20]),
+		Namespace:   namespace,
+	}, nil
+}
+
+// Close closes log file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
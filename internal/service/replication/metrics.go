@@ -0,0 +1,58 @@
+package replication
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	headSeq = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "head_seq",
+		Help:      "Sequence number of the last replication log record on primary.",
+	})
+	logBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "log_bytes",
+		Help:      "Size of replication log on primary, it is never compacted.",
+	})
+	replicasConnected = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "replicas_connected",
+		Help:      "Number of replicas currently streaming from primary.",
+	})
+	replicaAckedSeq = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "replica_acked_seq",
+		Help:      "Last sequence number acknowledged by replica.",
+	}, []string{"replica"})
+	replicaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "replica_lag_events",
+		Help:      "Number of log records not yet acknowledged by replica, as seen by primary.",
+	}, []string{"replica"})
+
+	appliedSeq = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "applied_seq",
+		Help:      "Last sequence number applied by replica.",
+	})
+	lagEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "lag_events",
+		Help:      "Number of primary log records not yet applied by replica.",
+	})
+	lagSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "replication",
+		Name:      "lag_seconds",
+		Help:      "Time since commit of the last applied record, zero if replica caught up.",
+	})
+)
@@ -0,0 +1,267 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpcModels "radio-storage/internal/domain/grpc"
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	bufferLen         = 1024 * 32
+	heartbeatInterval = 5 * time.Second
+)
+
+type FileStorage interface {
+	Open(ctx context.Context, id int) (io.ReadCloser, error)
+	OpenSidecar(ctx context.Context, id int) (io.ReadCloser, error)
+	IDs(ctx context.Context, fn func(id int) error) error
+}
+
+// Primary streams replication log to replicas.
+type Primary struct {
+	log      *slog.Logger
+	changes  *Log
+	storages map[string]FileStorage
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewPrimary returns primary serving files
+// of storages by their namespaces.
+func NewPrimary(
+	log *slog.Logger,
+	changes *Log,
+	storages map[string]FileStorage,
+) *Primary {
+	reportHead(changes.Head())
+
+	return &Primary{
+		log:      log,
+		changes:  changes,
+		storages: storages,
+		done:     make(chan struct{}),
+	}
+}
+
+// Seed records every stored file as upload followed by its sidecar
+// for namespaces not seeded yet, so replicas receive content
+// stored before replication was enabled for them.
+//
+// Namespace is marked seeded only after all its files are recorded,
+// so interrupted seed is started over on next start.
+func (p *Primary) Seed(ctx context.Context) error {
+	const op = "Primary.Seed"
+
+	log := p.log.With(
+		slog.String("op", op),
+	)
+
+	namespaces := make([]string, 0, len(p.storages))
+	for name := range p.storages {
+		namespaces = append(namespaces, name)
+	}
+	slices.Sort(namespaces)
+
+	for _, name := range namespaces {
+		if p.changes.Seeded(name) {
+			continue
+		}
+
+		journal, err := p.changes.Journal(name)
+		if err != nil {
+			log.Error("failed to register namespace", slog.String("namespace", name), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := p.storages[name].IDs(ctx, func(id int) error {
+			if _, err := journal.Append(models.OpUpload, id); err != nil {
+				return err
+			}
+			_, err := journal.Append(models.OpSidecar, id)
+			return err
+		}); err != nil {
+			log.Error("failed to seed replication log", slog.String("namespace", name), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := p.changes.MarkSeeded(name); err != nil {
+			log.Error("failed to mark namespace seeded", slog.String("namespace", name), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("seeded replication log", slog.String("namespace", name), slog.Uint64("head", p.changes.Head()))
+	}
+
+	reportHead(p.changes.Head())
+
+	return nil
+}
+
+// Replicate streams changes to replica
+// starting after position it acknowledges first.
+//
+// Returns when replica disconnects or primary is closed.
+func (p *Primary) Replicate(ctx context.Context, w *grpcModels.ReplicationStreamWrapper) error {
+	const op = "Primary.Replicate"
+
+	log := p.log.With(
+		slog.String("op", op),
+	)
+
+	replica, applied, err := w.Recv()
+	if err != nil {
+		log.Error("failed to receive replica position", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("replica", replica))
+
+	if applied > p.changes.Head() {
+		log.Error("replica is ahead of primary", slog.Uint64("applied", applied), slog.Uint64("head", p.changes.Head()))
+		return service.ErrReplicaAhead
+	}
+
+	log.Info("replica connected", slog.Uint64("applied", applied))
+
+	replicasConnected.Inc()
+	defer replicasConnected.Dec()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Receive acknowledgements.
+	var acked atomic.Uint64
+	acked.Store(applied)
+	p.reportAck(replica, applied)
+
+	go func() {
+		defer cancel()
+		for {
+			_, seq, err := w.Recv()
+			if err != nil {
+				return
+			}
+			acked.Store(seq)
+			p.reportAck(replica, seq)
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for seq := applied + 1; ; {
+		changed := p.changes.Changed()
+		head := p.changes.Head()
+
+		reportHead(head)
+		replicaLag.WithLabelValues(replica).Set(float64(head - min(acked.Load(), head)))
+
+		if seq > head {
+			select {
+			case <-ctx.Done():
+				log.Info("replica disconnected")
+				return nil
+			case <-p.done:
+				log.Info("primary is closed")
+				return nil
+			case <-changed:
+			case <-heartbeat.C:
+				if err := w.Heartbeat(head); err != nil {
+					log.Warn("failed to send heartbeat", sl.Err(err))
+					return fmt.Errorf("%s: %w", op, err)
+				}
+			}
+			continue
+		}
+
+		change, err := p.changes.Read(seq)
+		if err != nil {
+			log.Error("failed to read log", slog.Uint64("seq", seq), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := p.send(ctx, w, change, head); err != nil {
+			log.Warn("failed to send change", slog.Uint64("seq", seq), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		seq++
+	}
+}
+
+// send streams single change, including file content for uploads
+// and sidecar archive for sidecar changes.
+func (p *Primary) send(ctx context.Context, w *grpcModels.ReplicationStreamWrapper, change models.Change, head uint64) error {
+	if change.Op != models.OpUpload && change.Op != models.OpSidecar {
+		return w.Send(change, nil, true, head)
+	}
+
+	storage, ok := p.storages[change.Namespace]
+	if !ok {
+		return fmt.Errorf("namespace %q is not served", change.Namespace)
+	}
+
+	open := storage.Open
+	if change.Op == models.OpSidecar {
+		open = storage.OpenSidecar
+	}
+
+	file, err := open(ctx, change.ID)
+	if err != nil {
+		// File was deleted later, so the delete record follows.
+		// Send delete right away to keep sequence contiguous.
+		if errors.Is(err, service.ErrFileNotExist) {
+			change.Op = models.OpDelete
+			return w.Send(change, nil, true, head)
+		}
+		return err
+	}
+	defer file.Close()
+
+	buffer := make([]byte, bufferLen)
+	for {
+		n, err := io.ReadFull(file, buffer)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		last := n < bufferLen
+		if err := w.Send(change, buffer[:n], last, head); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+func (p *Primary) reportAck(replica string, seq uint64) {
+	replicaAckedSeq.WithLabelValues(replica).Set(float64(seq))
+	head := p.changes.Head()
+	replicaLag.WithLabelValues(replica).Set(float64(head - min(seq, head)))
+}
+
+// Close stops all replication streams.
+func (p *Primary) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// reportHead updates metrics of the log with given head.
+func reportHead(head uint64) {
+	headSeq.Set(float64(head))
+	logBytes.Set(float64(head * recordSize))
+}
@@ -0,0 +1,278 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+type Applier interface {
+	Put(ctx context.Context, id int, r io.Reader) error
+	PutSidecar(ctx context.Context, id int, r io.Reader) error
+	Remove(ctx context.Context, id int) error
+}
+
+// Replica applies changes streamed by primary
+// and keeps applied position on disk.
+type Replica struct {
+	log          *slog.Logger
+	name         string
+	client       ssov1.ReplicationServiceClient
+	storages     map[string]Applier
+	positionPath string
+
+	applied atomic.Uint64
+}
+
+// NewReplica returns replica applying changes
+// to storages by their namespaces.
+func NewReplica(
+	log *slog.Logger,
+	name string,
+	client ssov1.ReplicationServiceClient,
+	storages map[string]Applier,
+	positionPath string,
+) (*Replica, error) {
+	const op = "replication.NewReplica"
+
+	r := &Replica{
+		log:          log,
+		name:         name,
+		client:       client,
+		storages:     storages,
+		positionPath: positionPath,
+	}
+
+	data, err := os.ReadFile(positionPath)
+	switch {
+	case err == nil:
+		applied, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid position: %w", op, err)
+		}
+		r.applied.Store(applied)
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	appliedSeq.Set(float64(r.applied.Load()))
+
+	return r, nil
+}
+
+// Run replicates changes until context is done,
+// reconnecting to primary on failures.
+func (r *Replica) Run(ctx context.Context) {
+	const op = "Replica.Run"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.String("replica", r.name),
+	)
+
+	backoff := minBackoff
+	for {
+		progressed, err := r.replicate(ctx)
+		if ctx.Err() != nil {
+			log.Info("replication stopped", slog.Uint64("applied", r.applied.Load()))
+			return
+		}
+
+		if progressed {
+			backoff = minBackoff
+		}
+
+		log.Warn("replication interrupted", slog.Duration("retry_in", backoff), sl.Err(err))
+
+		select {
+		case <-ctx.Done():
+			log.Info("replication stopped", slog.Uint64("applied", r.applied.Load()))
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// replicate runs single replication session.
+// Reports if any change was applied.
+func (r *Replica) replicate(ctx context.Context) (bool, error) {
+	const op = "Replica.replicate"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := r.client.Replicate(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := stream.Send(&ssov1.ReplicationAck{Replica: r.name, AppliedSeq: r.applied.Load()}); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("connected to primary", slog.Uint64("applied", r.applied.Load()))
+
+	progressed := false
+
+	// Upload or sidecar in progress.
+	var (
+		pw   *io.PipeWriter
+		errc chan error
+	)
+	defer func() {
+		if pw != nil {
+			pw.CloseWithError(context.Canceled)
+			<-errc
+		}
+	}()
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return progressed, fmt.Errorf("%s: %w", op, err)
+		}
+
+		r.reportLag(event)
+
+		if event.GetOp() == ssov1.ReplicationOp_REPLICATION_OP_HEARTBEAT {
+			continue
+		}
+
+		storage, err := r.storage(event.GetNamespace())
+		if err != nil {
+			return progressed, fmt.Errorf("%s: %w", op, err)
+		}
+
+		switch event.GetOp() {
+		case ssov1.ReplicationOp_REPLICATION_OP_DELETE:
+			if err := storage.Remove(ctx, int(event.GetFileId())); err != nil {
+				return progressed, fmt.Errorf("%s: %w", op, err)
+			}
+
+		case ssov1.ReplicationOp_REPLICATION_OP_UPLOAD, ssov1.ReplicationOp_REPLICATION_OP_SIDECAR:
+			if pw == nil {
+				apply := storage.Put
+				if event.GetOp() == ssov1.ReplicationOp_REPLICATION_OP_SIDECAR {
+					apply = storage.PutSidecar
+				}
+
+				var pr *io.PipeReader
+				pr, pw = io.Pipe()
+				errc = make(chan error, 1)
+
+				go func(id int) {
+					err := apply(ctx, id, pr)
+					pr.CloseWithError(err)
+					errc <- err
+				}(int(event.GetFileId()))
+			}
+
+			if _, err := pw.Write(event.GetChunk()); err != nil {
+				return progressed, fmt.Errorf("%s: %w", op, err)
+			}
+
+			if !event.GetLast() {
+				continue
+			}
+
+			pw.Close()
+			err := <-errc
+			pw = nil
+			if err != nil {
+				return progressed, fmt.Errorf("%s: %w", op, err)
+			}
+
+		default:
+			return progressed, fmt.Errorf("%s: unknown op %s", op, event.GetOp())
+		}
+
+		if err := r.advance(event.GetSeq()); err != nil {
+			return progressed, fmt.Errorf("%s: %w", op, err)
+		}
+		progressed = true
+
+		r.reportLag(event)
+
+		if err := stream.Send(&ssov1.ReplicationAck{Replica: r.name, AppliedSeq: r.applied.Load()}); err != nil {
+			return progressed, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+}
+
+// storage returns storage of namespace,
+// empty name stands for default namespace.
+func (r *Replica) storage(namespace string) (Applier, error) {
+	if namespace == "" {
+		namespace = models.DefaultNamespace
+	}
+
+	s, ok := r.storages[namespace]
+	if !ok {
+		return nil, fmt.Errorf("namespace %q is not configured on replica", namespace)
+	}
+
+	return s, nil
+}
+
+// advance persists applied position.
+func (r *Replica) advance(seq uint64) error {
+	tmp := r.positionPath + ".tmp"
+
+	if err := os.MkdirAll(filepath.Dir(tmp), 0777); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(seq, 10)), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.positionPath); err != nil {
+		return err
+	}
+
+	r.applied.Store(seq)
+	appliedSeq.Set(float64(seq))
+
+	return nil
+}
+
+func (r *Replica) reportLag(event *ssov1.ReplicationEvent) {
+	head := event.GetHeadSeq()
+	lag := head - min(r.applied.Load(), head)
+
+	lagEvents.Set(float64(lag))
+
+	if lag == 0 {
+		lagSeconds.Set(0)
+	} else if event.GetCommittedAt() != nil {
+		lagSeconds.Set(time.Since(event.GetCommittedAt().AsTime()).Seconds())
+	}
+}
+
+// Applied returns last applied sequence number.
+func (r *Replica) Applied() uint64 {
+	return r.applied.Load()
+}
@@ -0,0 +1,378 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"radio-storage/internal/domain/models"
+	storageGRPC "radio-storage/internal/grpc"
+	"radio-storage/internal/service"
+	storage "radio-storage/internal/service/storage"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestLog(t *testing.T) {
+	path := t.TempDir() + "/log"
+
+	l, err := OpenLog(path)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), l.Head())
+
+	changed := l.Changed()
+
+	seq, err := l.Append(models.OpUpload, 42)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), seq)
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("append does not notify waiters")
+	}
+
+	_, err = l.Append(models.OpDelete, 42)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	// Simulate torn write.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	l, err = OpenLog(path)
+	require.NoError(t, err)
+	defer l.Close()
+	require.Equal(t, uint64(2), l.Head())
+
+	change, err := l.Read(2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), change.Seq)
+	require.Equal(t, models.OpDelete, change.Op)
+	require.Equal(t, 42, change.ID)
+
+	_, err = l.Read(3)
+	require.ErrorIs(t, err, ErrSeqOutOfRange)
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	changes, err := OpenLog(dir + "/.replication/log")
+	require.NoError(t, err)
+	defer changes.Close()
+
+	tracks := storage.New(discardLog, dir, 2, 5)
+	jingles := storage.New(discardLog, dir+"/jingles", 2, 5)
+	require.NoError(t, tracks.Put(ctx, 1, bytes.NewReader([]byte("track"))))
+	require.NoError(t, jingles.Put(ctx, 1, bytes.NewReader([]byte("jingle"))))
+
+	primary := NewPrimary(discardLog, changes, map[string]FileStorage{models.DefaultNamespace: tracks})
+	require.NoError(t, primary.Seed(ctx))
+	require.Equal(t, uint64(2), changes.Head())
+
+	// Seeded namespace is not seeded again, new one is.
+	primary = NewPrimary(discardLog, changes, map[string]FileStorage{
+		models.DefaultNamespace: tracks,
+		"jingles":               jingles,
+	})
+	require.NoError(t, primary.Seed(ctx))
+	require.Equal(t, uint64(4), changes.Head())
+
+	change, err := changes.Read(3)
+	require.NoError(t, err)
+	require.Equal(t, "jingles", change.Namespace)
+
+	require.NoError(t, primary.Seed(ctx))
+	require.Equal(t, uint64(4), changes.Head())
+
+	// Log without seeded list is seeded
+	// for namespaces having records.
+	require.NoError(t, os.Remove(dir+"/.replication/"+seededName))
+	reopened, err := OpenLog(dir + "/.replication/log")
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.True(t, reopened.Seeded(models.DefaultNamespace))
+	require.True(t, reopened.Seeded("jingles"))
+	require.False(t, reopened.Seeded("ads"))
+}
+
+func TestReplication(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Primary.
+	primaryDir := t.TempDir()
+
+	changes, err := OpenLog(primaryDir + "/.replication/log")
+	require.NoError(t, err)
+	defer changes.Close()
+
+	primaryStorage := storage.New(discardLog, primaryDir, 2, 5, storage.WithJournal(changes))
+
+	// File stored before replication log existed is seeded.
+	require.NoError(t, primaryStorage.Put(ctx, 1, bytes.NewReader([]byte("seeded"))))
+
+	primary := NewPrimary(discardLog, changes, map[string]FileStorage{models.DefaultNamespace: primaryStorage})
+	require.NoError(t, primary.Seed(ctx))
+	defer primary.Close()
+
+	cc := servePrimary(t, primary)
+
+	// Replica.
+	replicaDir := t.TempDir()
+	replicaStorage := storage.New(discardLog, replicaDir, 2, 5, storage.WithReadOnly())
+
+	require.ErrorIs(t, replicaStorage.Delete(ctx, 1), service.ErrReadOnly)
+
+	newReplica := func() (*Replica, context.CancelFunc) {
+		replica, err := NewReplica(
+			discardLog,
+			"replica-1",
+			ssov1.NewReplicationServiceClient(cc),
+			map[string]Applier{models.DefaultNamespace: replicaStorage},
+			replicaDir+"/.replication/position",
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(ctx)
+		go replica.Run(ctx)
+
+		return replica, cancel
+	}
+
+	replica, stop := newReplica()
+
+	big := bytes.Repeat([]byte("0123456789"), bufferLen/5)
+	require.NoError(t, primaryStorage.Put(ctx, 2, bytes.NewReader(big)))
+	require.NoError(t, primaryStorage.Put(ctx, 3, bytes.NewReader([]byte("deleted later"))))
+	require.NoError(t, primaryStorage.Delete(ctx, 3))
+
+	waitApplied(t, replica, changes.Head())
+
+	requireContent(t, replicaStorage, 1, []byte("seeded"))
+	requireContent(t, replicaStorage, 2, big)
+	requireMissing(t, replicaStorage, 3)
+
+	// Replica resumes from persisted position.
+	stop()
+	require.NoError(t, primaryStorage.Delete(ctx, 1))
+
+	replica, stop = newReplica()
+	defer stop()
+	require.Equal(t, changes.Head()-1, replica.Applied())
+
+	waitApplied(t, replica, changes.Head())
+	requireMissing(t, replicaStorage, 1)
+}
+
+func TestReplicationNamespaces(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	primaryDir := t.TempDir()
+
+	changes, err := OpenLog(primaryDir + "/.replication/log")
+	require.NoError(t, err)
+	defer changes.Close()
+
+	jingles, err := changes.Journal("jingles")
+	require.NoError(t, err)
+
+	primaryDefault := storage.New(discardLog, primaryDir, 2, 5, storage.WithJournal(changes))
+	primaryJingles := storage.New(discardLog, primaryDir+"/jingles", 2, 5, storage.WithJournal(jingles))
+
+	primary := NewPrimary(discardLog, changes, map[string]FileStorage{
+		models.DefaultNamespace: primaryDefault,
+		"jingles":               primaryJingles,
+	})
+	defer primary.Close()
+
+	cc := servePrimary(t, primary)
+
+	replicaDir := t.TempDir()
+	replicaDefault := storage.New(discardLog, replicaDir, 2, 5, storage.WithReadOnly())
+	replicaJingles := storage.New(discardLog, replicaDir+"/jingles", 2, 5, storage.WithReadOnly())
+
+	replica, err := NewReplica(
+		discardLog,
+		"replica-1",
+		ssov1.NewReplicationServiceClient(cc),
+		map[string]Applier{
+			models.DefaultNamespace: replicaDefault,
+			"jingles":               replicaJingles,
+		},
+		replicaDir+"/.replication/position",
+	)
+	require.NoError(t, err)
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	go replica.Run(ctx)
+
+	// Same id in different namespaces.
+	require.NoError(t, primaryDefault.Put(ctx, 1, bytes.NewReader([]byte("track"))))
+	require.NoError(t, primaryJingles.Put(ctx, 1, bytes.NewReader([]byte("jingle"))))
+	require.NoError(t, primaryJingles.Put(ctx, 2, bytes.NewReader([]byte("deleted later"))))
+	require.NoError(t, primaryJingles.Delete(ctx, 2))
+
+	waitApplied(t, replica, changes.Head())
+
+	requireContent(t, replicaDefault, 1, []byte("track"))
+	requireContent(t, replicaJingles, 1, []byte("jingle"))
+	requireMissing(t, replicaJingles, 2)
+
+	// Namespaces keep their numbers in reopened log.
+	reopened, err := OpenLog(primaryDir + "/.replication/log")
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	change, err := reopened.Read(2)
+	require.NoError(t, err)
+	require.Equal(t, "jingles", change.Namespace)
+
+	change, err = reopened.Read(1)
+	require.NoError(t, err)
+	require.Equal(t, models.DefaultNamespace, change.Namespace)
+}
+
+func TestReplicationSidecar(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	primaryDir := t.TempDir()
+
+	changes, err := OpenLog(primaryDir + "/.replication/log")
+	require.NoError(t, err)
+	defer changes.Close()
+
+	primaryStorage := storage.New(discardLog, primaryDir, 2, 5, storage.WithJournal(changes))
+
+	// Attachment stored before replication log existed is seeded.
+	require.NoError(t, primaryStorage.Put(ctx, 1, bytes.NewReader([]byte("seeded"))))
+	_, err = primaryStorage.PutAttachment(ctx, 1, "notes.txt", []byte("seeded notes"))
+	require.NoError(t, err)
+
+	primary := NewPrimary(discardLog, changes, map[string]FileStorage{models.DefaultNamespace: primaryStorage})
+	require.NoError(t, primary.Seed(ctx))
+	defer primary.Close()
+
+	cc := servePrimary(t, primary)
+
+	replicaDir := t.TempDir()
+	replicaStorage := storage.New(discardLog, replicaDir, 2, 5, storage.WithReadOnly())
+
+	replica, err := NewReplica(
+		discardLog,
+		"replica-1",
+		ssov1.NewReplicationServiceClient(cc),
+		map[string]Applier{models.DefaultNamespace: replicaStorage},
+		replicaDir+"/.replication/position",
+	)
+	require.NoError(t, err)
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	go replica.Run(ctx)
+
+	require.NoError(t, primaryStorage.Put(ctx, 2, bytes.NewReader([]byte("track"))))
+	_, err = primaryStorage.PutAttachment(ctx, 2, "cover.txt", []byte("cover"))
+	require.NoError(t, err)
+	_, err = primaryStorage.PutAttachment(ctx, 2, "lyrics.txt", []byte("deleted later"))
+	require.NoError(t, err)
+	require.NoError(t, primaryStorage.DeleteAttachment(ctx, 2, "lyrics.txt"))
+	require.NoError(t, primaryStorage.SetLabels(ctx, 2, map[string]string{"type": "jingle"}))
+	require.NoError(t, primaryStorage.SetPinned(ctx, 2, true))
+
+	waitApplied(t, replica, changes.Head())
+
+	requireAttachment(t, replicaStorage, 1, "notes.txt", []byte("seeded notes"))
+	requireAttachment(t, replicaStorage, 2, "cover.txt", []byte("cover"))
+
+	list, err := replicaStorage.Attachments(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	found, err := replicaStorage.Find(ctx, models.FindQuery{Selector: "type=jingle"})
+	require.NoError(t, err)
+	require.Equal(t, []models.LabeledFile{{FileID: 2, Labels: map[string]string{"type": "jingle"}}}, found)
+
+	pinned, err := replicaStorage.Pinned(ctx, 2)
+	require.NoError(t, err)
+	require.True(t, pinned)
+}
+
+// servePrimary serves primary on in-memory connection.
+func servePrimary(t *testing.T, primary *Primary) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	storageGRPC.RegisterReplication(srv, primary, []string{"bufconn"})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { cc.Close() })
+
+	return cc
+}
+
+func waitApplied(t *testing.T, r *Replica, seq uint64) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return r.Applied() == seq
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func requireContent(t *testing.T, s *storage.Storage, id int, expected []byte) {
+	t.Helper()
+
+	f, err := s.Open(context.Background(), id)
+	require.NoError(t, err)
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, expected, data)
+}
+
+func requireMissing(t *testing.T, s *storage.Storage, id int) {
+	t.Helper()
+
+	_, err := s.Open(context.Background(), id)
+	require.True(t, errors.Is(err, service.ErrFileNotExist))
+}
+
+func requireAttachment(t *testing.T, s *storage.Storage, id int, name string, expected []byte) {
+	t.Helper()
+
+	a, err := s.Attachment(context.Background(), id, name)
+	require.NoError(t, err)
+	require.Equal(t, expected, a.Data)
+}
@@ -1,7 +1,75 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"strings"
+
+	"radio-storage/internal/domain/models"
+)
 
 var (
-	ErrFileNotExist = errors.New("file not exists")
+	ErrFileNotExist     = errors.New("file not exists")
+	ErrFileExists       = errors.New("file already exists")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrInvalidArchive   = errors.New("invalid archive")
+
+	ErrSnapshotNotExist    = errors.New("snapshot not exists")
+	ErrSnapshotExists      = errors.New("snapshot already exists")
+	ErrInvalidSnapshotName = errors.New("invalid snapshot name")
+
+	ErrReadOnly     = errors.New("storage is read-only")
+	ErrReplicaAhead = errors.New("replica is ahead of primary")
+
+	ErrInvalidSyncOptions = errors.New("invalid sync options")
+
+	ErrFormatNotAllowed = errors.New("file format is not allowed")
+	ErrInvalidFile      = errors.New("file is invalid")
+
+	ErrInvalidDownloadOptions = errors.New("invalid download options")
+	ErrConversionUnsupported  = errors.New("conversion is not supported for file format")
+	ErrDecodeFailed           = errors.New("failed to decode file")
+
+	ErrNotAnalyzed  = errors.New("file is not analyzed")
+	ErrUnknownStage = errors.New("unknown analysis stage")
+
+	ErrInvalidResolution = errors.New("invalid waveform resolution")
+	ErrInvalidCuePoints  = errors.New("invalid cue points")
+
+	ErrInvalidSequence    = errors.New("invalid sequence")
+	ErrSampleRateMismatch = errors.New("sample rates of files differ")
+
+	ErrSegmentNotExist = errors.New("segment not exists")
+
+	ErrInvalidSimilarQuery = errors.New("invalid similar query")
+
+	ErrArtworkNotExist    = errors.New("artwork not exists")
+	ErrInvalidArtworkSize = errors.New("invalid artwork size")
+
+	ErrAttachmentNotExist    = errors.New("attachment not exists")
+	ErrInvalidAttachmentName = errors.New("invalid attachment name")
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+
+	ErrInvalidLabels    = errors.New("invalid labels")
+	ErrInvalidFindQuery = errors.New("invalid find query")
+
+	ErrQuotaExceeded     = errors.New("quota exceeded")
+	ErrInsufficientSpace = errors.New("insufficient disk space")
 )
+
+// ValidationError lists rules violated by uploaded file.
+type ValidationError struct {
+	Violations []models.Violation
+}
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		descriptions = append(descriptions, v.Description)
+	}
+
+	return ErrInvalidFile.Error() + ": " + strings.Join(descriptions, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidFile
+}
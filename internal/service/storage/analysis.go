@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/service"
+)
+
+// audioBufferFrames is number of frames
+// passed to audio analyses at once.
+const audioBufferFrames = 4096
+
+// Analyzer runs analysis of stored files in background.
+type Analyzer interface {
+	Enqueue(id int)
+}
+
+// WithAnalyzer makes storage enqueue every
+// uploaded or replicated file for analysis.
+func WithAnalyzer(a Analyzer) Option {
+	return func(s *Storage) {
+		s.analyzer = a
+	}
+}
+
+// analysisEntry is sidecar entry with analysis result
+// of the file with given size and modification time.
+type analysisEntry[T any] struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Result  T         `json:"result"`
+}
+
+// readAnalysis reads analysis result of the file from sidecar.
+// Returns false if result is missing or outdated.
+func readAnalysis[T any](s *Storage, id int, name string, info os.FileInfo) (T, bool, error) {
+	var entry analysisEntry[T]
+	if err := s.readSidecar(id, name, &entry); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entry.Result, false, nil
+		}
+		return entry.Result, false, err
+	}
+
+	if entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		var zero T
+		return zero, false, nil
+	}
+
+	return entry.Result, true, nil
+}
+
+// analysisResult returns stored analysis result of the file.
+//
+// Returns service.ErrNotAnalyzed if file was not
+// analyzed since it was last changed.
+func analysisResult[T any](s *Storage, id int, name string) (T, error) {
+	var zero T
+
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		return zero, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return zero, err
+	}
+
+	res, ok, err := readAnalysis[T](s, id, name, info)
+	if err != nil {
+		return zero, err
+	}
+	if !ok {
+		return zero, service.ErrNotAnalyzed
+	}
+
+	return res, nil
+}
+
+// hasAnalysis reports whether up to date
+// analysis result of the file is stored.
+func hasAnalysis[T any](s *Storage, id int, name string) (bool, error) {
+	_, err := analysisResult[T](s, id, name)
+	if errors.Is(err, service.ErrNotAnalyzed) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// writeAnalysis writes analysis result of the file to sidecar.
+func writeAnalysis[T any](s *Storage, id int, name string, info os.FileInfo, result T) error {
+	return s.writeSidecar(id, name, analysisEntry[T]{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Result:  result,
+	})
+}
+
+// audioAnalysis analyzes decoded audio of the file.
+type audioAnalysis interface {
+	// start prepares analysis of audio in given format.
+	start(f pcm.Format) error
+	// Write adds interleaved 16-bit samples.
+	Write(samples []int16)
+	// store writes result of the file with given info to the sidecar.
+	store(s *Storage, id int, info os.FileInfo) error
+}
+
+// audioAnalyses create audio analyses by names of analyzer stages.
+var audioAnalyses = map[string]func() audioAnalysis{
+	"loudness":    func() audioAnalysis { return &loudnessAnalysis{} },
+	"waveform":    func() audioAnalysis { return &waveformAnalysis{} },
+	"cues":        func() audioAnalysis { return &cueAnalysis{} },
+	"fingerprint": func() audioAnalysis { return &fingerprintAnalysis{} },
+	"tempo_key":   func() audioAnalysis { return &tempoKeyAnalysis{} },
+}
+
+// AnalyzeAudio decodes the file once and runs named analyses
+// of its audio, storing their results in the sidecar.
+//
+// Analyses are named as analyzer stages: loudness,
+// waveform, cues, fingerprint and tempo_key.
+func (s *Storage) AnalyzeAudio(ctx context.Context, id int, names ...string) error {
+	const op = "Storage.AnalyzeAudio"
+
+	analyses := make([]audioAnalysis, 0, len(names))
+	for _, name := range names {
+		newAnalysis, ok := audioAnalyses[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown analysis %q", op, name)
+		}
+		analyses = append(analyses, newAnalysis())
+	}
+
+	return s.analyzeAudio(ctx, id, analyses...)
+}
+
+// analyzeAudio decodes the file and feeds its audio
+// to every analysis, then stores their results.
+func (s *Storage) analyzeAudio(ctx context.Context, id int, analyses ...audioAnalysis) error {
+	const op = "Storage.analyzeAudio"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if f != format.MP3 {
+		return service.ErrConversionUnsupported
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Error("failed to open file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	// Results are bound to the version of the file read.
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Analyses needing mono mix channels down
+	// themselves, so audio keeps actual channels.
+	r, pcmFormat, _, err := s.decodePCM(ctx, id, file, 0, 0)
+	if err != nil {
+		log.Warn("failed to decode file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, a := range analyses {
+		if err := a.start(pcmFormat); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	buf := make([]byte, audioBufferFrames*pcmFormat.FrameSize())
+	samples := make([]int16, audioBufferFrames*pcmFormat.Channels)
+	for {
+		n, err := io.ReadFull(r, buf)
+
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+		}
+		for _, a := range analyses {
+			a.Write(samples[:n])
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			log.Warn("failed to decode file", sl.Err(err))
+			return fmt.Errorf("%s: %w: %w", op, service.ErrDecodeFailed, err)
+		}
+	}
+
+	for _, a := range analyses {
+		if err := a.store(s, id, info); err != nil {
+			log.Error("failed to write analysis", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("analyzed audio", slog.Int("analyses", len(analyses)))
+
+	return nil
+}
+
+// enqueue passes file to analyzer, if any.
+func (s *Storage) enqueue(id int) {
+	if s.analyzer != nil {
+		s.analyzer.Enqueue(id)
+	}
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/artwork"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/service"
+)
+
+const (
+	artworkName = "artwork.json"
+	// artworkOriginal is cover art as it is embedded in the file.
+	artworkOriginal = "artwork"
+)
+
+// artworkInfo describes cover art extracted from the file.
+type artworkInfo struct {
+	// Found is false if file has no decodable cover art.
+	Found       bool   `json:"found"`
+	ContentType string `json:"content_type,omitempty"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+}
+
+// artworkFile returns name of cover art scaled to given size.
+func artworkFile(size int) string {
+	return "artwork-" + strconv.Itoa(size) + ".jpg"
+}
+
+// artworkFiles returns names of scaled cover art.
+func artworkFiles() []string {
+	names := make([]string, len(artwork.Sizes))
+	for i, size := range artwork.Sizes {
+		names[i] = artworkFile(size)
+	}
+	return names
+}
+
+// HasArtwork reports whether cover art of the file
+// was extracted since it was last changed.
+func (s *Storage) HasArtwork(ctx context.Context, id int) (bool, error) {
+	const op = "Storage.HasArtwork"
+
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return false, err
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, ok, err := readAnalysis[artworkInfo](s, id, artworkName, info)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
+
+// AnalyzeArtwork extracts cover art embedded in the file
+// and stores it in the sidecar along with its scaled copies.
+func (s *Storage) AnalyzeArtwork(ctx context.Context, id int) error {
+	const op = "Storage.AnalyzeArtwork"
+
+	if _, err := s.analyzeArtwork(id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Artwork returns cover art of the file scaled to fit a square
+// of given size, which is one of artwork.Sizes. Zero size
+// returns cover art as it is embedded in the file.
+//
+// Cover art is extracted if file was not analyzed yet.
+func (s *Storage) Artwork(ctx context.Context, id int, size int) (models.Artwork, error) {
+	const op = "Storage.Artwork"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+		slog.Int("size", size),
+	)
+
+	if size != 0 && !artwork.IsSize(size) {
+		log.Warn("invalid artwork size")
+		return models.Artwork{}, fmt.Errorf("%w: size must be one of %v", service.ErrInvalidArtworkSize, artwork.Sizes)
+	}
+
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return models.Artwork{}, err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return models.Artwork{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		log.Error("failed to probe file", sl.Err(err))
+		return models.Artwork{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a, ok, err := readAnalysis[artworkInfo](s, id, artworkName, info)
+	if err != nil {
+		log.Warn("failed to read artwork entry", sl.Err(err))
+	}
+	if !ok {
+		if a, err = s.analyzeArtwork(id); err != nil {
+			if errors.Is(err, service.ErrFileNotExist) {
+				return models.Artwork{}, err
+			}
+			if errors.Is(err, service.ErrConversionUnsupported) {
+				return models.Artwork{}, service.ErrArtworkNotExist
+			}
+			return models.Artwork{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if !a.Found {
+		return models.Artwork{}, service.ErrArtworkNotExist
+	}
+
+	res := models.Artwork{
+		ContentType: a.ContentType,
+		Width:       a.Width,
+		Height:      a.Height,
+	}
+
+	name := artworkOriginal
+	if size != 0 {
+		name = artworkFile(size)
+		res.ContentType = "image/jpeg"
+		res.Width, res.Height = artwork.Fit(a.Width, a.Height, size)
+	}
+
+	if res.Data, err = s.readSidecarFile(id, name); err != nil {
+		log.Error("failed to read artwork", sl.Err(err))
+		return models.Artwork{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (s *Storage) analyzeArtwork(id int) (artworkInfo, error) {
+	log := s.log.With(
+		slog.String("op", "Storage.analyzeArtwork"),
+		slog.Int("id", id),
+	)
+
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return artworkInfo{}, err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return artworkInfo{}, err
+	}
+
+	if f != format.MP3 {
+		return artworkInfo{}, service.ErrConversionUnsupported
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Error("failed to open file", sl.Err(err))
+		return artworkInfo{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return artworkInfo{}, err
+	}
+
+	tag, err := readID3v2(file)
+	if err != nil {
+		log.Error("failed to read tag", sl.Err(err))
+		return artworkInfo{}, err
+	}
+
+	var res artworkInfo
+	if picture, ok := coverPicture(tag); ok {
+		if res, err = s.writeArtwork(id, picture); err != nil {
+			if !errors.Is(err, artwork.ErrUnsupportedFormat) {
+				log.Error("failed to write artwork", sl.Err(err))
+				return artworkInfo{}, err
+			}
+			log.Warn("unsupported artwork format", slog.String("content_type", picture.MIME))
+		}
+	}
+	if !res.Found {
+		// Cover art may be removed since previous analysis.
+		for _, name := range append(artworkFiles(), artworkOriginal) {
+			if err := s.removeSidecarFile(id, name); err != nil {
+				log.Error("failed to remove stale artwork", sl.Err(err))
+				return artworkInfo{}, err
+			}
+		}
+	}
+
+	// Entry is written last, so it never
+	// points to missing artwork.
+	if err := writeAnalysis(s, id, artworkName, info, res); err != nil {
+		log.Error("failed to write artwork entry", sl.Err(err))
+		return artworkInfo{}, err
+	}
+
+	log.Debug("analyzed artwork", slog.Bool("found", res.Found))
+
+	return res, nil
+}
+
+// writeArtwork stores picture and its scaled copies in the sidecar.
+func (s *Storage) writeArtwork(id int, picture mp3.Picture) (artworkInfo, error) {
+	img, contentType, err := artwork.Decode(picture.Data)
+	if err != nil {
+		return artworkInfo{}, err
+	}
+
+	for _, size := range artwork.Sizes {
+		data, _, _, err := artwork.Scale(img, size)
+		if err != nil {
+			return artworkInfo{}, err
+		}
+		if err := s.writeSidecarFile(id, artworkFile(size), data); err != nil {
+			return artworkInfo{}, err
+		}
+	}
+	if err := s.writeSidecarFile(id, artworkOriginal, picture.Data); err != nil {
+		return artworkInfo{}, err
+	}
+
+	b := img.Bounds()
+	return artworkInfo{
+		Found:       true,
+		ContentType: contentType,
+		Width:       b.Dx(),
+		Height:      b.Dy(),
+	}, nil
+}
+
+// coverPicture returns front cover attached to ID3v2 tag,
+// or its first picture if there is no front cover.
+func coverPicture(tag []byte) (mp3.Picture, bool) {
+	if tag == nil {
+		return mp3.Picture{}, false
+	}
+
+	pictures, err := mp3.ID3v2Pictures(tag)
+	if err != nil || len(pictures) == 0 {
+		return mp3.Picture{}, false
+	}
+
+	for _, p := range pictures {
+		if p.Type == mp3.PictureFrontCover {
+			return p, true
+		}
+	}
+	return pictures[0], true
+}
+
+// readID3v2 reads complete ID3v2 tag at the start of r.
+// Returns nil if there is no tag.
+func readID3v2(r io.Reader) ([]byte, error) {
+	header := make([]byte, mp3.ID3v2HeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	size := mp3.ID3v2Size(header)
+	if size == 0 {
+		return nil, nil
+	}
+
+	tag := make([]byte, size)
+	copy(tag, header)
+	if _, err := io.ReadFull(r, tag[len(header):]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// stripArtwork returns reader of MP3 stream with pictures
+// dropped from its leading ID3v2 tag.
+//
+// Invalid tag is passed as is, it is not
+// worth failing download of the file.
+func stripArtwork(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(mp3.ID3v2HeaderLen)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return br, nil
+		}
+		return nil, err
+	}
+
+	size := mp3.ID3v2Size(header)
+	if size == 0 {
+		return br, nil
+	}
+
+	tag := make([]byte, size)
+	if n, err := io.ReadFull(br, tag); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return bytes.NewReader(tag[:n]), nil
+		}
+		return nil, err
+	}
+
+	stripped, err := mp3.StripID3v2Pictures(tag)
+	if err != nil {
+		stripped = tag
+	}
+
+	return io.MultiReader(bytes.NewReader(stripped), br), nil
+}
@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	// attachmentsDir is sidecar subdirectory with attachments.
+	attachmentsDir = "attachments"
+
+	maxAttachmentName = 128
+	// maxAttachmentSize keeps attachment with its
+	// metadata below default gRPC message size.
+	maxAttachmentSize = 2 << 20
+)
+
+// PutAttachment writes attachment of the file, replacing existing one.
+//
+// Attachments are kept in the sidecar, so they are removed
+// along with the file and carried by snapshots, backups,
+// replication and sync.
+func (s *Storage) PutAttachment(ctx context.Context, id int, name string, data []byte) (models.Attachment, error) {
+	const op = "Storage.PutAttachment"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+		slog.String("name", name),
+	)
+
+	if s.readOnly {
+		log.Warn("put attachment on read-only storage")
+		return models.Attachment{}, service.ErrReadOnly
+	}
+
+	if err := checkAttachmentName(name); err != nil {
+		log.Warn("invalid attachment name", sl.Err(err))
+		return models.Attachment{}, err
+	}
+	if len(data) > maxAttachmentSize {
+		log.Warn("attachment is too large", slog.Int("size", len(data)))
+		return models.Attachment{}, fmt.Errorf("%w: size must be up to %d bytes", service.ErrAttachmentTooLarge, maxAttachmentSize)
+	}
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check existing id", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("file not exists")
+		return models.Attachment{}, service.ErrFileNotExist
+	}
+
+	// Attachment counts in usage of client uploaded the file.
+	client, err := s.readClient(id)
+	if err != nil {
+		log.Error("failed to read client", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	adm := s.admission(client, 0)
+	defer adm.release()
+	if err := adm.reserve(ctx, int64(len(data))); err != nil {
+		if exhausted(err) {
+			log.Warn("attachment rejected", sl.Err(err))
+			return models.Attachment{}, err
+		}
+		log.Error("failed to check quota", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.writeSidecarFile(id, attachmentsDir+"/"+name, data); err != nil {
+		log.Error("failed to write attachment", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.record(models.OpSidecar, id); err != nil {
+		log.Error("failed to record attachment", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.statAttachment(id, name)
+	if err != nil {
+		log.Error("failed to probe attachment", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("put attachment", slog.Int64("size", res.Size))
+
+	return res, nil
+}
+
+// Attachment returns attachment of the file with its data.
+func (s *Storage) Attachment(ctx context.Context, id int, name string) (models.Attachment, error) {
+	const op = "Storage.Attachment"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+		slog.String("name", name),
+	)
+
+	if err := checkAttachmentName(name); err != nil {
+		log.Warn("invalid attachment name", sl.Err(err))
+		return models.Attachment{}, err
+	}
+
+	if err := s.checkAttachmentFile(id); err != nil {
+		return models.Attachment{}, err
+	}
+
+	res, err := s.statAttachment(id, name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warn("attachment not exists")
+			return models.Attachment{}, service.ErrAttachmentNotExist
+		}
+		log.Error("failed to probe attachment", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if res.Data, err = s.readSidecarFile(id, attachmentsDir+"/"+name); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warn("attachment not exists")
+			return models.Attachment{}, service.ErrAttachmentNotExist
+		}
+		log.Error("failed to read attachment", sl.Err(err))
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	res.Size = int64(len(res.Data))
+
+	return res, nil
+}
+
+// Attachments returns attachments of the file
+// without their data, sorted by name.
+func (s *Storage) Attachments(ctx context.Context, id int) ([]models.Attachment, error) {
+	const op = "Storage.Attachments"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if err := s.checkAttachmentFile(id); err != nil {
+		return nil, err
+	}
+
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entries, err := os.ReadDir(dir + "/" + attachmentsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		log.Error("failed to read attachments", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := make([]models.Attachment, 0, len(entries))
+	for _, entry := range entries {
+		// Temporary files of unfinished writes.
+		if !entry.Type().IsRegular() || checkAttachmentName(entry.Name()) != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			log.Error("failed to probe attachment", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		res = append(res, attachmentFromInfo(info))
+	}
+
+	slices.SortFunc(res, func(a, b models.Attachment) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return res, nil
+}
+
+// DeleteAttachment deletes attachment of the file.
+func (s *Storage) DeleteAttachment(ctx context.Context, id int, name string) error {
+	const op = "Storage.DeleteAttachment"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+		slog.String("name", name),
+	)
+
+	if s.readOnly {
+		log.Warn("delete attachment from read-only storage")
+		return service.ErrReadOnly
+	}
+
+	if err := checkAttachmentName(name); err != nil {
+		log.Warn("invalid attachment name", sl.Err(err))
+		return err
+	}
+
+	if err := s.checkAttachmentFile(id); err != nil {
+		return err
+	}
+
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	file := attachmentsDir + "/" + name
+	if err := s.accountSidecar(id, file, func() error {
+		return os.Remove(dir + "/" + file)
+	}); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warn("attachment not exists")
+			return service.ErrAttachmentNotExist
+		}
+		log.Error("failed to delete attachment", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.record(models.OpSidecar, id); err != nil {
+		log.Error("failed to record attachment", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("deleted attachment")
+
+	return nil
+}
+
+// checkAttachmentFile checks that the file attachments belong to exists.
+func (s *Storage) checkAttachmentFile(id int) error {
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.log.Warn("file not exists", slog.String("op", "Storage.checkAttachmentFile"), slog.Int("id", id))
+		return service.ErrFileNotExist
+	}
+
+	return nil
+}
+
+// statAttachment returns attachment of the file without data.
+func (s *Storage) statAttachment(id int, name string) (models.Attachment, error) {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	info, err := os.Stat(dir + "/" + attachmentsDir + "/" + name)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	return attachmentFromInfo(info), nil
+}
+
+func attachmentFromInfo(info os.FileInfo) models.Attachment {
+	contentType := mime.TypeByExtension(filepath.Ext(info.Name()))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return models.Attachment{
+		Name:        info.Name(),
+		ContentType: contentType,
+		Size:        info.Size(),
+		ModifiedAt:  info.ModTime(),
+	}
+}
+
+// checkAttachmentName validates attachment name, which
+// is used as file name, so it can not escape the sidecar.
+func checkAttachmentName(name string) error {
+	if name == "" || len(name) > maxAttachmentName {
+		return fmt.Errorf("%w: name must be from 1 to %d characters", service.ErrInvalidAttachmentName, maxAttachmentName)
+	}
+	if name[0] == '.' {
+		return fmt.Errorf("%w: name must not start with '.'", service.ErrInvalidAttachmentName)
+	}
+
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == '-':
+		default:
+			return fmt.Errorf("%w: name must consist of letters, digits, '.', '_' and '-'", service.ErrInvalidAttachmentName)
+		}
+	}
+
+	return nil
+}
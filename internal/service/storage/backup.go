@@ -0,0 +1,585 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	// backupVersion 3 puts manifest last and checksum after
+	// every entry, earlier versions start with manifest.
+	backupVersion      = 3
+	backupManifestName = "manifest.json"
+	backupFilesDir     = "files"
+	// backupSidecarsDir holds sidecar archives named by file id,
+	// each follows its file. Version 1 archives have none.
+	backupSidecarsDir = "sidecars"
+	// backupSumSuffix names entry with hex encoded
+	// sha256 of preceding entry.
+	backupSumSuffix = ".sha256"
+)
+
+// ConflictPolicy defines restore behaviour
+// for files already present in the storage.
+type ConflictPolicy int
+
+const (
+	ConflictSkip ConflictPolicy = iota
+	ConflictOverwrite
+	ConflictFail
+)
+
+// ParseConflictPolicy parses policy name.
+func ParseConflictPolicy(str string) (ConflictPolicy, error) {
+	switch str {
+	case "skip":
+		return ConflictSkip, nil
+	case "overwrite":
+		return ConflictOverwrite, nil
+	case "fail":
+		return ConflictFail, nil
+	default:
+		return 0, fmt.Errorf("unknown conflict policy %q", str)
+	}
+}
+
+// Backup writes tar archive with selected files to w.
+//
+// Files are streamed as they are read, every one followed by
+// its checksum, so changes made meanwhile never break the archive.
+// Files deleted meanwhile are skipped. Archive ends with manifest
+// containing ids, sizes and checksums of all files, so it can be
+// verified on restore.
+func (s *Storage) Backup(ctx context.Context, w io.Writer, filter models.BackupFilter) (models.BackupManifest, error) {
+	const op = "Storage.Backup"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	manifest := models.BackupManifest{
+		Version:   backupVersion,
+		CreatedAt: time.Now().UTC(),
+		Filter:    filter,
+		Files:     make([]models.BackupManifestFile, 0),
+	}
+
+	tw := tar.NewWriter(w)
+
+	if err := s.walk(ctx, func(id int, filename string) error {
+		if id < filter.FromID || (filter.ToID > 0 && id > filter.ToID) {
+			return nil
+		}
+
+		file, ok, err := s.writeBackupFile(tw, id, filename, filter.Since)
+		if err != nil {
+			return err
+		}
+		if ok {
+			manifest.Files = append(manifest.Files, file)
+		}
+
+		return nil
+	}); err != nil {
+		log.Error("failed to write files", sl.Err(err))
+		return models.BackupManifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return models.BackupManifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := writeBackupEntry(tw, backupManifestName, manifest.CreatedAt, manifestData); err != nil {
+		return models.BackupManifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return models.BackupManifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("backup finished", slog.Int("count", len(manifest.Files)))
+
+	return manifest, nil
+}
+
+// writeBackupFile writes the file and its sidecar archive along
+// with their checksums. Returns false if the file is gone or
+// not changed since given time.
+func (s *Storage) writeBackupFile(tw *tar.Writer, id int, filename string, since time.Time) (models.BackupManifestFile, bool, error) {
+	// Stored files are replaced by rename, so opened
+	// file keeps its content until it is closed.
+	f, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return models.BackupManifestFile{}, false, nil
+		}
+		return models.BackupManifestFile{}, false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+	if !since.IsZero() && info.ModTime().Before(since) {
+		return models.BackupManifestFile{}, false, nil
+	}
+
+	file := models.BackupManifestFile{
+		ID:   id,
+		Name: path.Base(filename),
+		Size: info.Size(),
+	}
+
+	name := backupFilesDir + "/" + file.Name
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    file.Size,
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+
+	h := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(tw, h), f, file.Size); err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+	file.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if err := writeBackupEntry(tw, name+backupSumSuffix, info.ModTime(), []byte(file.SHA256)); err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+
+	names, err := s.persistentFiles(id)
+	if err != nil || len(names) == 0 {
+		return file, true, err
+	}
+
+	var sidecar bytes.Buffer
+	if err := s.writeSidecarArchive(id, &sidecar); err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+	sum := sha256.Sum256(sidecar.Bytes())
+	file.SidecarSize = int64(sidecar.Len())
+	file.SidecarSHA256 = hex.EncodeToString(sum[:])
+
+	name = sidecarEntry(id)
+	if err := writeBackupEntry(tw, name, info.ModTime(), sidecar.Bytes()); err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+	if err := writeBackupEntry(tw, name+backupSumSuffix, info.ModTime(), []byte(file.SidecarSHA256)); err != nil {
+		return models.BackupManifestFile{}, false, err
+	}
+
+	return file, true, nil
+}
+
+// writeBackupEntry writes archive entry with given content.
+func writeBackupEntry(tw *tar.Writer, name string, modTime time.Time, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+
+	return err
+}
+
+// sidecarEntry returns name of sidecar archive of the file in backup.
+func sidecarEntry(id int) string {
+	return backupSidecarsDir + "/" + strconv.Itoa(id) + ".tar"
+}
+
+// Restore reads archive produced by Backup
+// and places files back to the storage.
+//
+// Every file is verified against its checksum
+// before it becomes visible.
+func (s *Storage) Restore(ctx context.Context, r io.Reader, policy ConflictPolicy) (models.RestoreStats, error) {
+	const op = "Storage.Restore"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	tr := tar.NewReader(r)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		log.Error("failed to read archive", sl.Err(err))
+		return models.RestoreStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Archives before version 3 start with manifest.
+	restore := s.restoreStream
+	if hdr.Name == backupManifestName {
+		restore = s.restoreManifestFirst
+	}
+
+	stats, err := restore(ctx, tr, hdr, policy)
+	if err != nil {
+		log.Error("failed to restore archive", sl.Err(err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("restore finished", slog.Int("restored", stats.Restored), slog.Int("skipped", stats.Skipped))
+
+	return stats, nil
+}
+
+// restoreStream restores archive, every entry of which
+// is followed by its checksum, ending with manifest.
+func (s *Storage) restoreStream(ctx context.Context, tr *tar.Reader, hdr *tar.Header, policy ConflictPolicy) (models.RestoreStats, error) {
+	var stats models.RestoreStats
+
+	// Files met in the archive, checked against manifest.
+	seen := make(map[int]models.BackupManifestFile)
+	restoredIDs := make(map[int]bool)
+
+	for ; ; hdr = nil {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		if hdr == nil {
+			var err error
+			hdr, err = tr.Next()
+			if errors.Is(err, io.EOF) {
+				// Manifest is written last.
+				return stats, fmt.Errorf("%w: archive is truncated", service.ErrInvalidArchive)
+			}
+			if err != nil {
+				return stats, err
+			}
+		}
+
+		switch {
+		case hdr.Name == backupManifestName:
+			var manifest models.BackupManifest
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return stats, fmt.Errorf("%w: %w", service.ErrInvalidArchive, err)
+			}
+			if manifest.Version != backupVersion || len(manifest.Files) != len(seen) {
+				return stats, fmt.Errorf("%w: manifest does not match archive", service.ErrInvalidArchive)
+			}
+			for _, file := range manifest.Files {
+				if seen[file.ID] != file {
+					return stats, fmt.Errorf("%w: manifest does not match file %d", service.ErrInvalidArchive, file.ID)
+				}
+			}
+			if _, err := tr.Next(); !errors.Is(err, io.EOF) {
+				return stats, fmt.Errorf("%w: entries after manifest", service.ErrInvalidArchive)
+			}
+			return stats, nil
+
+		case strings.HasPrefix(hdr.Name, backupFilesDir+"/"):
+			name := strings.TrimPrefix(hdr.Name, backupFilesDir+"/")
+			id, _, ok := parseFilename(name)
+			if !ok {
+				return stats, fmt.Errorf("%w: unexpected entry %q", service.ErrInvalidArchive, hdr.Name)
+			}
+			if _, ok := seen[id]; ok {
+				return stats, fmt.Errorf("%w: duplicate file %d", service.ErrInvalidArchive, id)
+			}
+			file := models.BackupManifestFile{ID: id, Name: name, Size: hdr.Size}
+
+			entry := hdr.Name
+			verify := func(sum string) error {
+				file.SHA256 = sum
+				return readBackupSum(tr, entry, sum)
+			}
+
+			restored, err := s.restoreFile(tr, file, hdr.ModTime, policy, verify)
+			if err != nil {
+				return stats, err
+			}
+			if !restored {
+				// Skipped file is still checked against manifest.
+				h := sha256.New()
+				if _, err := io.Copy(h, tr); err != nil {
+					return stats, err
+				}
+				if err := verify(hex.EncodeToString(h.Sum(nil))); err != nil {
+					return stats, err
+				}
+				stats.Skipped++
+			} else {
+				stats.Restored++
+			}
+
+			seen[id] = file
+			restoredIDs[id] = restored
+
+		case strings.HasPrefix(hdr.Name, backupSidecarsDir+"/"):
+			id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(hdr.Name, backupSidecarsDir+"/"), ".tar"))
+			file, ok := seen[id]
+			if err != nil || !ok || hdr.Name != sidecarEntry(id) || file.SidecarSHA256 != "" {
+				return stats, fmt.Errorf("%w: unexpected entry %q", service.ErrInvalidArchive, hdr.Name)
+			}
+
+			var sidecar bytes.Buffer
+			h := sha256.New()
+			if _, err := io.Copy(io.MultiWriter(&sidecar, h), tr); err != nil {
+				return stats, err
+			}
+			file.SidecarSize = int64(sidecar.Len())
+			file.SidecarSHA256 = hex.EncodeToString(h.Sum(nil))
+			if err := readBackupSum(tr, hdr.Name, file.SidecarSHA256); err != nil {
+				return stats, err
+			}
+			seen[id] = file
+
+			// Sidecars of skipped files are skipped too.
+			if restoredIDs[id] {
+				if err := s.restoreBackupSidecar(&sidecar, id); err != nil {
+					return stats, err
+				}
+			}
+
+		default:
+			return stats, fmt.Errorf("%w: unexpected entry %q", service.ErrInvalidArchive, hdr.Name)
+		}
+	}
+}
+
+// readBackupSum reads checksum entry following entry
+// of given name and compares it with sum of its content.
+func readBackupSum(tr *tar.Reader, name, sum string) error {
+	hdr, err := tr.Next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: archive is truncated", service.ErrInvalidArchive)
+		}
+		return err
+	}
+	if hdr.Name != name+backupSumSuffix {
+		return fmt.Errorf("%w: missing checksum of %q", service.ErrInvalidArchive, name)
+	}
+
+	expected, err := io.ReadAll(io.LimitReader(tr, sha256.Size*2+1))
+	if err != nil {
+		return err
+	}
+	if string(expected) != sum {
+		return service.ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// restoreManifestFirst restores archive of version 1 or 2,
+// which starts with manifest given by hdr.
+func (s *Storage) restoreManifestFirst(ctx context.Context, tr *tar.Reader, hdr *tar.Header, policy ConflictPolicy) (models.RestoreStats, error) {
+	var stats models.RestoreStats
+
+	var manifest models.BackupManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return stats, err
+	}
+	if manifest.Version < 1 || manifest.Version > 2 {
+		return stats, fmt.Errorf("%w: unsupported manifest version %d", service.ErrInvalidArchive, manifest.Version)
+	}
+
+	files := make(map[string]models.BackupManifestFile, len(manifest.Files))
+	sidecars := make(map[string]models.BackupManifestFile)
+	for _, file := range manifest.Files {
+		files[backupFilesDir+"/"+file.Name] = file
+		if file.SidecarSHA256 != "" {
+			sidecars[sidecarEntry(file.ID)] = file
+		}
+	}
+	// Sidecars of skipped files are skipped too.
+	restoredIDs := make(map[int]bool, len(files))
+
+	s.log.Info("restoring archive", slog.Int("count", len(files)), slog.Time("created_at", manifest.CreatedAt))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return stats, err
+		}
+
+		if file, ok := sidecars[hdr.Name]; ok {
+			delete(sidecars, hdr.Name)
+
+			if !restoredIDs[file.ID] {
+				continue
+			}
+
+			var sidecar bytes.Buffer
+			h := sha256.New()
+			if _, err := io.Copy(io.MultiWriter(&sidecar, h), tr); err != nil {
+				return stats, err
+			}
+			if int64(sidecar.Len()) != file.SidecarSize || hex.EncodeToString(h.Sum(nil)) != file.SidecarSHA256 {
+				return stats, service.ErrChecksumMismatch
+			}
+			if err := s.restoreBackupSidecar(&sidecar, file.ID); err != nil {
+				return stats, err
+			}
+			continue
+		}
+
+		file, ok := files[hdr.Name]
+		if !ok {
+			return stats, fmt.Errorf("%w: file %q is not listed in manifest", service.ErrInvalidArchive, hdr.Name)
+		}
+		delete(files, hdr.Name)
+
+		restored, err := s.restoreFile(tr, file, hdr.ModTime, policy, func(sum string) error {
+			if sum != file.SHA256 {
+				return service.ErrChecksumMismatch
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+		restoredIDs[file.ID] = restored
+
+		if restored {
+			stats.Restored++
+		} else {
+			stats.Skipped++
+		}
+	}
+
+	if len(files) != 0 || len(sidecars) != 0 {
+		return stats, fmt.Errorf("%w: archive is truncated, %d entries missing", service.ErrInvalidArchive, len(files)+len(sidecars))
+	}
+
+	return stats, nil
+}
+
+// restoreFile writes single archive entry to the storage,
+// verify checks checksum of its content before it is visible.
+// Returns false if file was skipped due to conflict policy,
+// its content is not read then.
+func (s *Storage) restoreFile(r io.Reader, file models.BackupManifestFile, modTime time.Time, policy ConflictPolicy, verify func(sum string) error) (bool, error) {
+	id, f, ok := parseFilename(file.Name)
+	if !ok || id != file.ID {
+		return false, service.ErrInvalidArchive
+	}
+
+	exists, err := s.checkExistingID(file.ID)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		switch policy {
+		case ConflictSkip:
+			return false, nil
+		case ConflictFail:
+			return false, service.ErrFileExists
+		}
+	}
+
+	dir, err := s.getCorrespondingDir(file.ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Write to temporary file and move it
+	// in place only after verification.
+	tmp, err := os.CreateTemp(dir, ".restore-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return false, err
+	}
+	if n != file.Size {
+		return false, service.ErrChecksumMismatch
+	}
+	if err := verify(hex.EncodeToString(h.Sum(nil))); err != nil {
+		return false, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return false, err
+	}
+	filename, err := s.replaceFile(tmp.Name(), file.ID, f)
+	if err != nil {
+		return false, err
+	}
+	if err := s.reloadFingerprint(file.ID, filename); err != nil {
+		s.log.Warn("failed to reload fingerprint", slog.Int("id", file.ID), sl.Err(err))
+	}
+
+	// Sidecar state of replaced file is dropped,
+	// archived one follows the file if there is any.
+	if err := s.clearSidecar(file.ID); err != nil {
+		return false, err
+	}
+
+	if err := s.record(models.OpUpload, file.ID); err != nil {
+		return false, err
+	}
+	if err := s.record(models.OpSidecar, file.ID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// restoreBackupSidecar writes verified sidecar archive of the file.
+func (s *Storage) restoreBackupSidecar(r io.Reader, id int) error {
+	if err := s.readSidecarArchive(id, r); err != nil {
+		return err
+	}
+
+	return s.record(models.OpSidecar, id)
+}
+
+// fileChecksum returns hex encoded sha256 of the file.
+func fileChecksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
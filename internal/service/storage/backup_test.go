@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	return New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		t.TempDir(),
+		2,
+		5,
+	)
+}
+
+func putTestFile(t *testing.T, s *Storage, id int, data []byte) {
+	t.Helper()
+
+	dir, err := s.getCorrespondingDir(id)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dir+"/"+strconv.Itoa(id)+".mp3", data, 0644))
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+
+	src := newTestStorage(t)
+	files := map[int][]byte{
+		7:     []byte("seven"),
+		1234:  []byte("one two three four"),
+		99999: []byte("last"),
+	}
+	for id, data := range files {
+		putTestFile(t, src, id, data)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := src.Backup(ctx, &archive, models.BackupFilter{ToID: 50000})
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 2)
+	require.Equal(t, 7, manifest.Files[0].ID)
+	require.Equal(t, 1234, manifest.Files[1].ID)
+
+	dst := newTestStorage(t)
+	putTestFile(t, dst, 7, []byte("other"))
+
+	// Fail policy stops on existing file.
+	_, err = dst.Restore(ctx, bytes.NewReader(archive.Bytes()), ConflictFail)
+	require.ErrorIs(t, err, service.ErrFileExists)
+
+	// Skip policy keeps existing file.
+	stats, err := dst.Restore(ctx, bytes.NewReader(archive.Bytes()), ConflictSkip)
+	require.NoError(t, err)
+	require.Equal(t, models.RestoreStats{Restored: 1, Skipped: 1}, stats)
+
+	dir, err := dst.getCorrespondingDir(7)
+	require.NoError(t, err)
+	data, err := os.ReadFile(dir + "/7.mp3")
+	require.NoError(t, err)
+	require.Equal(t, []byte("other"), data)
+
+	// Overwrite policy replaces it.
+	stats, err = dst.Restore(ctx, bytes.NewReader(archive.Bytes()), ConflictOverwrite)
+	require.NoError(t, err)
+	require.Equal(t, models.RestoreStats{Restored: 2}, stats)
+
+	for _, id := range []int{7, 1234} {
+		dir, err := dst.getCorrespondingDir(id)
+		require.NoError(t, err)
+		data, err := os.ReadFile(dir + "/" + strconv.Itoa(id) + ".mp3")
+		require.NoError(t, err)
+		require.Equal(t, files[id], data)
+	}
+}
+
+func TestRestoreCorrupted(t *testing.T) {
+	ctx := context.Background()
+
+	src := newTestStorage(t)
+	putTestFile(t, src, 42, []byte("original content"))
+
+	var archive bytes.Buffer
+	_, err := src.Backup(ctx, &archive, models.BackupFilter{})
+	require.NoError(t, err)
+
+	// Rebuild archive with tampered file content.
+	var tampered bytes.Buffer
+	tr := tar.NewReader(&archive)
+	tw := tar.NewWriter(&tampered)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		if strings.HasPrefix(hdr.Name, backupFilesDir+"/") && !strings.HasSuffix(hdr.Name, backupSumSuffix) {
+			data = []byte("tampered content")
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	dst := newTestStorage(t)
+	_, err = dst.Restore(ctx, &tampered, ConflictFail)
+	require.ErrorIs(t, err, service.ErrChecksumMismatch)
+
+	ok, err := dst.checkExistingID(42)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestRestoreTruncated(t *testing.T) {
+	ctx := context.Background()
+
+	src := newTestStorage(t)
+	putTestFile(t, src, 42, []byte("original content"))
+
+	var archive bytes.Buffer
+	_, err := src.Backup(ctx, &archive, models.BackupFilter{})
+	require.NoError(t, err)
+
+	// Drop manifest, which is the last entry.
+	var truncated bytes.Buffer
+	tr := tar.NewReader(&archive)
+	tw := tar.NewWriter(&truncated)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Name == backupManifestName {
+			continue
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = io.Copy(tw, tr)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	dst := newTestStorage(t)
+	_, err = dst.Restore(ctx, &truncated, ConflictFail)
+	require.ErrorIs(t, err, service.ErrInvalidArchive)
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/cue"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/service"
+)
+
+const (
+	cuesName        = "cues.json"
+	cueOverrideName = "cues-override.json"
+)
+
+// HasCuePoints reports whether up to date
+// detected cue points of the file are stored.
+func (s *Storage) HasCuePoints(ctx context.Context, id int) (bool, error) {
+	const op = "Storage.HasCuePoints"
+
+	ok, err := hasAnalysis[models.CuePoints](s, id, cuesName)
+	if err != nil && !errors.Is(err, service.ErrFileNotExist) {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, err
+}
+
+// CuePoints returns detected and manually set cue points of the file.
+//
+// Cue points are detected if they are not stored yet.
+func (s *Storage) CuePoints(ctx context.Context, id int) (models.Cues, error) {
+	const op = "Storage.CuePoints"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return models.Cues{}, err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return models.Cues{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if f != format.MP3 {
+		return models.Cues{}, service.ErrConversionUnsupported
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		log.Error("failed to probe file", sl.Err(err))
+		return models.Cues{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var cues models.Cues
+
+	detected, ok, err := readAnalysis[models.CuePoints](s, id, cuesName, info)
+	if err != nil {
+		log.Warn("failed to read cue points", sl.Err(err))
+	}
+	if !ok {
+		if detected, err = s.AnalyzeCuePoints(ctx, id); err != nil {
+			return models.Cues{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	cues.Detected = detected
+
+	if err := s.readSidecar(id, cueOverrideName, &cues.Override); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Error("failed to read cue override", sl.Err(err))
+		return models.Cues{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cues, nil
+}
+
+// SetCueOverride replaces manually set cue points of the file.
+// Empty override restores detected cue points.
+//
+// Override is kept until file is deleted, even if it changes.
+func (s *Storage) SetCueOverride(ctx context.Context, id int, override models.CueOverride) (models.Cues, error) {
+	const op = "Storage.SetCueOverride"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if s.readOnly {
+		log.Warn("set cue points on read-only storage")
+		return models.Cues{}, service.ErrReadOnly
+	}
+
+	for _, d := range []*time.Duration{override.Start, override.End, override.FadeOut, override.IntroEnd} {
+		if d != nil && *d < 0 {
+			return models.Cues{}, fmt.Errorf("%w: negative position", service.ErrInvalidCuePoints)
+		}
+	}
+
+	cues, err := s.CuePoints(ctx, id)
+	if err != nil {
+		return models.Cues{}, err
+	}
+
+	cues.Override = override
+	if effective := cues.Effective(); effective.Start > effective.End {
+		return models.Cues{}, fmt.Errorf("%w: start is after end", service.ErrInvalidCuePoints)
+	}
+
+	if override.IsZero() {
+		err = s.removeSidecarFile(id, cueOverrideName)
+	} else {
+		err = s.writeSidecar(id, cueOverrideName, override)
+	}
+	if err != nil {
+		log.Error("failed to write cue override", sl.Err(err))
+		return models.Cues{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("cue points overridden", slog.Bool("reset", override.IsZero()))
+
+	return cues, nil
+}
+
+// AnalyzeCuePoints decodes the file, detects
+// its cue points and stores them in the sidecar.
+func (s *Storage) AnalyzeCuePoints(ctx context.Context, id int) (models.CuePoints, error) {
+	a := &cueAnalysis{}
+	if err := s.analyzeAudio(ctx, id, a); err != nil {
+		return models.CuePoints{}, err
+	}
+
+	return a.res, nil
+}
+
+// cueAnalysis detects cue points of the file.
+type cueAnalysis struct {
+	*cue.Detector
+	res models.CuePoints
+}
+
+func (a *cueAnalysis) start(f pcm.Format) (err error) {
+	a.Detector, err = cue.NewDetector(f.SampleRate, f.Channels)
+	return err
+}
+
+func (a *cueAnalysis) store(s *Storage, id int, info os.FileInfo) error {
+	p := a.Points()
+	a.res = models.CuePoints{
+		Start:    p.Start,
+		End:      p.End,
+		FadeOut:  p.FadeOut,
+		IntroEnd: p.IntroEnd,
+	}
+
+	return writeAnalysis(s, id, cuesName, info, a.res)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	gomp3 "github.com/hajimehoshi/go-mp3"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/service"
+)
+
+// decodedRates are sample rates decoded audio
+// may be resampled to.
+var decodedRates = []int{44100, 48000}
+
+// checkDownloadOptions validates download options.
+func checkDownloadOptions(opts models.DownloadOptions) error {
+	switch {
+	case opts.Output < models.OutputOriginal || opts.Output > models.OutputPCM:
+		return fmt.Errorf("%w: unknown output format", service.ErrInvalidDownloadOptions)
+	case opts.SampleRate != 0 && !slices.Contains(decodedRates, opts.SampleRate):
+		return fmt.Errorf("%w: sample rate must be one of %v", service.ErrInvalidDownloadOptions, decodedRates)
+	case opts.Channels < 0 || opts.Channels > 2:
+		return fmt.Errorf("%w: channels must be 1 or 2", service.ErrInvalidDownloadOptions)
+	case opts.Output == models.OutputOriginal && (opts.SampleRate != 0 || opts.Channels != 0):
+		return fmt.Errorf("%w: original output can not be converted", service.ErrInvalidDownloadOptions)
+	case opts.StripArtwork && opts.Output != models.OutputOriginal:
+		return fmt.Errorf("%w: artwork is stripped from original output only", service.ErrInvalidDownloadOptions)
+	case opts.TagMode < models.TagsKeep || opts.TagMode > models.TagsReplace:
+		return fmt.Errorf("%w: unknown tag mode", service.ErrInvalidDownloadOptions)
+	case opts.TagMode != models.TagsKeep && opts.Output != models.OutputOriginal:
+		return fmt.Errorf("%w: tags are rewritten in original output only", service.ErrInvalidDownloadOptions)
+	case opts.TagMode != models.TagsReplace && opts.Tags != (models.Tags{}):
+		return fmt.Errorf("%w: tags are given to replace ones of the file only", service.ErrInvalidDownloadOptions)
+	}
+
+	return nil
+}
+
+// decode returns reader of decoded MP3 file
+// in requested output format and its content type.
+func (s *Storage) decode(ctx context.Context, id int, r io.ReadSeeker, opts models.DownloadOptions) (io.Reader, string, error) {
+	conv, to, frames, err := s.decodePCM(ctx, id, r, opts.SampleRate, opts.Channels)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.Output == models.OutputPCM {
+		return conv, to.ContentType(), nil
+	}
+
+	header, err := pcm.WAVHeader(to, frames)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", service.ErrDecodeFailed, err)
+	}
+
+	return io.MultiReader(bytes.NewReader(header), conv), format.WAV.ContentType, nil
+}
+
+// decodePCM returns reader of decoded MP3 file in PCM format
+// with given sample rate and channels, zero keeps ones of the file.
+// Number of frames the reader produces is returned too.
+func (s *Storage) decodePCM(ctx context.Context, id int, r io.ReadSeeker, sampleRate, channels int) (io.Reader, pcm.Format, int64, error) {
+	// Decoder always produces stereo,
+	// so keep actual channels of the file.
+	if channels == 0 {
+		channels = 2
+		if metadata, err := s.Metadata(ctx, id); err == nil && metadata.Channels == 1 {
+			channels = 1
+		}
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, pcm.Format{}, 0, err
+	}
+
+	dec, err := gomp3.NewDecoder(r)
+	if err != nil {
+		return nil, pcm.Format{}, 0, fmt.Errorf("%w: %w", service.ErrDecodeFailed, err)
+	}
+
+	from := pcm.Format{SampleRate: dec.SampleRate(), Channels: 2}
+	to := pcm.Format{SampleRate: sampleRate, Channels: channels}
+	if to.SampleRate == 0 {
+		to.SampleRate = from.SampleRate
+	}
+
+	conv, err := pcm.NewConverter(dec, from, to)
+	if err != nil {
+		return nil, pcm.Format{}, 0, err
+	}
+
+	return conv, to, pcm.OutputFrames(dec.Length()/int64(from.FrameSize()), from, to), nil
+}
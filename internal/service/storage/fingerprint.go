@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/fingerprint"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/service"
+)
+
+const (
+	fingerprintName = "fingerprint.json"
+	fingerprintFile = "fingerprint.dat"
+
+	defaultMinConfidence = 0.5
+	defaultSimilarLimit  = 10
+	maxSimilarLimit      = 100
+)
+
+// HasFingerprint reports whether up to date
+// fingerprint of the file is stored.
+func (s *Storage) HasFingerprint(ctx context.Context, id int) (bool, error) {
+	const op = "Storage.HasFingerprint"
+
+	ok, err := hasAnalysis[int](s, id, fingerprintName)
+	if err != nil && !errors.Is(err, service.ErrFileNotExist) {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, err
+}
+
+// AnalyzeFingerprint decodes the file, stores its
+// acoustic fingerprint in the sidecar and index.
+func (s *Storage) AnalyzeFingerprint(ctx context.Context, id int) error {
+	const op = "Storage.AnalyzeFingerprint"
+
+	_, err := s.analyzeFingerprint(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FindSimilar returns stored files sounding like queried
+// audio, even if encoded differently, best match first.
+//
+// Files are matched by fingerprints, so ones not
+// analyzed yet are not found.
+func (s *Storage) FindSimilar(ctx context.Context, query models.SimilarQuery) ([]models.SimilarFile, error) {
+	const op = "Storage.FindSimilar"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	if query.MinConfidence == 0 {
+		query.MinConfidence = defaultMinConfidence
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSimilarLimit
+	}
+	if query.MinConfidence < 0 || query.MinConfidence > 1 {
+		log.Warn("invalid min confidence", slog.Float64("min_confidence", query.MinConfidence))
+		return nil, fmt.Errorf("%w: min confidence must be from 0 to 1", service.ErrInvalidSimilarQuery)
+	}
+	if query.Limit < 0 || query.Limit > maxSimilarLimit {
+		log.Warn("invalid limit", slog.Int("limit", query.Limit))
+		return nil, fmt.Errorf("%w: limit must be from 1 to %d", service.ErrInvalidSimilarQuery, maxSimilarLimit)
+	}
+
+	var (
+		fp  fingerprint.Fingerprint
+		err error
+	)
+	if len(query.Clip) > 0 {
+		fp, err = s.clipFingerprint(ctx, query.Clip)
+		if err != nil {
+			log.Warn("failed to fingerprint clip", sl.Err(err))
+			return nil, err
+		}
+	} else {
+		fp, err = s.fingerprint(ctx, query.FileID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	index, err := s.fingerprintIndex(ctx)
+	if err != nil {
+		log.Error("failed to load fingerprints", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var res []models.SimilarFile
+	for _, m := range index.Search(fp, query.MinConfidence) {
+		if len(query.Clip) == 0 && m.ID == query.FileID {
+			continue
+		}
+		res = append(res, models.SimilarFile{
+			FileID:     m.ID,
+			Offset:     m.Offset,
+			Confidence: m.Confidence,
+		})
+		if len(res) == query.Limit {
+			break
+		}
+	}
+
+	log.Debug("found similar files", slog.Int("count", len(res)))
+
+	return res, nil
+}
+
+// fingerprint returns stored fingerprint of the file,
+// computing it if it is missing or outdated.
+func (s *Storage) fingerprint(ctx context.Context, id int) (fingerprint.Fingerprint, error) {
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	fp, ok, err := s.readFingerprint(id, info)
+	if err != nil {
+		s.log.Warn("failed to read fingerprint", slog.Int("id", id), sl.Err(err))
+	}
+	if ok {
+		return fp, nil
+	}
+
+	return s.analyzeFingerprint(ctx, id)
+}
+
+// readFingerprint reads fingerprint of the file from sidecar.
+// Returns false if it is missing or outdated.
+func (s *Storage) readFingerprint(id int, info os.FileInfo) (fingerprint.Fingerprint, bool, error) {
+	if _, ok, err := readAnalysis[int](s, id, fingerprintName, info); !ok {
+		return nil, false, err
+	}
+
+	data, err := s.readSidecarFile(id, fingerprintFile)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var fp fingerprint.Fingerprint
+	if err := fp.UnmarshalBinary(data); err != nil {
+		return nil, false, err
+	}
+
+	return fp, true, nil
+}
+
+func (s *Storage) analyzeFingerprint(ctx context.Context, id int) (fingerprint.Fingerprint, error) {
+	a := &fingerprintAnalysis{}
+	if err := s.analyzeAudio(ctx, id, a); err != nil {
+		return nil, err
+	}
+
+	return a.fp, nil
+}
+
+// fingerprintAnalysis computes acoustic fingerprint
+// of the file and adds it to the index.
+type fingerprintAnalysis struct {
+	*fingerprint.Builder
+	fp fingerprint.Fingerprint
+}
+
+func (a *fingerprintAnalysis) start(f pcm.Format) (err error) {
+	a.Builder, err = fingerprint.NewBuilder(f.SampleRate, f.Channels)
+	return err
+}
+
+func (a *fingerprintAnalysis) store(s *Storage, id int, info os.FileInfo) error {
+	a.fp = a.Fingerprint()
+
+	data, err := a.fp.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := s.writeSidecarFile(id, fingerprintFile, data); err != nil {
+		return err
+	}
+	// Entry is written last, so it never
+	// points to missing fingerprint.
+	if err := writeAnalysis(s, id, fingerprintName, info, len(a.fp)); err != nil {
+		return err
+	}
+
+	s.fingerprintsMu.Lock()
+	if s.fingerprints != nil {
+		s.fingerprints.Add(id, a.fp)
+	}
+	s.fingerprintsMu.Unlock()
+
+	return nil
+}
+
+// clipFingerprint returns fingerprint of MP3 audio clip.
+func (s *Storage) clipFingerprint(ctx context.Context, clip []byte) (fingerprint.Fingerprint, error) {
+	r, pcmFormat, _, err := s.decodePCM(ctx, 0, bytes.NewReader(clip), 0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode clip: %w", service.ErrInvalidSimilarQuery, err)
+	}
+
+	fp, err := fingerprintPCM(r, pcmFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode clip: %w", service.ErrInvalidSimilarQuery, err)
+	}
+	if len(fp) == 0 {
+		return nil, fmt.Errorf("%w: clip is too short", service.ErrInvalidSimilarQuery)
+	}
+
+	return fp, nil
+}
+
+func fingerprintPCM(r io.Reader, f pcm.Format) (fingerprint.Fingerprint, error) {
+	b, err := fingerprint.NewBuilder(f.SampleRate, f.Channels)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	return b.Fingerprint(), nil
+}
+
+// fingerprintIndex returns index of stored fingerprints,
+// reading them from sidecars on first call.
+func (s *Storage) fingerprintIndex(ctx context.Context) (*fingerprint.Index, error) {
+	s.fingerprintsMu.Lock()
+	defer s.fingerprintsMu.Unlock()
+
+	if s.fingerprints != nil {
+		return s.fingerprints, nil
+	}
+
+	index := fingerprint.NewIndex()
+	if err := s.walk(ctx, func(id int, filename string) error {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+
+		fp, ok, err := s.readFingerprint(id, info)
+		if err != nil {
+			s.log.Warn("failed to read fingerprint", slog.Int("id", id), sl.Err(err))
+		}
+		if ok {
+			index.Add(id, fp)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("loaded fingerprints", slog.Int("count", index.Len()))
+
+	s.fingerprints = index
+
+	return index, nil
+}
+
+// forgetFingerprint removes fingerprint of deleted file from index.
+func (s *Storage) forgetFingerprint(id int) {
+	s.fingerprintsMu.Lock()
+	defer s.fingerprintsMu.Unlock()
+
+	if s.fingerprints != nil {
+		s.fingerprints.Remove(id)
+	}
+}
+
+// reloadFingerprint puts stored fingerprint of replaced file
+// to index instead of old one, if it is up to date.
+func (s *Storage) reloadFingerprint(id int, filename string) error {
+	s.forgetFingerprint(id)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	fp, ok, err := s.readFingerprint(id, info)
+	if err != nil || !ok {
+		return err
+	}
+
+	s.fingerprintsMu.Lock()
+	defer s.fingerprintsMu.Unlock()
+
+	if s.fingerprints != nil {
+		s.fingerprints.Add(id, fp)
+	}
+
+	return nil
+}
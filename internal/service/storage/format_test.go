@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+)
+
+func TestPutChangesFormat(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	dir, err := s.getCorrespondingDir(42)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put(ctx, 42, bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVEfmt "))))
+	require.FileExists(t, dir+"/42.wav")
+
+	// Replacing file with other format leaves single file.
+	require.NoError(t, s.Put(ctx, 42, bytes.NewReader(mp3test.Silence(3))))
+	require.FileExists(t, dir+"/42.mp3")
+	require.NoFileExists(t, dir+"/42.wav")
+
+	ids := make([]int, 0)
+	require.NoError(t, s.IDs(ctx, func(id int) error {
+		ids = append(ids, id)
+		return nil
+	}))
+	require.Equal(t, []int{42}, ids)
+
+	f, err := s.Open(ctx, 42)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, mp3test.Silence(3), data)
+
+	metadata, err := s.Metadata(ctx, 42)
+	require.NoError(t, err)
+	require.Equal(t, "mp3", metadata.Format)
+
+	require.NoError(t, s.Remove(ctx, 42))
+	_, err = os.Stat(dir + "/42.mp3")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		id   int
+		ext  string
+		ok   bool
+	}{
+		{name: "12.mp3", id: 12, ext: "mp3", ok: true},
+		{name: "0.flac", id: 0, ext: "flac", ok: true},
+		{name: "7.opus", id: 7, ext: "opus", ok: true},
+		{name: "012.mp3"},
+		{name: "12.txt"},
+		{name: "12.d"},
+		{name: ".put-123"},
+		{name: "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, f, ok := parseFilename(tt.name)
+			require.Equal(t, tt.ok, ok)
+			if ok {
+				require.Equal(t, tt.id, id)
+				require.Equal(t, tt.ext, f.Ext)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/hls"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/service"
+)
+
+const (
+	hlsName          = "hls.json"
+	hlsSegmentPrefix = "hls-"
+	// hlsSegmentDuration is target duration of HLS segments.
+	hlsSegmentDuration = 6 * time.Second
+)
+
+// hlsSegmentFile returns sidecar file name of cached segment.
+func hlsSegmentFile(n int) string {
+	return hlsSegmentPrefix + strconv.Itoa(n) + ".mp3"
+}
+
+// HLSSegments returns HLS segments of MP3 file.
+//
+// File is split when segments are first requested,
+// and the result is cached until the file changes.
+func (s *Storage) HLSSegments(ctx context.Context, id int) ([]hls.Segment, error) {
+	const op = "Storage.HLSSegments"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	_, segments, err := s.hlsSegments(log, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return segments, nil
+}
+
+// HLSSegment returns n-th HLS segment of MP3 file, which is
+// raw audio frames preceded by ID3 tag with segment timestamp.
+//
+// Segment is cut on first request and cached in the sidecar.
+func (s *Storage) HLSSegment(ctx context.Context, id int, n int) ([]byte, error) {
+	const op = "Storage.HLSSegment"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+		slog.Int("segment", n),
+	)
+
+	filename, segments, err := s.hlsSegments(log, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n < 0 || n >= len(segments) {
+		log.Warn("segment not exists")
+		return nil, service.ErrSegmentNotExist
+	}
+
+	data, err := s.readSidecarFile(id, hlsSegmentFile(n))
+	if err == nil {
+		return data, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		log.Warn("failed to read cached segment", sl.Err(err))
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Error("failed to open file", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	seg := segments[n]
+	tag := hls.TimestampTag(seg.Start)
+
+	data = make([]byte, len(tag)+int(seg.Size))
+	copy(data, tag)
+	if _, err := file.ReadAt(data[len(tag):], seg.Offset); err != nil {
+		log.Error("failed to read segment", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.writeSidecarFile(id, hlsSegmentFile(n), data); err != nil {
+		log.Warn("failed to cache segment", sl.Err(err))
+	}
+
+	log.Debug("cut segment", slog.Int64("size", seg.Size))
+
+	return data, nil
+}
+
+// hlsSegments returns path to MP3 file and its segments,
+// splitting the file if cached segments are missing or outdated.
+func (s *Storage) hlsSegments(log *slog.Logger, id int) (string, []hls.Segment, error) {
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return "", nil, err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return "", nil, err
+	}
+
+	if f != format.MP3 {
+		return "", nil, service.ErrConversionUnsupported
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Error("failed to open file", sl.Err(err))
+		return "", nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+
+	segments, ok, err := readAnalysis[[]hls.Segment](s, id, hlsName, info)
+	if err != nil {
+		log.Warn("failed to read segments", sl.Err(err))
+	}
+	if ok {
+		return filename, segments, nil
+	}
+
+	segments, err = splitHLS(file, info.Size())
+	if err != nil {
+		log.Warn("failed to split file", sl.Err(err))
+		return "", nil, fmt.Errorf("%w: %w", service.ErrDecodeFailed, err)
+	}
+
+	// Segments cut from previous version of the file.
+	if err := s.removeHLSSegments(id); err != nil {
+		log.Error("failed to remove outdated segments", sl.Err(err))
+		return "", nil, err
+	}
+
+	if err := writeAnalysis(s, id, hlsName, info, segments); err != nil {
+		log.Error("failed to write segments", sl.Err(err))
+		return "", nil, err
+	}
+
+	log.Debug("split file", slog.Int("segments", len(segments)))
+
+	return filename, segments, nil
+}
+
+// splitHLS cuts audio frames of MP3 stream into segments.
+// Tags and VBR header are left out.
+func splitHLS(r io.ReaderAt, size int64) ([]hls.Segment, error) {
+	info, err := mp3.Analyze(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	segmenter := hls.NewSegmenter(hlsSegmentDuration, info.Header.SampleRate)
+
+	frames := mp3.NewReader(io.NewSectionReader(r, info.AudioOffset, info.AudioSize))
+	for first := true; ; first = false {
+		frame, err := frames.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		if first && info.Xing != nil {
+			continue
+		}
+
+		segmenter.Add(info.AudioOffset+frame.Offset, len(frame.Data), frame.Header.Samples())
+	}
+
+	segments := segmenter.Segments()
+	if len(segments) == 0 {
+		return nil, mp3.ErrNoFrames
+	}
+
+	return segments, nil
+}
+
+// removeHLSSegments deletes cached segments of the file.
+func (s *Storage) removeHLSSegments(id int) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(dir + "/" + hlsSegmentPrefix + "*.mp3")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := s.accountSidecar(id, filepath.Base(file), func() error {
+			return os.Remove(file)
+		}); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/lib/hls"
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/internal/service"
+)
+
+func TestHLS(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	tag := mp3test.ID3v2(map[string]string{"TIT2": "Show"})
+	audio := mp3test.Silence(600)
+	putTestFile(t, s, 1, append(tag, audio...))
+
+	// Segment of 6 seconds takes 230 frames.
+	frameDuration := time.Duration(mp3test.FrameSamples) * time.Second / mp3test.SampleRate
+	segments, err := s.HLSSegments(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	for i, frames := range []int{230, 230, 140} {
+		require.Equal(t, int64(len(tag)+i*230*mp3test.FrameLen), segments[i].Offset)
+		require.Equal(t, int64(frames*mp3test.FrameLen), segments[i].Size)
+		require.InDelta(t, float64(i*230)*frameDuration.Seconds(), segments[i].Start.Seconds(), 1e-6)
+		require.InDelta(t, float64(frames)*frameDuration.Seconds(), segments[i].Duration.Seconds(), 1e-6)
+	}
+
+	data, err := s.HLSSegment(ctx, 1, 1)
+	require.NoError(t, err)
+	timestamp := hls.TimestampTag(segments[1].Start)
+	require.Equal(t, timestamp, data[:len(timestamp)])
+	require.Equal(t, audio[230*mp3test.FrameLen:460*mp3test.FrameLen], data[len(timestamp):])
+
+	// Segment is cached in the sidecar.
+	cached, err := s.readSidecarFile(1, hlsSegmentFile(1))
+	require.NoError(t, err)
+	require.Equal(t, data, cached)
+
+	_, err = s.HLSSegment(ctx, 1, 3)
+	require.ErrorIs(t, err, service.ErrSegmentNotExist)
+
+	// Changed file is split again and outdated segments are dropped.
+	putTestFile(t, s, 1, mp3test.Silence(100))
+
+	segments, err = s.HLSSegments(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	_, err = s.readSidecarFile(1, hlsSegmentFile(1))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	data, err = s.HLSSegment(ctx, 1, 0)
+	require.NoError(t, err)
+	require.True(t, bytes.HasSuffix(data, mp3test.Silence(100)))
+
+	_, err = s.HLSSegments(ctx, 2)
+	require.ErrorIs(t, err, service.ErrFileNotExist)
+}
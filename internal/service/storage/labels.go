@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/labels"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	labelsName = "labels.json"
+
+	defaultFindLimit = 1000
+	maxFindLimit     = 10000
+)
+
+// SetLabels replaces labels of the file,
+// empty labels remove all of them.
+//
+// Labels are kept in the sidecar, so they are removed along
+// with the file and carried by snapshots, backups, replication
+// and sync, and in memory index used by Find.
+func (s *Storage) SetLabels(ctx context.Context, id int, l map[string]string) error {
+	const op = "Storage.SetLabels"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if s.readOnly {
+		log.Warn("set labels on read-only storage")
+		return service.ErrReadOnly
+	}
+
+	if err := labels.Validate(l); err != nil {
+		log.Warn("invalid labels", sl.Err(err))
+		return fmt.Errorf("%w: %w", service.ErrInvalidLabels, err)
+	}
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check existing id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("file not exists")
+		return service.ErrFileNotExist
+	}
+
+	if err := s.writeLabels(id, l); err != nil {
+		log.Error("failed to write labels", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.record(models.OpSidecar, id); err != nil {
+		log.Error("failed to record labels", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("set labels", slog.Any("labels", l))
+
+	return nil
+}
+
+// Labels returns labels of the file.
+func (s *Storage) Labels(ctx context.Context, id int) (map[string]string, error) {
+	const op = "Storage.Labels"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check existing id", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("file not exists")
+		return nil, service.ErrFileNotExist
+	}
+
+	l, err := s.readLabels(id)
+	if err != nil {
+		log.Error("failed to read labels", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l, nil
+}
+
+// Find returns labeled files matching label selector, sorted by id.
+func (s *Storage) Find(ctx context.Context, query models.FindQuery) ([]models.LabeledFile, error) {
+	const op = "Storage.Find"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("selector", query.Selector),
+	)
+
+	if query.Limit == 0 {
+		query.Limit = defaultFindLimit
+	}
+	if query.Limit < 0 || query.Limit > maxFindLimit {
+		log.Warn("invalid limit", slog.Int("limit", query.Limit))
+		return nil, fmt.Errorf("%w: limit must be from 1 to %d", service.ErrInvalidFindQuery, maxFindLimit)
+	}
+
+	selector, err := labels.Parse(query.Selector)
+	if err != nil {
+		log.Warn("invalid selector", sl.Err(err))
+		return nil, fmt.Errorf("%w: %w", service.ErrInvalidFindQuery, err)
+	}
+
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+
+	if err := s.loadLabels(ctx); err != nil {
+		log.Error("failed to load labels", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var res []models.LabeledFile
+	for id, l := range s.labels {
+		if id > query.AfterID && selector.Matches(l) {
+			res = append(res, models.LabeledFile{FileID: id, Labels: maps.Clone(l)})
+		}
+	}
+
+	slices.SortFunc(res, func(a, b models.LabeledFile) int {
+		return a.FileID - b.FileID
+	})
+	if len(res) > query.Limit {
+		res = res[:query.Limit]
+	}
+
+	log.Debug("found files", slog.Int("count", len(res)))
+
+	return res, nil
+}
+
+// readLabels reads labels of the file from sidecar.
+func (s *Storage) readLabels(id int) (map[string]string, error) {
+	var l map[string]string
+	if err := s.readSidecar(id, labelsName, &l); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// writeLabels writes labels of the file to sidecar and index.
+func (s *Storage) writeLabels(id int, l map[string]string) error {
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+
+	var err error
+	if len(l) == 0 {
+		err = s.removeSidecarFile(id, labelsName)
+	} else {
+		err = s.writeSidecar(id, labelsName, l)
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.labels != nil {
+		if len(l) == 0 {
+			delete(s.labels, id)
+		} else {
+			s.labels[id] = maps.Clone(l)
+		}
+	}
+
+	return nil
+}
+
+// loadLabels reads labels of all files from
+// sidecars unless they are loaded already.
+// Must be called with labelsMu held.
+func (s *Storage) loadLabels(ctx context.Context) error {
+	if s.labels != nil {
+		return nil
+	}
+
+	index := make(map[int]map[string]string)
+	if err := s.walk(ctx, func(id int, filename string) error {
+		l, err := s.readLabels(id)
+		if err != nil {
+			return err
+		}
+		if len(l) != 0 {
+			index[id] = l
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	s.labels = index
+	s.log.Info("loaded labels", slog.String("op", "Storage.loadLabels"), slog.Int("files", len(index)))
+
+	return nil
+}
+
+// reloadLabels updates index with labels of the file
+// read from sidecar replaced as a whole.
+func (s *Storage) reloadLabels(id int) error {
+	l, err := s.readLabels(id)
+	if err != nil {
+		return err
+	}
+
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+
+	if s.labels != nil {
+		if len(l) == 0 {
+			delete(s.labels, id)
+		} else {
+			s.labels[id] = l
+		}
+	}
+
+	return nil
+}
+
+// forgetLabels removes labels of deleted file from index.
+func (s *Storage) forgetLabels(id int) {
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+
+	if s.labels != nil {
+		delete(s.labels, id)
+	}
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+// cachedSum is a checksum of file with given size and modification time.
+type cachedSum struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// List calls fn for every stored file with id in [fromID, toID]
+// in ascending id order. Zero toID means no upper bound.
+//
+// Checksums are cached while file size
+// and modification time stay the same.
+func (s *Storage) List(ctx context.Context, fromID, toID int, fn func(info models.FileInfo) error) error {
+	const op = "Storage.List"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	if err := s.walk(ctx, func(id int, filename string) error {
+		if id < fromID || (toID > 0 && id > toID) {
+			return nil
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+
+		sum, err := s.checksum(id, filename, info)
+		if err != nil {
+			return err
+		}
+		_, sidecarSum, err := s.sidecarChecksum(id)
+		if err != nil {
+			return err
+		}
+
+		return fn(models.FileInfo{
+			ID:            id,
+			Size:          info.Size(),
+			SHA256:        sum,
+			SidecarSHA256: sidecarSum,
+		})
+	}); err != nil {
+		log.Error("failed to list files", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) checksum(id int, filename string, info os.FileInfo) (string, error) {
+	s.sumsMu.Lock()
+	cached, ok := s.sums[id]
+	s.sumsMu.Unlock()
+
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, nil
+	}
+
+	sum, err := fileChecksum(filename)
+	if err != nil {
+		return "", err
+	}
+
+	s.sumsMu.Lock()
+	s.sums[id] = cachedSum{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		sum:     sum,
+	}
+	s.sumsMu.Unlock()
+
+	return sum, nil
+}
+
+// Import writes file with given id, replacing existing one.
+//
+// Unlike Put, it is rejected by read-only storage and
+// admits file the same way as Upload: it is counted in quota,
+// its format must be allowed and it must pass validation.
+func (s *Storage) Import(ctx context.Context, id int, r io.Reader) error {
+	const op = "Storage.Import"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if s.readOnly {
+		log.Warn("import to read-only storage")
+		return service.ErrReadOnly
+	}
+
+	dir, err := s.getCorrespondingDir(id)
+	if err != nil {
+		log.Error("failed to get corresponding dir", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	exists, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Replaced file does not add to number of files.
+	files := 1
+	if exists {
+		files = 0
+	}
+	adm := s.admission("", files)
+	defer adm.release()
+
+	tmp, err := os.CreateTemp(dir, ".put-*")
+	if err != nil {
+		log.Error("failed to create temporary file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	buf := make([]byte, bufferLen)
+	next := func() ([]byte, error) {
+		n, err := io.ReadAtLeast(r, buf, 1)
+		if n > 0 {
+			return buf[:n], nil
+		}
+		return nil, err
+	}
+	if err := s.receive(ctx, log, adm, tmp, next, func() int64 { return 0 }); err != nil {
+		if exhausted(err) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, metadata, err := s.admit(log, tmp)
+	if err != nil {
+		if errors.Is(err, service.ErrFormatNotAllowed) || errors.As(err, new(*service.ValidationError)) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	filename, err := s.replaceFile(tmp.Name(), id, f)
+	if err != nil {
+		log.Error("failed to move file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.reloadFingerprint(id, filename); err != nil {
+		log.Warn("failed to reload fingerprint", sl.Err(err))
+	}
+
+	if err := s.record(models.OpUpload, id); err != nil {
+		log.Error("failed to record upload", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.writeIndex(id, filename, metadata); err != nil {
+		log.Warn("failed to index file", sl.Err(err))
+	}
+
+	s.enqueue(id)
+
+	log.Debug("imported file", slog.String("format", f.Name))
+
+	return nil
+}
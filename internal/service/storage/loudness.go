@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/loudness"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/service"
+)
+
+const loudnessName = "loudness.json"
+
+// Loudness returns measured loudness of the file.
+//
+// Returns service.ErrNotAnalyzed if file was not
+// analyzed since it was last changed.
+func (s *Storage) Loudness(ctx context.Context, id int) (models.Loudness, error) {
+	const op = "Storage.Loudness"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	res, err := analysisResult[models.Loudness](s, id, loudnessName)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return models.Loudness{}, err
+		}
+		if errors.Is(err, service.ErrNotAnalyzed) {
+			return models.Loudness{}, err
+		}
+		log.Error("failed to read loudness", sl.Err(err))
+		return models.Loudness{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// AnalyzeLoudness decodes the file, measures its
+// loudness and stores result in the sidecar.
+func (s *Storage) AnalyzeLoudness(ctx context.Context, id int) (models.Loudness, error) {
+	a := &loudnessAnalysis{}
+	if err := s.analyzeAudio(ctx, id, a); err != nil {
+		return models.Loudness{}, err
+	}
+
+	return a.res, nil
+}
+
+// loudnessAnalysis measures loudness of the file.
+type loudnessAnalysis struct {
+	meter   *loudness.Meter
+	samples []float64
+	res     models.Loudness
+}
+
+func (a *loudnessAnalysis) start(f pcm.Format) (err error) {
+	a.meter, err = loudness.NewMeter(f.SampleRate, f.Channels)
+	return err
+}
+
+func (a *loudnessAnalysis) Write(samples []int16) {
+	a.samples = a.samples[:0]
+	for _, v := range samples {
+		a.samples = append(a.samples, float64(v)/32768)
+	}
+	a.meter.Write(a.samples)
+}
+
+func (a *loudnessAnalysis) store(s *Storage, id int, info os.FileInfo) error {
+	m := a.meter.Result()
+	a.res = models.Loudness{
+		Integrated: m.Integrated,
+		Range:      m.Range,
+		TruePeak:   m.TruePeak,
+		TrackGain:  loudness.TrackGain(m.Integrated),
+	}
+
+	return writeAnalysis(s, id, loudnessName, info, a.res)
+}
@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/service"
+)
+
+const (
+	sidecarSuffix = ".d"
+	metadataName  = "meta.json"
+)
+
+// metadataIndex is sidecar index entry
+// of the file with given size and modification time.
+type metadataIndex struct {
+	Size     int64           `json:"size"`
+	ModTime  time.Time       `json:"mod_time"`
+	Metadata models.Metadata `json:"metadata"`
+}
+
+// Metadata returns metadata of stored file.
+//
+// Sidecar index is rebuilt if it is missing
+// or file has changed since it was written.
+func (s *Storage) Metadata(ctx context.Context, id int) (models.Metadata, error) {
+	const op = "Storage.Metadata"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return models.Metadata{}, err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warn("file not exists")
+			return models.Metadata{}, service.ErrFileNotExist
+		}
+		log.Error("failed to probe file", sl.Err(err))
+		return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		index    metadataIndex
+		metadata models.Metadata
+	)
+	if err := s.readSidecar(id, metadataName, &index); err == nil &&
+		index.Size == info.Size() && index.ModTime.Equal(info.ModTime()) {
+		metadata = index.Metadata
+	} else {
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn("failed to read metadata index", sl.Err(err))
+		}
+
+		if metadata, err = s.index(id, filename, f); err != nil {
+			log.Error("failed to index file", sl.Err(err))
+			return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Analysis results are kept in their own sidecars.
+	if loudness, ok, err := readAnalysis[models.Loudness](s, id, loudnessName, info); err != nil {
+		log.Warn("failed to read loudness", sl.Err(err))
+	} else if ok {
+		metadata.Loudness = &loudness
+	}
+	if tempoKey, ok, err := readAnalysis[models.TempoKey](s, id, tempoKeyName, info); err != nil {
+		log.Warn("failed to read tempo and key", sl.Err(err))
+	} else if ok {
+		metadata.TempoKey = &tempoKey
+	}
+
+	return metadata, nil
+}
+
+// index extracts metadata of the file
+// and writes it to the sidecar index.
+func (s *Storage) index(id int, filename string, f format.Format) (models.Metadata, error) {
+	const op = "Storage.index"
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	metadata, audio, err := analyze(file, info.Size(), f)
+	if err != nil {
+		return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if f == format.MP3 && audio.Frames == 0 {
+		s.log.Warn("file contains no audio frames", slog.String("op", op), slog.Int("id", id))
+	}
+
+	if err := s.writeIndex(id, filename, metadata); err != nil {
+		return models.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return metadata, nil
+}
+
+// writeIndex writes metadata of the file to the sidecar index.
+func (s *Storage) writeIndex(id int, filename string, metadata models.Metadata) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	return s.writeSidecar(id, metadataName, metadataIndex{
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Metadata: metadata,
+	})
+}
+
+// analyze extracts metadata of the file.
+//
+// Only MP3 files are parsed, other formats
+// are described by format alone.
+func analyze(r io.ReaderAt, size int64, f format.Format) (models.Metadata, mp3.Info, error) {
+	var (
+		metadata models.Metadata
+		audio    mp3.Info
+	)
+
+	if f == format.MP3 {
+		var err error
+		audio, err = mp3.Analyze(r, size)
+		if err != nil && !errors.Is(err, mp3.ErrNoFrames) {
+			return models.Metadata{}, mp3.Info{}, err
+		}
+
+		metadata = metadataFromInfo(audio)
+	}
+
+	metadata.Format = f.Name
+	metadata.ContentType = f.ContentType
+
+	return metadata, audio, nil
+}
+
+func metadataFromInfo(info mp3.Info) models.Metadata {
+	metadata := models.Metadata{
+		Title:    info.Tags.Title,
+		Artist:   info.Tags.Artist,
+		Album:    info.Tags.Album,
+		Year:     info.Tags.Year,
+		HasCover: info.Tags.HasCover,
+	}
+
+	if info.Frames == 0 && info.Xing == nil {
+		return metadata
+	}
+
+	metadata.Duration = info.Duration
+	metadata.Bitrate = info.Bitrate
+	metadata.SampleRate = info.Header.SampleRate
+	metadata.Channels = info.Header.Channels()
+
+	switch info.BitrateMode {
+	case mp3.BitrateCBR:
+		metadata.BitrateMode = models.BitrateCBR
+	case mp3.BitrateVBR:
+		metadata.BitrateMode = models.BitrateVBR
+	case mp3.BitrateABR:
+		metadata.BitrateMode = models.BitrateABR
+	}
+
+	return metadata
+}
+
+// sidecarDir returns directory with derived data of the file.
+func (s *Storage) sidecarDir(id int) (string, error) {
+	dir, err := s.getCorrespondingDir(id)
+	if err != nil {
+		return "", err
+	}
+
+	return dir + "/" + strconv.Itoa(id) + sidecarSuffix, nil
+}
+
+// readSidecar decodes JSON sidecar file of given id.
+func (s *Storage) readSidecar(id int, name string, v any) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(dir + "/" + name)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// readSidecarFile reads raw sidecar file of given id.
+func (s *Storage) readSidecarFile(id int, name string) ([]byte, error) {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(dir + "/" + name)
+}
+
+// writeSidecar atomically writes JSON sidecar file of given id.
+func (s *Storage) writeSidecar(id int, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.writeSidecarFile(id, name, data)
+}
+
+// writeSidecarFile atomically writes sidecar file of given id.
+func (s *Storage) writeSidecarFile(id int, name string, data []byte) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	// Name may include subdirectory.
+	path := dir + "/" + name
+	dir = filepath.Dir(path)
+
+	return s.accountSidecar(id, name, func() error {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return err
+		}
+
+		tmp, err := os.CreateTemp(dir, ".tmp-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := tmp.Write(data); err != nil {
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+
+		return os.Rename(tmp.Name(), path)
+	})
+}
+
+// removeSidecar deletes all derived data of the file.
+func (s *Storage) removeSidecar(id int) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	return s.accountSidecar(id, "", func() error {
+		return os.RemoveAll(dir)
+	})
+}
+
+// removeSidecarFile deletes sidecar file of given id,
+// missing file is not an error.
+func (s *Storage) removeSidecarFile(id int, name string) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	return s.accountSidecar(id, name, func() error {
+		if err := os.Remove(dir + "/" + name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"radio-storage/internal/domain/models"
+)
+
+// reasonDisk labels uploads rejected by disk space reserve.
+const reasonDisk = "disk"
+
+var rejectedUploads = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "storage",
+	Subsystem: "quota",
+	Name:      "rejected_uploads_total",
+	Help:      "Number of uploads rejected by quota scope or disk space reserve.",
+}, []string{"reason"})
+
+var (
+	usageBytesDesc = prometheus.NewDesc(
+		"storage_quota_usage_bytes",
+		"Total size of stored files by quota scope.",
+		[]string{"scope", "name"}, nil,
+	)
+	usageFilesDesc = prometheus.NewDesc(
+		"storage_quota_usage_files",
+		"Number of stored files by quota scope.",
+		[]string{"scope", "name"}, nil,
+	)
+	limitBytesDesc = prometheus.NewDesc(
+		"storage_quota_limit_bytes",
+		"Limit of total size of stored files by quota scope.",
+		[]string{"scope", "name"}, nil,
+	)
+	limitFilesDesc = prometheus.NewDesc(
+		"storage_quota_limit_files",
+		"Limit of number of stored files by quota scope.",
+		[]string{"scope", "name"}, nil,
+	)
+	diskFreeDesc = prometheus.NewDesc(
+		"storage_disk_free_bytes",
+		"Free disk space available to storage.",
+		nil, nil,
+	)
+	diskReserveDesc = prometheus.NewDesc(
+		"storage_disk_reserve_bytes",
+		"Free disk space uploads never take.",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (q *Quotas) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usageBytesDesc
+	ch <- usageFilesDesc
+	ch <- limitBytesDesc
+	ch <- limitFilesDesc
+	ch <- diskFreeDesc
+	ch <- diskReserveDesc
+}
+
+// Collect implements prometheus.Collector,
+// reporting current usage of every scope.
+func (q *Quotas) Collect(ch chan<- prometheus.Metric) {
+	report, err := q.Usage(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(usageBytesDesc, err)
+		return
+	}
+
+	for _, quota := range report.Quotas {
+		scope := scopeLabel(quota.Scope)
+
+		ch <- prometheus.MustNewConstMetric(usageBytesDesc, prometheus.GaugeValue, float64(quota.Usage.Bytes), scope, quota.Name)
+		ch <- prometheus.MustNewConstMetric(usageFilesDesc, prometheus.GaugeValue, float64(quota.Usage.Files), scope, quota.Name)
+		if quota.Limit.Bytes != 0 {
+			ch <- prometheus.MustNewConstMetric(limitBytesDesc, prometheus.GaugeValue, float64(quota.Limit.Bytes), scope, quota.Name)
+		}
+		if quota.Limit.Files != 0 {
+			ch <- prometheus.MustNewConstMetric(limitFilesDesc, prometheus.GaugeValue, float64(quota.Limit.Files), scope, quota.Name)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(diskFreeDesc, prometheus.GaugeValue, float64(report.DiskFree))
+	ch <- prometheus.MustNewConstMetric(diskReserveDesc, prometheus.GaugeValue, float64(report.DiskReserve))
+}
+
+func scopeLabel(scope models.QuotaScope) string {
+	switch scope {
+	case models.QuotaGlobal:
+		return "global"
+	case models.QuotaNamespace:
+		return "namespace"
+	case models.QuotaClient:
+		return "client"
+	}
+	return ""
+}
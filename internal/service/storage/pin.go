@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const pinName = "pin.json"
+
+// pin is sidecar marker of pinned file.
+type pin struct {
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// SetPinned pins or unpins the file.
+//
+// Pin is kept in the sidecar, so it is removed along with the file
+// and carried by snapshots, backups, replication and sync.
+func (s *Storage) SetPinned(ctx context.Context, id int, pinned bool) error {
+	const op = "Storage.SetPinned"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if s.readOnly {
+		log.Warn("pin on read-only storage")
+		return service.ErrReadOnly
+	}
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check existing id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("file not exists")
+		return service.ErrFileNotExist
+	}
+
+	if pinned {
+		err = s.writeSidecar(id, pinName, pin{PinnedAt: time.Now()})
+	} else {
+		err = s.removeSidecarFile(id, pinName)
+	}
+	if err != nil {
+		log.Error("failed to write pin", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.record(models.OpSidecar, id); err != nil {
+		log.Error("failed to record pin", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("set pin", slog.Bool("pinned", pinned))
+
+	return nil
+}
+
+// Pinned reports whether the file is pinned.
+func (s *Storage) Pinned(ctx context.Context, id int) (bool, error) {
+	const op = "Storage.Pinned"
+
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := os.Stat(dir + "/" + pinName); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// ListPinned returns ids of pinned files in ascending order.
+func (s *Storage) ListPinned(ctx context.Context) ([]int, error) {
+	const op = "Storage.ListPinned"
+
+	var ids []int
+	if err := s.walk(ctx, func(id int, _ string) error {
+		pinned, err := s.Pinned(ctx, id)
+		if err != nil {
+			return err
+		}
+		if pinned {
+			ids = append(ids, id)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
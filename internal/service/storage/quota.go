@@ -0,0 +1,635 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	// clientName is sidecar with identity of client uploaded the file.
+	clientName = "client.json"
+
+	// minReserve is the least bytes reservation of upload grows by,
+	// so upload of unknown size takes quota lock a few times only.
+	minReserve = 1 << 20
+)
+
+// QuotaPolicy lists limits of storages sharing quotas.
+// Zero values are not limited.
+type QuotaPolicy struct {
+	// Global bounds files of all namespaces together.
+	Global     models.Usage
+	Namespaces map[string]models.Usage
+	// Clients bound files uploaded by client to any namespace.
+	Clients map[string]models.Usage
+	// Reserve is disk free space in bytes uploads never take.
+	Reserve int64
+}
+
+// Quotas tracks usage of storages sharing it
+// and admits uploads within limits.
+//
+// Usage of a file includes its sidecar with attachments and
+// caches. Namespace usage also includes files kept only by
+// snapshots and quarantined uploads.
+type Quotas struct {
+	policy QuotaPolicy
+
+	mu       sync.Mutex
+	storages map[string]*Storage
+	// used is running usage of every namespace:
+	// stored files along with uploads in progress.
+	used map[string]*usage
+}
+
+func NewQuotas(policy QuotaPolicy) *Quotas {
+	return &Quotas{
+		policy:   policy,
+		storages: make(map[string]*Storage),
+		used:     make(map[string]*usage),
+	}
+}
+
+// WithQuotas makes storage count its files in quotas as namespace
+// and reject uploads exceeding them.
+func WithQuotas(q *Quotas, namespace string) Option {
+	return func(s *Storage) {
+		s.quotas = q
+		s.namespace = namespace
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		q.storages[namespace] = s
+		q.used[namespace] = newUsage()
+	}
+}
+
+// Usage returns usage of every scope along with its limit:
+// global one, every namespace and every known client.
+func (q *Quotas) Usage(ctx context.Context) (models.UsageReport, error) {
+	const op = "Quotas.Usage"
+
+	if err := q.load(ctx); err != nil {
+		return models.UsageReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	global := newUsage()
+	for _, u := range q.used {
+		global.merge(u)
+	}
+	for client := range q.policy.Clients {
+		if _, ok := global.clients[client]; !ok {
+			global.clients[client] = models.Usage{}
+		}
+	}
+
+	report := models.UsageReport{
+		Quotas: []models.Quota{{
+			Scope: models.QuotaGlobal,
+			Usage: global.total,
+			Limit: q.policy.Global,
+		}},
+		DiskReserve: q.policy.Reserve,
+	}
+	for _, name := range sortedKeys(q.used) {
+		report.Quotas = append(report.Quotas, models.Quota{
+			Scope: models.QuotaNamespace,
+			Name:  name,
+			Usage: q.used[name].total,
+			Limit: q.policy.Namespaces[name],
+		})
+	}
+	for _, client := range sortedKeys(global.clients) {
+		report.Quotas = append(report.Quotas, models.Quota{
+			Scope: models.QuotaClient,
+			Name:  client,
+			Usage: global.clients[client],
+			Limit: q.policy.Clients[client],
+		})
+	}
+
+	if s, ok := q.storages[models.DefaultNamespace]; ok {
+		var err error
+		if report.DiskFree, err = diskFree(s.dir); err != nil {
+			return models.UsageReport{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return report, nil
+}
+
+// reserve admits upload growing usage of namespace by
+// client with delta and counts it as in progress.
+//
+// Returns label of rejection reason along with error
+// if upload is rejected.
+func (q *Quotas) reserve(ctx context.Context, namespace, client string, delta models.Usage) (string, error) {
+	if err := q.load(ctx); err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var global, byClient models.Usage
+	for _, u := range q.used {
+		global = global.Add(u.total)
+		byClient = byClient.Add(u.clients[client])
+	}
+
+	if exceeds(global, delta, q.policy.Global) {
+		return scopeLabel(models.QuotaGlobal), fmt.Errorf("%w: global quota", service.ErrQuotaExceeded)
+	}
+	if exceeds(q.used[namespace].total, delta, q.policy.Namespaces[namespace]) {
+		return scopeLabel(models.QuotaNamespace), fmt.Errorf("%w: quota of namespace %q", service.ErrQuotaExceeded, namespace)
+	}
+	if client != "" && exceeds(byClient, delta, q.policy.Clients[client]) {
+		return scopeLabel(models.QuotaClient), fmt.Errorf("%w: quota of client %q", service.ErrQuotaExceeded, client)
+	}
+
+	if q.policy.Reserve != 0 && delta.Bytes != 0 {
+		free, err := diskFree(q.storages[namespace].dir)
+		if err != nil {
+			return "", err
+		}
+		if free-delta.Bytes < q.policy.Reserve {
+			return reasonDisk, fmt.Errorf("%w: %d bytes free, %d reserved", service.ErrInsufficientSpace, free, q.policy.Reserve)
+		}
+	}
+
+	q.used[namespace].add(client, delta)
+
+	return "", nil
+}
+
+// add counts change of usage of namespace by client.
+func (q *Quotas) add(namespace, client string, delta models.Usage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.used[namespace].add(client, delta)
+}
+
+// load makes every storage count its stored files,
+// unless it is done already.
+func (q *Quotas) load(ctx context.Context) error {
+	q.mu.Lock()
+	storages := make([]*Storage, 0, len(q.storages))
+	for _, s := range q.storages {
+		storages = append(storages, s)
+	}
+	q.mu.Unlock()
+
+	for _, s := range storages {
+		if err := s.loadUsage(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// exceeds reports whether usage grown by delta exceeds limit.
+func exceeds(u, delta, limit models.Usage) bool {
+	u = u.Add(delta)
+	return limit.Bytes != 0 && delta.Bytes != 0 && u.Bytes > limit.Bytes ||
+		limit.Files != 0 && delta.Files != 0 && u.Files > limit.Files
+}
+
+// exhausted reports whether upload is rejected
+// by quota or disk space reserve.
+func exhausted(err error) bool {
+	return errors.Is(err, service.ErrQuotaExceeded) || errors.Is(err, service.ErrInsufficientSpace)
+}
+
+// diskFree returns free space of file system
+// available to unprivileged user.
+func diskFree(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// admission is quota reserved by upload in progress.
+type admission struct {
+	s      *Storage
+	client string
+	// files is number of files upload adds.
+	files    int
+	admitted bool
+	reserved models.Usage
+}
+
+// admission returns admission of upload by client adding
+// given number of files, nothing is reserved until first reserve.
+func (s *Storage) admission(client string, files int) *admission {
+	return &admission{s: s, client: client, files: files}
+}
+
+// reserve makes sure quota is reserved for upload of given size.
+//
+// Quota is checked only when reservation grows,
+// so most calls do not take the quota lock.
+func (a *admission) reserve(ctx context.Context, size int64) error {
+	if a.admitted && size <= a.reserved.Bytes {
+		return nil
+	}
+
+	if a.admitted {
+		step := max(size, 2*a.reserved.Bytes, minReserve) - a.reserved.Bytes
+		_, err := a.grow(ctx, models.Usage{Bytes: step})
+		if !exhausted(err) {
+			return err
+		}
+		// Retry without headroom, which may fit the quota.
+	}
+
+	delta := models.Usage{Bytes: max(size-a.reserved.Bytes, 0)}
+	if !a.admitted {
+		delta.Files = a.files
+	}
+
+	reason, err := a.grow(ctx, delta)
+	if err != nil {
+		if reason != "" {
+			rejectedUploads.WithLabelValues(reason).Inc()
+		}
+		return err
+	}
+	a.admitted = true
+
+	return nil
+}
+
+func (a *admission) grow(ctx context.Context, delta models.Usage) (string, error) {
+	if a.s.quotas == nil || delta == (models.Usage{}) {
+		return "", nil
+	}
+
+	reason, err := a.s.quotas.reserve(ctx, a.s.namespace, a.client, delta)
+	if err != nil {
+		return reason, err
+	}
+	a.reserved = a.reserved.Add(delta)
+
+	return "", nil
+}
+
+// release returns reserved quota, stored file
+// is counted in usage of the storage by then.
+func (a *admission) release() {
+	if a.s.quotas == nil || a.reserved == (models.Usage{}) {
+		return
+	}
+
+	a.s.quotas.add(a.s.namespace, a.client, models.Usage{}.Sub(a.reserved))
+	a.reserved = models.Usage{}
+}
+
+// usage is usage of files, total and by client.
+type usage struct {
+	total   models.Usage
+	clients map[string]models.Usage
+}
+
+func newUsage() *usage {
+	return &usage{clients: make(map[string]models.Usage)}
+}
+
+func (u *usage) add(client string, delta models.Usage) {
+	u.total = u.total.Add(delta)
+	if client == "" {
+		return
+	}
+
+	if c := u.clients[client].Add(delta); c != (models.Usage{}) {
+		u.clients[client] = c
+	} else {
+		delete(u.clients, client)
+	}
+}
+
+func (u *usage) merge(other *usage) {
+	u.total = u.total.Add(other.total)
+	for client, c := range other.clients {
+		u.clients[client] = u.clients[client].Add(c)
+	}
+}
+
+// report counts change of stored usage in quotas.
+func (s *Storage) report(client string, delta models.Usage) {
+	if s.quotas == nil || delta == (models.Usage{}) {
+		return
+	}
+	s.quotas.add(s.namespace, client, delta)
+}
+
+// loadUsage sums usage of all files unless it is loaded already,
+// and recounts service directories if they changed.
+func (s *Storage) loadUsage(ctx context.Context) error {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	if s.storedUsage == nil {
+		u := newUsage()
+		if err := s.walk(ctx, func(id int, filename string) error {
+			fileUsage, err := s.mainUsage(id)
+			if err != nil {
+				return err
+			}
+			dir, err := s.sidecarDir(id)
+			if err != nil {
+				return err
+			}
+			sidecarSize, err := pathSize(dir)
+			if err != nil {
+				return err
+			}
+			fileUsage.Bytes += sidecarSize
+
+			client, err := s.readClient(id)
+			if err != nil {
+				return err
+			}
+
+			u.add(client, fileUsage)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		s.storedUsage = u
+		for client, c := range u.clients {
+			s.report(client, c)
+		}
+		s.report("", u.total.Sub(sumClients(u)))
+	}
+
+	if !s.serviceUsageFresh {
+		size, err := s.serviceDirsSize()
+		if err != nil {
+			return err
+		}
+
+		s.report("", models.Usage{Bytes: size - s.serviceUsage})
+		s.serviceUsage = size
+		s.serviceUsageFresh = true
+	}
+
+	return nil
+}
+
+func sumClients(u *usage) models.Usage {
+	var sum models.Usage
+	for _, c := range u.clients {
+		sum = sum.Add(c)
+	}
+	return sum
+}
+
+// resetUsage drops stored usage, so it is loaded again.
+// Must be called with usageMu held.
+func (s *Storage) resetUsage() {
+	if s.storedUsage == nil {
+		return
+	}
+
+	for client, c := range s.storedUsage.clients {
+		s.report(client, models.Usage{}.Sub(c))
+	}
+	s.report("", models.Usage{}.Sub(s.storedUsage.total.Sub(sumClients(s.storedUsage))))
+	s.storedUsage = nil
+}
+
+// invalidateServiceUsage makes service directories
+// be recounted on next use.
+func (s *Storage) invalidateServiceUsage() {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	s.serviceUsageFresh = false
+}
+
+// serviceDirsSize returns size of quarantined uploads
+// and files kept only by snapshots.
+func (s *Storage) serviceDirsSize() (int64, error) {
+	quarantined, err := pathSize(s.dir + "/" + quarantineDir)
+	if err != nil {
+		return 0, err
+	}
+
+	// Snapshots hardlink stored files, so only files with
+	// all their links inside snapshots take extra space.
+	type inode struct {
+		size  int64
+		links uint64
+		seen  uint64
+	}
+	inodes := make(map[uint64]*inode)
+	var unlinked int64
+
+	if err := filepath.WalkDir(s.dir+"/"+snapshotsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			unlinked += info.Size()
+			return nil
+		}
+
+		in, ok := inodes[st.Ino]
+		if !ok {
+			in = &inode{size: info.Size(), links: uint64(st.Nlink)}
+			inodes[st.Ino] = in
+		}
+		in.seen++
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	size := quarantined + unlinked
+	for _, in := range inodes {
+		if in.seen >= in.links {
+			size += in.size
+		}
+	}
+
+	return size, nil
+}
+
+// mainUsage returns usage of stored file with given id
+// without its sidecar, zero if it not exists.
+func (s *Storage) mainUsage(id int) (models.Usage, error) {
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return models.Usage{}, nil
+		}
+		return models.Usage{}, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return models.Usage{}, nil
+		}
+		return models.Usage{}, err
+	}
+
+	return models.Usage{Bytes: info.Size(), Files: 1}, nil
+}
+
+// account runs fn changing stored file with given id
+// and counts change of its size in usage.
+func (s *Storage) account(id int, fn func() error) error {
+	return s.accountChange(id, s.mainUsage, fn)
+}
+
+// accountSidecar runs fn changing sidecar file or directory
+// of the file with given id and counts change of its size in usage.
+// Empty name stands for the whole sidecar.
+func (s *Storage) accountSidecar(id int, name string, fn func() error) error {
+	return s.accountChange(id, func(id int) (models.Usage, error) {
+		dir, err := s.sidecarDir(id)
+		if err != nil {
+			return models.Usage{}, err
+		}
+		if name != "" {
+			dir += "/" + name
+		}
+
+		size, err := pathSize(dir)
+		return models.Usage{Bytes: size}, err
+	}, fn)
+}
+
+// accountChange runs fn and adds difference of sizes
+// given by measure before and after it to usage.
+func (s *Storage) accountChange(id int, measure func(id int) (models.Usage, error), fn func() error) error {
+	const op = "Storage.accountChange"
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	// Replaced or removed file may stay in snapshots.
+	s.serviceUsageFresh = false
+
+	if s.storedUsage == nil {
+		return fn()
+	}
+
+	before, err := measure(id)
+	if err != nil {
+		return err
+	}
+	// Client is read before the file and
+	// its sidecar may be deleted.
+	client, err := s.readClient(id)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		// Change may be partial.
+		s.resetUsage()
+		return err
+	}
+
+	after, err := measure(id)
+	if err != nil {
+		// Change is done, but its size is unknown.
+		s.resetUsage()
+		s.log.Error("failed to measure usage", slog.String("op", op), slog.Int("id", id), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	delta := after.Sub(before)
+	s.storedUsage.add(client, delta)
+	s.report(client, delta)
+
+	return nil
+}
+
+// pathSize returns total size of regular files in path,
+// zero if it not exists.
+func pathSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		size += info.Size()
+
+		return nil
+	})
+	return size, err
+}
+
+// readClient returns client uploaded the file,
+// empty if it is unknown.
+func (s *Storage) readClient(id int) (string, error) {
+	var client string
+	if err := s.readSidecar(id, clientName, &client); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return client, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+)
+
+func TestAdmissionReserve(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewQuotas(QuotaPolicy{Global: models.Usage{Bytes: 3 * minReserve}})
+	s := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		t.TempDir(),
+		2,
+		5,
+		WithQuotas(q, models.DefaultNamespace),
+	)
+
+	used := func() models.Usage {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.used[models.DefaultNamespace].total
+	}
+
+	adm := s.admission("", 1)
+
+	// First chunk is reserved exactly.
+	require.NoError(t, adm.reserve(ctx, 10))
+	require.Equal(t, models.Usage{Bytes: 10, Files: 1}, used())
+
+	// Next chunks grow reservation with headroom.
+	require.NoError(t, adm.reserve(ctx, 20))
+	require.Equal(t, models.Usage{Bytes: minReserve, Files: 1}, used())
+	require.NoError(t, adm.reserve(ctx, minReserve))
+	require.Equal(t, models.Usage{Bytes: minReserve, Files: 1}, used())
+	require.NoError(t, adm.reserve(ctx, minReserve+1))
+	require.Equal(t, models.Usage{Bytes: 2 * minReserve, Files: 1}, used())
+
+	// Headroom beyond quota is not taken.
+	require.NoError(t, adm.reserve(ctx, 3*minReserve))
+	require.Equal(t, models.Usage{Bytes: 3 * minReserve, Files: 1}, used())
+	require.ErrorIs(t, adm.reserve(ctx, 3*minReserve+1), service.ErrQuotaExceeded)
+
+	adm.release()
+	require.Equal(t, models.Usage{}, used())
+}
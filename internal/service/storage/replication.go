@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+// Open opens stored file for reading.
+func (s *Storage) Open(ctx context.Context, id int) (io.ReadCloser, error) {
+	const op = "Storage.Open"
+
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, service.ErrFileNotExist
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return file, nil
+}
+
+// Put writes file with given id, replacing existing one.
+//
+// File becomes visible only after it is completely written.
+// Unlike Upload, Put is allowed for read-only storage,
+// since replicas apply changes with it, and accepts any format.
+func (s *Storage) Put(ctx context.Context, id int, r io.Reader) error {
+	const op = "Storage.Put"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	dir, err := s.getCorrespondingDir(id)
+	if err != nil {
+		log.Error("failed to get corresponding dir", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".put-*")
+	if err != nil {
+		log.Error("failed to create temporary file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		log.Error("failed to write file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := format.Sniff(tmp)
+	if err != nil {
+		log.Error("failed to detect format", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	filename, err := s.replaceFile(tmp.Name(), id, f)
+	if err != nil {
+		log.Error("failed to move file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.reloadFingerprint(id, filename); err != nil {
+		log.Warn("failed to reload fingerprint", sl.Err(err))
+	}
+
+	if err := s.record(models.OpUpload, id); err != nil {
+		log.Error("failed to record upload", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.index(id, filename, f); err != nil {
+		log.Warn("failed to index file", sl.Err(err))
+	}
+
+	s.enqueue(id)
+
+	log.Debug("put file")
+
+	return nil
+}
+
+// Remove deletes file by its id.
+// Missing file is not an error.
+//
+// Unlike Delete, Remove is allowed for read-only storage,
+// since replicas apply changes with it.
+func (s *Storage) Remove(ctx context.Context, id int) error {
+	const op = "Storage.Remove"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if err := s.removeFiles(id); err != nil {
+		log.Error("failed to delete file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.removeSidecar(id); err != nil {
+		log.Warn("failed to delete sidecar", sl.Err(err))
+	}
+	s.forgetFingerprint(id)
+	s.forgetLabels(id)
+
+	if err := s.record(models.OpDelete, id); err != nil {
+		log.Error("failed to record delete", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("removed file")
+
+	return nil
+}
+
+// IDs calls fn for every stored file id in ascending order.
+func (s *Storage) IDs(ctx context.Context, fn func(id int) error) error {
+	return s.walk(ctx, func(id int, _ string) error {
+		return fn(id)
+	})
+}
+
+// record appends change to the journal if any.
+func (s *Storage) record(op models.ChangeOp, id int) error {
+	if s.journal == nil {
+		return nil
+	}
+
+	_, err := s.journal.Append(op, id)
+
+	return err
+}
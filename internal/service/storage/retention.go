@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const expiryName = "expiry.json"
+
+// expiry is sidecar with time the file is deleted at.
+type expiry struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WithRetention makes files expire after given age,
+// unless expiry of the file is set explicitly.
+// Age is counted from last change of the file.
+func WithRetention(retention time.Duration) Option {
+	return func(s *Storage) {
+		s.retention = retention
+	}
+}
+
+// SetExpiry sets time the file is deleted at,
+// zero time returns the file to retention of the storage.
+func (s *Storage) SetExpiry(ctx context.Context, id int, at time.Time) error {
+	const op = "Storage.SetExpiry"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if s.readOnly {
+		log.Warn("set expiry on read-only storage")
+		return service.ErrReadOnly
+	}
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check existing id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("file not exists")
+		return service.ErrFileNotExist
+	}
+
+	if err := s.writeExpiry(id, at); err != nil {
+		log.Error("failed to write expiry", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.record(models.OpSidecar, id); err != nil {
+		log.Error("failed to record expiry", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("set expiry", slog.Time("expires_at", at))
+
+	return nil
+}
+
+// Expiry returns time the file is deleted at,
+// zero if it never expires.
+func (s *Storage) Expiry(ctx context.Context, id int) (time.Time, error) {
+	const op = "Storage.Expiry"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	filename, _, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return time.Time{}, service.ErrFileNotExist
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	at, err := s.expiresAt(id, filename)
+	if err != nil {
+		log.Error("failed to read expiry", sl.Err(err))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return at, nil
+}
+
+// Reap deletes files expired by now. Pinned files are kept.
+//
+// Failure to delete a file does not stop others from being deleted.
+func (s *Storage) Reap(ctx context.Context, now time.Time) (models.ReapStats, error) {
+	const op = "Storage.Reap"
+
+	log := s.log.With(slog.String("op", op))
+
+	if s.readOnly {
+		return models.ReapStats{}, service.ErrReadOnly
+	}
+
+	// Files are deleted after walk,
+	// so it does not see the tree changing.
+	expired := make(map[int]time.Time)
+	if err := s.walk(ctx, func(id int, filename string) error {
+		at, err := s.expiresAt(id, filename)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !at.IsZero() && !at.After(now) {
+			expired[id] = at
+		}
+		return nil
+	}); err != nil {
+		log.Error("failed to find expired files", sl.Err(err))
+		return models.ReapStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var stats models.ReapStats
+	for _, id := range sortedIDs(expired) {
+		if err := ctx.Err(); err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log := log.With(slog.Int("id", id), slog.Time("expires_at", expired[id]))
+
+		pinned, err := s.Pinned(ctx, id)
+		if err != nil {
+			log.Error("failed to check pin", sl.Err(err))
+			stats.Failed++
+			continue
+		}
+		if pinned {
+			log.Info("kept pinned expired file")
+			stats.Pinned++
+			continue
+		}
+
+		if err := s.Delete(ctx, id); err != nil {
+			if errors.Is(err, service.ErrFileNotExist) {
+				continue
+			}
+			log.Error("failed to delete expired file", sl.Err(err))
+			stats.Failed++
+			continue
+		}
+
+		log.Info("deleted expired file")
+		stats.Deleted++
+	}
+
+	return stats, nil
+}
+
+// expiresAt returns time stored file expires at,
+// zero if it never expires.
+func (s *Storage) expiresAt(id int, filename string) (time.Time, error) {
+	var e expiry
+	err := s.readSidecar(id, expiryName, &e)
+	if err == nil {
+		return e.ExpiresAt, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, err
+	}
+
+	if s.retention == 0 {
+		return time.Time{}, nil
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime().Add(s.retention), nil
+}
+
+// writeExpiry sets expiry of the file,
+// zero time removes it.
+func (s *Storage) writeExpiry(id int, at time.Time) error {
+	if at.IsZero() {
+		return s.removeSidecarFile(id, expiryName)
+	}
+	return s.writeSidecar(id, expiryName, expiry{ExpiresAt: at.UTC()})
+}
+
+func sortedIDs[V any](m map[int]V) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
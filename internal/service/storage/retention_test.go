@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/mp3/mp3test"
+)
+
+func TestReap(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	s := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		t.TempDir(),
+		2,
+		5,
+		WithRetention(24*time.Hour),
+	)
+
+	// Files aged by modification time.
+	age := func(id int, age time.Duration) {
+		t.Helper()
+
+		filename, _, err := s.findFile(id)
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(filename, now.Add(-age), now.Add(-age)))
+	}
+
+	const (
+		expired = iota + 1
+		extended
+		old
+		fresh
+		pinned
+	)
+	for id := expired; id <= pinned; id++ {
+		putTestFile(t, s, id, mp3test.Silence(3))
+	}
+
+	require.NoError(t, s.SetExpiry(ctx, expired, now.Add(-time.Hour)))
+	// Expiry of the file overrides retention.
+	require.NoError(t, s.SetExpiry(ctx, extended, now.Add(time.Hour)))
+	age(extended, 48*time.Hour)
+	age(old, 48*time.Hour)
+	require.NoError(t, s.SetExpiry(ctx, pinned, now.Add(-time.Hour)))
+	require.NoError(t, s.SetPinned(ctx, pinned, true))
+
+	at, err := s.Expiry(ctx, old)
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(-24*time.Hour), at, time.Second)
+
+	stats, err := s.Reap(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, models.ReapStats{Deleted: 2, Pinned: 1}, stats)
+
+	for id, exists := range map[int]bool{expired: false, extended: true, old: false, fresh: true, pinned: true} {
+		ok, err := s.checkExistingID(id)
+		require.NoError(t, err)
+		require.Equal(t, exists, ok, "file %d", id)
+	}
+
+	// Unpinned file is deleted by next run.
+	require.NoError(t, s.SetPinned(ctx, pinned, false))
+	stats, err = s.Reap(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, models.ReapStats{Deleted: 1}, stats)
+
+	// Cleared expiry returns file to retention.
+	require.NoError(t, s.SetExpiry(ctx, extended, time.Time{}))
+	at, err = s.Expiry(ctx, extended)
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(-24*time.Hour), at, time.Second)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	grpcModels "radio-storage/internal/domain/grpc"
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/service"
+)
+
+// maxSequenceLen limits number of files in a sequence.
+const maxSequenceLen = 256
+
+type sequenceFile struct {
+	id   int
+	file *os.File
+	info mp3.Info
+}
+
+// DownloadSequence streams MP3 files back-to-back as a single stream.
+// Tags and VBR headers are stripped, so only audio frames are sent,
+// and start of every file is reported before its frames.
+//
+// All files must have the same sample rate, otherwise
+// ErrSampleRateMismatch is returned before streaming starts.
+func (s *Storage) DownloadSequence(ctx context.Context, ids []int, w *grpcModels.DownloadSequenceStreamWrapper) error {
+	const op = "Storage.DownloadSequence"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("ids", ids),
+	)
+
+	if len(ids) == 0 || len(ids) > maxSequenceLen {
+		log.Warn("invalid sequence length", slog.Int("len", len(ids)))
+		return fmt.Errorf("%w: sequence must have from 1 to %d files", service.ErrInvalidSequence, maxSequenceLen)
+	}
+
+	files := make([]sequenceFile, 0, len(ids))
+	defer func() {
+		for _, f := range files {
+			f.file.Close()
+		}
+	}()
+
+	for _, id := range ids {
+		f, err := s.openSequenceFile(id)
+		if err != nil {
+			if errors.Is(err, service.ErrFileNotExist) || errors.Is(err, service.ErrConversionUnsupported) || errors.Is(err, service.ErrDecodeFailed) {
+				log.Warn("invalid sequence file", slog.Int("id", id), sl.Err(err))
+				return err
+			}
+			log.Error("failed to open sequence file", slog.Int("id", id), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		files = append(files, f)
+
+		if first := files[0]; f.info.Header.SampleRate != first.info.Header.SampleRate {
+			log.Warn("sample rates of files differ")
+			return fmt.Errorf(
+				"%w: file %d is %d Hz, file %d is %d Hz",
+				service.ErrSampleRateMismatch,
+				first.id, first.info.Header.SampleRate,
+				f.id, f.info.Header.SampleRate,
+			)
+		}
+	}
+
+	log.Debug("download sequence")
+
+	w.SetContentType(format.MP3.ContentType)
+
+	sampleRate := int64(files[0].info.Header.SampleRate)
+
+	var (
+		offset  int64
+		samples int64
+	)
+	buffer := make([]byte, 0, bufferLen)
+	flush := func() error {
+		if len(buffer) == 0 {
+			return nil
+		}
+		if err := w.Write(buffer); err != nil {
+			return err
+		}
+		buffer = buffer[:0]
+		return nil
+	}
+
+	for i, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fileSamples := int64(f.info.Frames) * int64(f.info.Header.Samples())
+
+		item := models.SequenceItem{
+			Index:      i,
+			FileID:     f.id,
+			ByteOffset: offset,
+			TimeOffset: time.Duration(samples * int64(time.Second) / sampleRate),
+			Duration:   time.Duration(fileSamples * int64(time.Second) / sampleRate),
+		}
+		if x := f.info.Xing; x != nil {
+			item.EncoderDelay = x.Delay
+			item.EncoderPadding = x.Padding
+		}
+
+		if err := flush(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := w.StartItem(item); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		frames := mp3.NewReader(io.NewSectionReader(f.file, f.info.AudioOffset, f.info.AudioSize))
+		for first := true; ; first = false {
+			frame, err := frames.Next()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				log.Error("failed to read file", slog.Int("id", f.id), sl.Err(err))
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			if first && f.info.Xing != nil {
+				continue
+			}
+
+			if len(buffer)+len(frame.Data) > bufferLen {
+				if err := flush(); err != nil {
+					return fmt.Errorf("%s: %w", op, err)
+				}
+			}
+			buffer = append(buffer, frame.Data...)
+			offset += int64(len(frame.Data))
+		}
+
+		samples += fileSamples
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("downloaded sequence", slog.Int64("bytes", offset))
+
+	return nil
+}
+
+// openSequenceFile opens MP3 file and parses its frames.
+func (s *Storage) openSequenceFile(id int) (sequenceFile, error) {
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			return sequenceFile{}, fmt.Errorf("%w: file %d", err, id)
+		}
+		return sequenceFile{}, err
+	}
+
+	if f != format.MP3 {
+		return sequenceFile{}, fmt.Errorf("%w: file %d", service.ErrConversionUnsupported, id)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return sequenceFile{}, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return sequenceFile{}, err
+	}
+
+	info, err := mp3.Analyze(file, stat.Size())
+	if err != nil {
+		file.Close()
+		return sequenceFile{}, fmt.Errorf("%w: file %d: %w", service.ErrDecodeFailed, id, err)
+	}
+
+	return sequenceFile{id: id, file: file, info: info}, nil
+}
@@ -0,0 +1,379 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+// persistentSidecar lists sidecar entries holding state of the file
+// given by clients. Unlike caches derived from the file content,
+// they can not be rebuilt, so they are carried along with the file
+// by snapshots, backups, replication and sync.
+var persistentSidecar = []string{attachmentsDir, labelsName, expiryName, pinName}
+
+// maxSidecarFile bounds size of persistent sidecar file
+// read from archive.
+const maxSidecarFile = maxAttachmentSize
+
+// isPersistent reports whether sidecar path
+// names file of persistent entry.
+func isPersistent(name string) bool {
+	if name != path.Clean(name) || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return false
+	}
+	// Temporary files are never carried.
+	if strings.HasPrefix(path.Base(name), ".") {
+		return false
+	}
+
+	entry, _, _ := strings.Cut(name, "/")
+	return slices.Contains(persistentSidecar, entry)
+}
+
+// persistentFiles returns sidecar paths of persistent
+// files of the file in lexical order.
+func (s *Storage) persistentFiles(id int) ([]string, error) {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return persistentFilesIn(dir)
+}
+
+// persistentFilesIn returns paths of persistent
+// files in sidecar dir in lexical order.
+func persistentFilesIn(dir string) ([]string, error) {
+	names := make([]string, 0)
+	for _, entry := range persistentSidecar {
+		if err := filepath.WalkDir(dir+"/"+entry, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			if name := strings.TrimPrefix(p, dir+"/"); isPersistent(name) {
+				names = append(names, name)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(names)
+
+	return names, nil
+}
+
+// clearSidecar removes persistent sidecar files of the file.
+func (s *Storage) clearSidecar(id int) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range persistentSidecar {
+		if err := s.accountSidecar(id, entry, func() error {
+			return os.RemoveAll(dir + "/" + entry)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return s.reloadLabels(id)
+}
+
+// linkSidecar hardlinks persistent sidecar files of the file
+// into the same place under root, returns their total size.
+func (s *Storage) linkSidecar(id int, root string) (int64, error) {
+	names, err := s.persistentFiles(id)
+	if err != nil {
+		return 0, err
+	}
+
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return 0, err
+	}
+	target := root + strings.TrimPrefix(dir, s.dir)
+
+	var size int64
+	for _, name := range names {
+		info, err := os.Stat(dir + "/" + name)
+		if err != nil {
+			return 0, err
+		}
+		if err := os.MkdirAll(path.Dir(target+"/"+name), 0777); err != nil {
+			return 0, err
+		}
+		if err := os.Link(dir+"/"+name, target+"/"+name); err != nil {
+			return 0, err
+		}
+		size += info.Size()
+	}
+
+	return size, nil
+}
+
+// restoreSidecar replaces persistent sidecar files of the file
+// with ones hardlinked by linkSidecar under root.
+func (s *Storage) restoreSidecar(id int, root string) error {
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	source := root + strings.TrimPrefix(dir, s.dir)
+	names, err := persistentFilesIn(source)
+	if err != nil {
+		return err
+	}
+
+	if err := s.clearSidecar(id); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		// Sidecar files are replaced by rename,
+		// so linked files are never changed in place.
+		if err := s.accountSidecar(id, name, func() error {
+			if err := os.MkdirAll(path.Dir(dir+"/"+name), 0777); err != nil {
+				return err
+			}
+			return os.Link(source+"/"+name, dir+"/"+name)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return s.reloadLabels(id)
+}
+
+// writeSidecarArchive writes persistent sidecar files
+// of the file to w as tar archive.
+//
+// Archive of unchanged files is the same byte to byte,
+// so its checksum identifies state of the sidecar.
+func (s *Storage) writeSidecarArchive(id int, w io.Writer) error {
+	names, err := s.persistentFiles(id)
+	if err != nil {
+		return err
+	}
+
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		data, err := os.ReadFile(dir + "/" + name)
+		if err != nil {
+			// File removed meanwhile is not part of the state anymore.
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		info, err := os.Stat(dir + "/" + name)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: info.ModTime().Truncate(time.Second),
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// readSidecarArchive replaces persistent sidecar files
+// of the file with ones of archive made by writeSidecarArchive.
+//
+// Archive is verified before current files are replaced.
+func (s *Storage) readSidecarArchive(id int, r io.Reader) error {
+	type entry struct {
+		name    string
+		data    []byte
+		modTime time.Time
+	}
+
+	entries := make([]entry, 0)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("%w: %w", service.ErrInvalidArchive, err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || !isPersistent(hdr.Name) || hdr.Size > maxSidecarFile {
+			return fmt.Errorf("%w: unexpected sidecar entry %q", service.ErrInvalidArchive, hdr.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("%w: %w", service.ErrInvalidArchive, err)
+		}
+		entries = append(entries, entry{name: hdr.Name, data: data, modTime: hdr.ModTime})
+	}
+
+	if err := s.clearSidecar(id); err != nil {
+		return err
+	}
+
+	dir, err := s.sidecarDir(id)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := s.writeSidecarFile(id, e.name, e.data); err != nil {
+			return err
+		}
+		if err := os.Chtimes(dir+"/"+e.name, e.modTime, e.modTime); err != nil {
+			return err
+		}
+	}
+
+	return s.reloadLabels(id)
+}
+
+// sidecarChecksum returns size and hex encoded sha256 of sidecar
+// archive of the file, zero if it has no persistent sidecar files.
+func (s *Storage) sidecarChecksum(id int) (int64, string, error) {
+	names, err := s.persistentFiles(id)
+	if err != nil || len(names) == 0 {
+		return 0, "", err
+	}
+
+	h := sha256.New()
+	cw := &countingWriter{w: h}
+	if err := s.writeSidecarArchive(id, cw); err != nil {
+		return 0, "", err
+	}
+
+	return cw.n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// OpenSidecar returns tar archive of sidecar files
+// holding state of the file given by clients.
+func (s *Storage) OpenSidecar(ctx context.Context, id int) (io.ReadCloser, error) {
+	const op = "Storage.OpenSidecar"
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return nil, service.ErrFileNotExist
+	}
+
+	var buf bytes.Buffer
+	if err := s.writeSidecarArchive(id, &buf); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return io.NopCloser(&buf), nil
+}
+
+// PutSidecar replaces sidecar files holding state
+// of the file with ones of archive made by OpenSidecar.
+//
+// Like Put, it is allowed for read-only storage,
+// since replicas apply changes with it.
+func (s *Storage) PutSidecar(ctx context.Context, id int, r io.Reader) error {
+	const op = "Storage.PutSidecar"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	ok, err := s.checkExistingID(id)
+	if err != nil {
+		log.Error("failed to check existing id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		log.Warn("file not exists")
+		return service.ErrFileNotExist
+	}
+
+	if err := s.readSidecarArchive(id, r); err != nil {
+		if errors.Is(err, service.ErrInvalidArchive) {
+			log.Warn("invalid sidecar archive", sl.Err(err))
+			return err
+		}
+		log.Error("failed to write sidecar", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.record(models.OpSidecar, id); err != nil {
+		log.Error("failed to record sidecar", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("put sidecar")
+
+	return nil
+}
+
+// ImportSidecar replaces sidecar files of the file
+// like PutSidecar, but is rejected by read-only storage.
+func (s *Storage) ImportSidecar(ctx context.Context, id int, r io.Reader) error {
+	if s.readOnly {
+		s.log.Warn("import to read-only storage", slog.String("op", "Storage.ImportSidecar"), slog.Int("id", id))
+		return service.ErrReadOnly
+	}
+
+	return s.PutSidecar(ctx, id, r)
+}
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/fingerprint"
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/internal/service"
+)
+
+func requireAttachments(t *testing.T, s *Storage, id int, expected map[string][]byte) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	list, err := s.Attachments(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, list, len(expected))
+
+	for name, data := range expected {
+		a, err := s.Attachment(ctx, id, name)
+		require.NoError(t, err)
+		require.Equal(t, data, a.Data)
+	}
+}
+
+func TestSnapshotAttachments(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestStorage(t)
+	putTestFile(t, s, 7, []byte("seven"))
+
+	_, err := s.PutAttachment(ctx, 7, "notes.txt", []byte("notes"))
+	require.NoError(t, err)
+
+	snapshot, err := s.CreateSnapshot(ctx, "snap")
+	require.NoError(t, err)
+	require.Equal(t, int64(len("seven")+len("notes")), snapshot.Size)
+
+	// Changes after snapshot are reverted by restore.
+	_, err = s.PutAttachment(ctx, 7, "notes.txt", []byte("changed"))
+	require.NoError(t, err)
+	_, err = s.PutAttachment(ctx, 7, "cover.txt", []byte("cover"))
+	require.NoError(t, err)
+
+	_, err = s.RestoreSnapshot(ctx, "snap", nil)
+	require.NoError(t, err)
+	requireAttachments(t, s, 7, map[string][]byte{"notes.txt": []byte("notes")})
+
+	// Deleted file gets its attachments back.
+	require.NoError(t, s.Delete(ctx, 7))
+	_, err = s.RestoreSnapshot(ctx, "snap", []int{7})
+	require.NoError(t, err)
+	requireAttachments(t, s, 7, map[string][]byte{"notes.txt": []byte("notes")})
+}
+
+func TestBackupAttachments(t *testing.T) {
+	ctx := context.Background()
+
+	src := newTestStorage(t)
+	putTestFile(t, src, 7, []byte("seven"))
+	putTestFile(t, src, 8, []byte("eight"))
+
+	_, err := src.PutAttachment(ctx, 7, "notes.txt", []byte("notes"))
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	manifest, err := src.Backup(ctx, &archive, models.BackupFilter{})
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 2)
+	require.NotEmpty(t, manifest.Files[0].SidecarSHA256)
+	require.Empty(t, manifest.Files[1].SidecarSHA256)
+
+	dst := newTestStorage(t)
+	putTestFile(t, dst, 8, []byte("other"))
+	_, err = dst.PutAttachment(ctx, 8, "stale.txt", []byte("stale"))
+	require.NoError(t, err)
+
+	_, err = dst.Restore(ctx, bytes.NewReader(archive.Bytes()), ConflictOverwrite)
+	require.NoError(t, err)
+
+	requireAttachments(t, dst, 7, map[string][]byte{"notes.txt": []byte("notes")})
+	// Attachments of overwritten file are replaced too.
+	requireAttachments(t, dst, 8, map[string][]byte{})
+}
+
+func TestSidecarRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	src := newTestStorage(t)
+	putTestFile(t, src, 7, []byte("seven"))
+
+	_, err := src.PutAttachment(ctx, 7, "notes.txt", []byte("notes"))
+	require.NoError(t, err)
+
+	r, err := src.OpenSidecar(ctx, 7)
+	require.NoError(t, err)
+	archive, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	dst := newTestStorage(t)
+	err = dst.PutSidecar(ctx, 7, bytes.NewReader(archive))
+	require.ErrorIs(t, err, service.ErrFileNotExist)
+
+	putTestFile(t, dst, 7, []byte("seven"))
+	require.NoError(t, dst.PutSidecar(ctx, 7, bytes.NewReader(archive)))
+	requireAttachments(t, dst, 7, map[string][]byte{"notes.txt": []byte("notes")})
+
+	// Same state gives same checksum.
+	_, srcSum, err := src.sidecarChecksum(7)
+	require.NoError(t, err)
+	_, dstSum, err := dst.sidecarChecksum(7)
+	require.NoError(t, err)
+	require.Equal(t, srcSum, dstSum)
+
+	err = dst.PutSidecar(ctx, 7, bytes.NewReader([]byte("not a tar archive")))
+	require.ErrorIs(t, err, service.ErrInvalidArchive)
+	requireAttachments(t, dst, 7, map[string][]byte{"notes.txt": []byte("notes")})
+}
+
+func requireFound(t *testing.T, s *Storage, selector string, expected ...int) {
+	t.Helper()
+
+	found, err := s.Find(context.Background(), models.FindQuery{Selector: selector})
+	require.NoError(t, err)
+
+	var ids []int
+	for _, f := range found {
+		ids = append(ids, f.FileID)
+	}
+	require.Equal(t, expected, ids)
+}
+
+func TestRestoreLabels(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestStorage(t)
+	putTestFile(t, s, 7, []byte("seven"))
+	putTestFile(t, s, 8, []byte("eight"))
+
+	require.NoError(t, s.SetLabels(ctx, 7, map[string]string{"type": "jingle"}))
+	requireFound(t, s, "type=jingle", 7)
+
+	_, err := s.CreateSnapshot(ctx, "snap")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	_, err = s.Backup(ctx, &archive, models.BackupFilter{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetLabels(ctx, 7, nil))
+	require.NoError(t, s.SetLabels(ctx, 8, map[string]string{"type": "ad"}))
+	requireFound(t, s, "type=jingle")
+
+	// Snapshot restore brings labels back to index.
+	_, err = s.RestoreSnapshot(ctx, "snap", nil)
+	require.NoError(t, err)
+	requireFound(t, s, "type=jingle", 7)
+	requireFound(t, s, "type=ad")
+
+	l, err := s.Labels(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"type": "jingle"}, l)
+
+	// So does backup restore.
+	dst := newTestStorage(t)
+	putTestFile(t, dst, 8, []byte("other"))
+	require.NoError(t, dst.SetLabels(ctx, 8, map[string]string{"type": "ad"}))
+	requireFound(t, dst, "type", 8)
+
+	_, err = dst.Restore(ctx, bytes.NewReader(archive.Bytes()), ConflictOverwrite)
+	require.NoError(t, err)
+	requireFound(t, dst, "type", 7)
+}
+
+func TestRestoreExpiryAndPin(t *testing.T) {
+	ctx := context.Background()
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	requireState := func(s *Storage) {
+		t.Helper()
+
+		at, err := s.Expiry(ctx, 7)
+		require.NoError(t, err)
+		require.True(t, expiresAt.Equal(at))
+
+		pinned, err := s.Pinned(ctx, 8)
+		require.NoError(t, err)
+		require.True(t, pinned)
+	}
+
+	s := newTestStorage(t)
+	putTestFile(t, s, 7, []byte("seven"))
+	putTestFile(t, s, 8, []byte("eight"))
+
+	require.NoError(t, s.SetExpiry(ctx, 7, expiresAt))
+	require.NoError(t, s.SetPinned(ctx, 8, true))
+
+	_, err := s.CreateSnapshot(ctx, "snap")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	_, err = s.Backup(ctx, &archive, models.BackupFilter{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetExpiry(ctx, 7, time.Time{}))
+	require.NoError(t, s.SetPinned(ctx, 8, false))
+
+	_, err = s.RestoreSnapshot(ctx, "snap", nil)
+	require.NoError(t, err)
+	requireState(s)
+
+	dst := newTestStorage(t)
+	_, err = dst.Restore(ctx, bytes.NewReader(archive.Bytes()), ConflictFail)
+	require.NoError(t, err)
+	requireState(dst)
+}
+
+func TestReplaceForgetsFingerprint(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestStorage(t)
+	putTestFile(t, s, 7, []byte("seven"))
+
+	_, err := s.CreateSnapshot(ctx, "snap")
+	require.NoError(t, err)
+
+	index, err := s.fingerprintIndex(ctx)
+	require.NoError(t, err)
+
+	// Fingerprint of replaced file is dropped from index.
+	index.Add(7, fingerprint.Fingerprint{1, 2, 3})
+	require.NoError(t, s.Put(ctx, 7, bytes.NewReader(mp3test.Silence(3))))
+	require.Zero(t, index.Len())
+
+	index.Add(7, fingerprint.Fingerprint{1, 2, 3})
+	_, err = s.RestoreSnapshot(ctx, "snap", nil)
+	require.NoError(t, err)
+	require.Zero(t, index.Len())
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	snapshotsDir      = "snapshots"
+	snapshotInfoName  = "snapshot.json"
+	snapshotTmpPrefix = ".tmp-"
+)
+
+var snapshotNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// CreateSnapshot hardlinks current storage tree into named snapshot
+// along with sidecar files holding state of stored files.
+//
+// Stored files are never modified in place,
+// so hardlinks are enough to preserve their content.
+func (s *Storage) CreateSnapshot(ctx context.Context, name string) (models.Snapshot, error) {
+	const op = "Storage.CreateSnapshot"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("name", name),
+	)
+
+	if !snapshotNameRe.MatchString(name) {
+		log.Warn("invalid snapshot name")
+		return models.Snapshot{}, service.ErrInvalidSnapshotName
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	snapDir := s.snapshotDir(name)
+
+	if _, err := os.Stat(snapDir); err == nil {
+		log.Warn("snapshot already exists")
+		return models.Snapshot{}, service.ErrSnapshotExists
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Error("failed to probe snapshot", sl.Err(err))
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Build snapshot aside, so incomplete one is never listed.
+	tmpDir := s.dir + "/" + snapshotsDir + "/" + snapshotTmpPrefix + name
+	if err := os.RemoveAll(tmpDir); err != nil {
+		log.Error("failed to clean temporary dir", sl.Err(err))
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	snapshot := models.Snapshot{
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.walk(ctx, func(id int, filename string) error {
+		dir, err := s.getCorrespondingDir(id)
+		if err != nil {
+			return err
+		}
+
+		targetDir := tmpDir + strings.TrimPrefix(dir, s.dir)
+		if err := os.MkdirAll(targetDir, 0777); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Link(filename, targetDir+"/"+path.Base(filename)); err != nil {
+			return err
+		}
+
+		sidecarSize, err := s.linkSidecar(id, tmpDir)
+		if err != nil {
+			return err
+		}
+
+		snapshot.Files++
+		snapshot.Size += info.Size() + sidecarSize
+
+		return nil
+	}); err != nil {
+		log.Error("failed to link files", sl.Err(err))
+		os.RemoveAll(tmpDir)
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(tmpDir, 0777); err != nil {
+		log.Error("failed to create snapshot dir", sl.Err(err))
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := os.WriteFile(tmpDir+"/"+snapshotInfoName, data, 0644); err != nil {
+		log.Error("failed to write snapshot info", sl.Err(err))
+		os.RemoveAll(tmpDir)
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmpDir, snapDir); err != nil {
+		log.Error("failed to move snapshot", sl.Err(err))
+		os.RemoveAll(tmpDir)
+		return models.Snapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Snapshot info counts in usage.
+	s.invalidateServiceUsage()
+
+	log.Info("created snapshot", slog.Int("files", snapshot.Files), slog.Int64("size", snapshot.Size))
+
+	return snapshot, nil
+}
+
+// ListSnapshots returns all snapshots ordered by creation time.
+func (s *Storage) ListSnapshots(ctx context.Context) ([]models.Snapshot, error) {
+	const op = "Storage.ListSnapshots"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	entries, err := os.ReadDir(s.dir + "/" + snapshotsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []models.Snapshot{}, nil
+		}
+		log.Error("failed to read snapshots dir", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	snapshots := make([]models.Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || !snapshotNameRe.MatchString(entry.Name()) {
+			continue
+		}
+
+		snapshot, err := s.readSnapshotInfo(entry.Name())
+		if err != nil {
+			log.Error("failed to read snapshot info", slog.String("name", entry.Name()), sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes snapshot by its name.
+func (s *Storage) DeleteSnapshot(ctx context.Context, name string) error {
+	const op = "Storage.DeleteSnapshot"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("name", name),
+	)
+
+	if !snapshotNameRe.MatchString(name) {
+		log.Warn("invalid snapshot name")
+		return service.ErrInvalidSnapshotName
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	snapDir := s.snapshotDir(name)
+
+	if _, err := os.Stat(snapDir); errors.Is(err, os.ErrNotExist) {
+		log.Warn("snapshot not exists")
+		return service.ErrSnapshotNotExist
+	}
+
+	err := os.RemoveAll(snapDir)
+	// Even partially deleted snapshot frees space.
+	s.invalidateServiceUsage()
+	if err != nil {
+		log.Error("failed to delete snapshot", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("deleted snapshot")
+
+	return nil
+}
+
+// RestoreSnapshot puts files from snapshot back to the storage,
+// replacing current versions along with their sidecar state.
+// If ids are empty, all snapshot files are restored.
+// Files created after the snapshot are kept.
+//
+// Returns number of restored files.
+func (s *Storage) RestoreSnapshot(ctx context.Context, name string, ids []int) (int, error) {
+	const op = "Storage.RestoreSnapshot"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("name", name),
+	)
+
+	if s.readOnly {
+		log.Warn("restore to read-only storage")
+		return 0, service.ErrReadOnly
+	}
+
+	if !snapshotNameRe.MatchString(name) {
+		log.Warn("invalid snapshot name")
+		return 0, service.ErrInvalidSnapshotName
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	snapDir := s.snapshotDir(name)
+
+	if _, err := os.Stat(snapDir); errors.Is(err, os.ErrNotExist) {
+		log.Warn("snapshot not exists")
+		return 0, service.ErrSnapshotNotExist
+	}
+
+	restore := func(id int, filename string) error {
+		dir, err := s.getCorrespondingDir(id)
+		if err != nil {
+			return err
+		}
+
+		// Link aside and rename over current file,
+		// so readers never see missing file.
+		tmp := dir + "/.snapshot-" + strconv.Itoa(id)
+		os.Remove(tmp)
+		if err := os.Link(filename, tmp); err != nil {
+			return err
+		}
+		_, f, _ := parseFilename(path.Base(filename))
+		restored, err := s.replaceFile(tmp, id, f)
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		// Rename is no-op if both names link the same file.
+		os.Remove(tmp)
+
+		if err := s.reloadFingerprint(id, restored); err != nil {
+			log.Warn("failed to reload fingerprint", slog.Int("id", id), sl.Err(err))
+		}
+
+		if err := s.restoreSidecar(id, snapDir); err != nil {
+			return err
+		}
+
+		if err := s.record(models.OpUpload, id); err != nil {
+			return err
+		}
+		return s.record(models.OpSidecar, id)
+	}
+
+	restored := 0
+
+	if len(ids) == 0 {
+		if err := s.walkDir(ctx, snapDir, 0, func(id int, filename string) error {
+			if err := restore(id, filename); err != nil {
+				return err
+			}
+			restored++
+			return nil
+		}); err != nil {
+			log.Error("failed to restore snapshot", sl.Err(err))
+			return restored, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		for _, id := range ids {
+			dir, err := s.getCorrespondingDir(id)
+			if err != nil {
+				return restored, fmt.Errorf("%s: %w", op, err)
+			}
+
+			filename, _, err := findFileIn(snapDir+strings.TrimPrefix(dir, s.dir), id)
+			if errors.Is(err, service.ErrFileNotExist) {
+				log.Warn("file not exists in snapshot", slog.Int("id", id))
+				return restored, err
+			} else if err != nil {
+				log.Error("failed to find file in snapshot", slog.Int("id", id), sl.Err(err))
+				return restored, fmt.Errorf("%s: %w", op, err)
+			}
+
+			if err := restore(id, filename); err != nil {
+				log.Error("failed to restore file", slog.Int("id", id), sl.Err(err))
+				return restored, fmt.Errorf("%s: %w", op, err)
+			}
+			restored++
+		}
+	}
+
+	log.Info("restored snapshot", slog.Int("restored", restored))
+
+	return restored, nil
+}
+
+func (s *Storage) snapshotDir(name string) string {
+	return s.dir + "/" + snapshotsDir + "/" + name
+}
+
+func (s *Storage) readSnapshotInfo(name string) (models.Snapshot, error) {
+	data, err := os.ReadFile(s.snapshotDir(name) + "/" + snapshotInfoName)
+	if err != nil {
+		return models.Snapshot{}, err
+	}
+
+	var snapshot models.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return models.Snapshot{}, err
+	}
+
+	return snapshot, nil
+}
@@ -8,11 +8,20 @@ import (
 	"log/slog"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	grpcModels "radio-storage/internal/domain/grpc"
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/fingerprint"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/labels"
 	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/mp3"
 	"radio-storage/internal/service"
 )
 
@@ -27,6 +36,73 @@ type Storage struct {
 
 	idLength int
 	maxId    int
+
+	snapshotMu sync.Mutex
+
+	journal  Journal
+	readOnly bool
+	formats  []format.Format
+
+	validation ValidationPolicy
+	analyzer   Analyzer
+
+	sumsMu sync.Mutex
+	sums   map[int]cachedSum
+
+	// fingerprints is nil until first search.
+	fingerprintsMu sync.Mutex
+	fingerprints   *fingerprint.Index
+
+	// labels are labels of labeled files by id,
+	// nil until first search.
+	labelsMu sync.Mutex
+	labels   map[int]map[string]string
+
+	quotas    *Quotas
+	namespace string
+
+	// retention is age files expire at, zero if they never do.
+	retention time.Duration
+
+	// storedUsage is nil until quota is checked.
+	usageMu     sync.Mutex
+	storedUsage *usage
+	// serviceUsage is size of snapshots and quarantine,
+	// counted again unless serviceUsageFresh.
+	serviceUsage      int64
+	serviceUsageFresh bool
+}
+
+// Journal records committed changes of the storage.
+type Journal interface {
+	Append(op models.ChangeOp, id int) (uint64, error)
+}
+
+// Option configures storage.
+type Option func(*Storage)
+
+// WithJournal makes storage record every
+// committed upload and delete to the journal.
+func WithJournal(j Journal) Option {
+	return func(s *Storage) {
+		s.journal = j
+	}
+}
+
+// WithReadOnly rejects client uploads and deletes.
+// Changes may still be applied with Put and Remove.
+func WithReadOnly() Option {
+	return func(s *Storage) {
+		s.readOnly = true
+	}
+}
+
+// WithFormats limits formats accepted by Upload.
+// By default all known formats are accepted.
+func WithFormats(formats ...format.Format) Option {
+	return func(s *Storage) {
+		s.formats = formats
+	}
 }
 
 func New(
@@ -34,6 +110,7 @@ func New(
 	dir string,
 	nestingDepth int,
 	idLength int,
+	opts ...Option,
 ) *Storage {
 	N := 1
 	for i := 0; i < idLength; i++ {
@@ -46,6 +123,11 @@ func New(
 		nestingDepth: nestingDepth,
 		idLength:     idLength,
 		maxId:        N,
+		sums:         make(map[int]cachedSum),
+	}
+
+	for _, opt := range opts {
+		opt(storage)
 	}
 
 	storage.mustInitFilesystem()
@@ -54,59 +136,234 @@ func New(
 }
 
 // Upload writes content from io.Reader to generated file,
-// returns its id.
-func (s *Storage) Upload(ctx context.Context, r *grpcModels.UploadStreamWrapper) (int, error) {
+// returns its id and content type.
+//
+// Format of the file is detected by its content.
+// File violating validation policy is rejected with ValidationError.
+func (s *Storage) Upload(ctx context.Context, r *grpcModels.UploadStreamWrapper) (int, string, error) {
 	const op = "Storage.Upload"
 
 	log := s.log.With(
 		slog.String("op", op),
 	)
 
+	if s.readOnly {
+		log.Warn("upload to read-only storage")
+		return 0, "", service.ErrReadOnly
+	}
+
+	adm := s.admission(r.Client, 1)
+	defer adm.release()
+
+	// Receive file aside, since its name
+	// depends on format known only after upload.
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		log.Error("failed to create temporary file", sl.Err(err))
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Labels are checked as they arrive, so invalid
+	// ones reject upload before anything is reserved.
+	next := func() ([]byte, error) {
+		chunk, err := r.GetChunk()
+		if err != nil {
+			return nil, err
+		}
+		if err := labels.Validate(r.Labels()); err != nil {
+			return nil, fmt.Errorf("%w: %w", service.ErrInvalidLabels, err)
+		}
+		return chunk, nil
+	}
+	if err := s.receive(ctx, log, adm, tmp, next, r.Size); err != nil {
+		if errors.Is(err, service.ErrInvalidLabels) {
+			log.Warn("invalid labels", sl.Err(err))
+			return 0, "", err
+		}
+		if exhausted(err) {
+			return 0, "", err
+		}
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+	fileLabels := r.Labels()
+
+	f, metadata, err := s.admit(log, tmp)
+	if err != nil {
+		if errors.Is(err, service.ErrFormatNotAllowed) || errors.As(err, new(*service.ValidationError)) {
+			return 0, "", err
+		}
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
 	// Generate new id.
 	id, err := s.generateNewID()
 	if err != nil {
 		log.Error("failed to generate new id", sl.Err(err))
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Construct new file.
-	dir, err := s.getCorrespondingDir(id)
-	if err != nil {
-		log.Error("failed to get corresponding dir", sl.Err(err))
-		return 0, fmt.Errorf("%s: %w", op, err)
+	// Client is recorded before the file appears,
+	// so the file is counted in usage of the client.
+	if r.Client != "" {
+		if err := s.writeSidecar(id, clientName, r.Client); err != nil {
+			log.Error("failed to write client", slog.Int("id", id), sl.Err(err))
+			s.removeSidecar(id)
+			return 0, "", fmt.Errorf("%s: %w", op, err)
+		}
 	}
-	filename := dir + "/" + strconv.Itoa(id) + ".mp3"
 
-	file, err := os.Create(filename)
+	filename, err := s.replaceFile(tmp.Name(), id, f)
 	if err != nil {
-		log.Error("failed to create new file", slog.String("file", filename), sl.Err(err))
-		return 0, fmt.Errorf("%s: %w", op, err)
+		log.Error("failed to move file", slog.Int("id", id), sl.Err(err))
+		s.removeSidecar(id)
+		return 0, "", fmt.Errorf("%s: %w", op, err)
 	}
-	defer file.Close()
 
-	// Load data
+	if len(fileLabels) != 0 {
+		if err := s.writeLabels(id, fileLabels); err != nil {
+			log.Error("failed to write labels", slog.Int("id", id), sl.Err(err))
+			s.removeFiles(id)
+			s.removeSidecar(id)
+			return 0, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if expiresAt := r.ExpiresAt(); !expiresAt.IsZero() {
+		if err := s.writeExpiry(id, expiresAt); err != nil {
+			log.Error("failed to write expiry", slog.Int("id", id), sl.Err(err))
+			s.removeFiles(id)
+			s.removeSidecar(id)
+			s.forgetLabels(id)
+			return 0, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.record(models.OpUpload, id); err != nil {
+		log.Error("failed to record upload", slog.Int("id", id), sl.Err(err))
+		s.removeFiles(id)
+		s.removeSidecar(id)
+		s.forgetLabels(id)
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if len(fileLabels) != 0 || !r.ExpiresAt().IsZero() {
+		if err := s.record(models.OpSidecar, id); err != nil {
+			log.Error("failed to record sidecar", slog.Int("id", id), sl.Err(err))
+			s.removeFiles(id)
+			s.removeSidecar(id)
+			s.forgetLabels(id)
+			return 0, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.writeIndex(id, filename, metadata); err != nil {
+		log.Warn("failed to index file", slog.Int("id", id), sl.Err(err))
+	}
+
+	s.enqueue(id)
+
+	log.Debug("uploaded file", slog.Int("id", id), slog.String("format", f.Name))
+
+	return id, f.ContentType, nil
+}
+
+// receive writes chunks returned by next to tmp until io.EOF,
+// reserving quota for them first. Expected size sent by
+// client, if any, is reserved at once.
+func (s *Storage) receive(ctx context.Context, log *slog.Logger, adm *admission, tmp *os.File, next func() ([]byte, error), expected func() int64) error {
+	reserve := func(size int64) error {
+		if err := adm.reserve(ctx, size); err != nil {
+			if exhausted(err) {
+				log.Warn("upload rejected", sl.Err(err))
+				return err
+			}
+			log.Error("failed to check quota", sl.Err(err))
+			return err
+		}
+		return nil
+	}
+
+	var written int64
 	for {
-		chunk, err := r.GetChunk()
+		chunk, err := next()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			return 0, fmt.Errorf("%s: %w", op, err)
+			return err
 		}
 
-		if _, err := file.Write(chunk); err != nil {
+		// Expected size lets upload exceeding quota
+		// be rejected before its data is written.
+		if err := reserve(max(written+int64(len(chunk)), expected())); err != nil {
+			return err
+		}
+
+		if _, err := tmp.Write(chunk); err != nil {
 			log.Error("failed to write chunk", sl.Err(err))
-			return 0, fmt.Errorf("%s: %w", op, err)
+			return err
 		}
+		written += int64(len(chunk))
+	}
+
+	// Empty upload is admitted as well.
+	return reserve(written)
+}
+
+// admit detects format of received file and checks it against
+// allowed formats and validation policy, closing the file.
+//
+// Rejected file is quarantined if policy says so.
+func (s *Storage) admit(log *slog.Logger, tmp *os.File) (format.Format, models.Metadata, error) {
+	f, err := format.Sniff(tmp)
+	if err != nil {
+		log.Error("failed to detect format", sl.Err(err))
+		return format.Format{}, models.Metadata{}, err
+	}
+	if !s.allowedFormat(f) {
+		log.Warn("format is not allowed", slog.String("format", f.Name))
+		return format.Format{}, models.Metadata{}, service.ErrFormatNotAllowed
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return format.Format{}, models.Metadata{}, err
+	}
+
+	metadata, audio, err := analyze(tmp, info.Size(), f)
+	if err != nil {
+		log.Error("failed to analyze file", sl.Err(err))
+		return format.Format{}, models.Metadata{}, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return format.Format{}, models.Metadata{}, err
 	}
 
-	log.Debug("uploaded file", slog.Int("id", id))
+	if violations := s.validation.check(info.Size(), f, metadata, audio); len(violations) != 0 {
+		log.Warn("file violates validation policy", slog.Any("violations", violations))
 
-	return id, nil
+		if s.validation.Quarantine {
+			name, err := s.quarantine(tmp.Name(), f, violations)
+			if err != nil {
+				log.Error("failed to quarantine file", sl.Err(err))
+			} else {
+				log.Info("quarantined file", slog.String("name", name))
+			}
+		}
+
+		return format.Format{}, models.Metadata{}, &service.ValidationError{Violations: violations}
+	}
+
+	return f, metadata, nil
 }
 
 // Download writes file to io.Writer.
-func (s *Storage) Download(ctx context.Context, id int, w *grpcModels.DownloadStreamWrapper) error {
+//
+// Unless original output is requested,
+// audio is decoded to PCM on the fly.
+func (s *Storage) Download(ctx context.Context, id int, opts models.DownloadOptions, w *grpcModels.DownloadStreamWrapper) error {
 	const op = "Storage.Download"
 
 	log := s.log.With(
@@ -114,24 +371,25 @@ func (s *Storage) Download(ctx context.Context, id int, w *grpcModels.DownloadSt
 		slog.Int("id", id),
 	)
 
-	// Check if file exists.
-	ok, err := s.checkExistingID(id)
-	if err != nil {
-		log.Error("failed to check existing id", sl.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if !ok {
-		log.Warn("file not exists")
-		return service.ErrFileNotExist
+	if err := checkDownloadOptions(opts); err != nil {
+		log.Warn("invalid download options", sl.Err(err))
+		return err
 	}
 
-	// Construct path to the file.
-	dir, err := s.getCorrespondingDir(id)
+	filename, f, err := s.findFile(id)
 	if err != nil {
-		log.Error("failed to get corresponding dir", sl.Err(err))
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return err
+		}
+		log.Error("failed to find file", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	filename := dir + "/" + strconv.Itoa(id) + ".mp3"
+
+	if (opts.Output != models.OutputOriginal || opts.TrimSilence || opts.StripArtwork || opts.TagMode != models.TagsKeep) && f != format.MP3 {
+		log.Warn("conversion is not supported", slog.String("format", f.Name))
+		return service.ErrConversionUnsupported
+	}
 
 	log.Debug("download file", slog.Int("id", id))
 
@@ -143,17 +401,87 @@ func (s *Storage) Download(ctx context.Context, id int, w *grpcModels.DownloadSt
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		log.Error("failed to probe file", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Tags are rewritten first, so silence
+	// is trimmed from rewritten file.
+	var (
+		src  io.ReaderAt = file
+		size             = info.Size()
+	)
+	if opts.TagMode != models.TagsKeep {
+		var tag []byte
+		if opts.TagMode == models.TagsReplace {
+			tag = mp3.BuildID3v2(mp3.Tags{
+				Title:  opts.Tags.Title,
+				Artist: opts.Tags.Artist,
+				Album:  opts.Tags.Album,
+				Year:   opts.Tags.Year,
+			})
+		}
+		if src, size, err = mp3.RewriteTags(file, size, tag); err != nil {
+			log.Error("failed to rewrite tags", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var (
+		r           io.Reader     = io.NewSectionReader(src, 0, size)
+		input       io.ReadSeeker = file
+		contentType               = f.ContentType
+	)
+	if opts.TrimSilence {
+		trimmed, err := s.trimSilence(id, src, size)
+		if err != nil {
+			if errors.Is(err, service.ErrDecodeFailed) {
+				log.Warn("failed to trim silence", sl.Err(err))
+				return err
+			}
+			log.Error("failed to trim silence", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		// Decoder seeks its input, so it reads
+		// trimmed file on its own.
+		r = trimmed
+		input = io.NewSectionReader(trimmed, 0, trimmed.Size())
+	}
+	if opts.StripArtwork {
+		if r, err = stripArtwork(r); err != nil {
+			log.Error("failed to strip artwork", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if opts.Output != models.OutputOriginal {
+		if r, contentType, err = s.decode(ctx, id, input, opts); err != nil {
+			if errors.Is(err, service.ErrDecodeFailed) {
+				log.Warn("failed to decode file", sl.Err(err))
+				return err
+			}
+			log.Error("failed to prepare decoding", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	w.SetContentType(contentType)
+
 	// Copy data.
 	buffer := make([]byte, bufferLen)
 	for {
-		p, err := file.Read(buffer)
+		p, err := r.Read(buffer)
+		if p > 0 {
+			if err := w.Write(buffer[:p]); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			return fmt.Errorf("%s: %w", op, err)
-		}
-		if err := w.Write(buffer[:p]); err != nil {
+			log.Error("failed to read file", sl.Err(err))
 			return fmt.Errorf("%s: %w", op, err)
 		}
 	}
@@ -174,6 +502,11 @@ func (s *Storage) Delete(ctx context.Context, id int) error {
 		slog.Int("id", id),
 	)
 
+	if s.readOnly {
+		log.Warn("delete from read-only storage")
+		return service.ErrReadOnly
+	}
+
 	// Check if file exists.
 	ok, err := s.checkExistingID(id)
 	if err != nil {
@@ -187,17 +520,20 @@ func (s *Storage) Delete(ctx context.Context, id int) error {
 
 	log.Debug("deleting file")
 
-	// Construct path to the file.
-	dir, err := s.getCorrespondingDir(id)
-	if err != nil {
-		log.Error("failed to get corresponding dir", sl.Err(err))
+	// Delete file
+	if err := s.removeFiles(id); err != nil {
+		log.Error("failed to delete file", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	file := dir + "/" + strconv.Itoa(id) + ".mp3"
 
-	// Delete file
-	if err := os.Remove(file); err != nil {
-		log.Error("failed to delete file", slog.String("file", file), sl.Err(err))
+	if err := s.removeSidecar(id); err != nil {
+		log.Warn("failed to delete sidecar", sl.Err(err))
+	}
+	s.forgetFingerprint(id)
+	s.forgetLabels(id)
+
+	if err := s.record(models.OpDelete, id); err != nil {
+		log.Error("failed to record delete", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -206,6 +542,13 @@ func (s *Storage) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// ReservedName reports whether name is taken by service
+// directory in the root of storage, so directories of other
+// storages nested in the root can not be named by it.
+func ReservedName(name string) bool {
+	return name == snapshotsDir || name == quarantineDir
+}
+
 // mustinitFileSystem inits file system.
 // Creates necessary directories.
 //
@@ -241,6 +584,18 @@ func (s *Storage) mustInitFilesystem() {
 			panic("failed to create dir")
 		}
 	}
+
+	// Temporary files of uploads interrupted by crash.
+	// Downloads no longer create them, but older versions did.
+	for _, pattern := range []string{".upload-*", ".download-*"} {
+		// Pattern is valid, so Glob never fails.
+		names, _ := filepath.Glob(s.dir + "/" + pattern)
+		for _, name := range names {
+			if err := os.Remove(name); err != nil {
+				log.Warn("failed to remove temporary file", slog.String("file", name), sl.Err(err))
+			}
+		}
+	}
 }
 
 func (s *Storage) generateNewID() (int, error) {
@@ -288,7 +643,7 @@ func (s *Storage) getCorrespondingDir(id int) (string, error) {
 
 	splitted := make([]string, s.nestingDepth)
 
-	for j := 0; j < s.idLength-len(str); j++ {
+	for j := 0; j < s.idLength-len(str) && j < s.nestingDepth; j++ {
 		splitted[j] = "0"
 	}
 	for j := s.idLength - len(str); j < s.nestingDepth; j++ {
@@ -305,20 +660,179 @@ func (s *Storage) checkExistingID(id int) (bool, error) {
 		slog.String("op", op),
 	)
 
+	if _, _, err := s.findFile(id); err == nil {
+		return true, nil
+	} else if errors.Is(err, service.ErrFileNotExist) {
+		return false, nil
+	} else {
+		log.Error("failed to probe file", slog.Int("id", id), sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+// findFile returns path and format of stored file with given id.
+func (s *Storage) findFile(id int) (string, format.Format, error) {
 	dir, err := s.getCorrespondingDir(id)
 	if err != nil {
-		log.Error("failed to get dir", slog.Int("id", id), sl.Err(err))
-		return false, fmt.Errorf("%s: %w", op, err)
+		return "", format.Format{}, err
 	}
 
-	file := dir + "/" + strconv.Itoa(id) + ".mp3"
+	return findFileIn(dir, id)
+}
 
-	if _, err := os.Stat(file); err == nil {
-		return true, nil
-	} else if errors.Is(err, os.ErrNotExist) {
-		return false, nil
-	} else {
-		log.Error("failed to probe file", slog.String("file", file), sl.Err(err))
-		return false, fmt.Errorf("%s: %w", op, err)
+// findFileIn looks up file with given id in directory
+// trying extensions of all known formats.
+func findFileIn(dir string, id int) (string, format.Format, error) {
+	for _, f := range format.All() {
+		filename := dir + "/" + fileName(id, f)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, f, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", format.Format{}, err
+		}
+	}
+
+	return "", format.Format{}, service.ErrFileNotExist
+}
+
+// replaceFile moves file in place of stored file with given id,
+// removing its versions in other formats.
+func (s *Storage) replaceFile(tmp string, id int, f format.Format) (string, error) {
+	dir, err := s.getCorrespondingDir(id)
+	if err != nil {
+		return "", err
 	}
+
+	filename := dir + "/" + fileName(id, f)
+
+	if err := s.account(id, func() error {
+		if err := os.Rename(tmp, filename); err != nil {
+			return err
+		}
+
+		for _, other := range format.All() {
+			if other.Ext == f.Ext {
+				continue
+			}
+			if err := os.Remove(dir + "/" + fileName(id, other)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// removeFiles removes stored file with given id in any format.
+func (s *Storage) removeFiles(id int) error {
+	dir, err := s.getCorrespondingDir(id)
+	if err != nil {
+		return err
+	}
+
+	return s.account(id, func() error {
+		for _, f := range format.All() {
+			if err := os.Remove(dir + "/" + fileName(id, f)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) allowedFormat(f format.Format) bool {
+	if len(s.formats) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.formats, f)
+}
+
+// walk calls fn for every stored file in ascending id order.
+func (s *Storage) walk(ctx context.Context, fn func(id int, filename string) error) error {
+	const op = "Storage.walk"
+
+	if err := s.walkDir(ctx, s.dir, 0, fn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) walkDir(ctx context.Context, dir string, depth int, fn func(id int, filename string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// Intermediate levels contain only single digit directories.
+	if depth < s.nestingDepth {
+		for _, entry := range entries {
+			if !entry.IsDir() || len(entry.Name()) != 1 || entry.Name()[0] < '0' || entry.Name()[0] > '9' {
+				continue
+			}
+			if err := s.walkDir(ctx, dir+"/"+entry.Name(), depth+1, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	names := make(map[int]string, len(entries))
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		id, _, ok := parseFilename(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		// File is being replaced with other format.
+		if _, ok := names[id]; ok {
+			continue
+		}
+		names[id] = entry.Name()
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		if err := fn(id, dir+"/"+names[id]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileName returns name of stored file.
+func fileName(id int, f format.Format) string {
+	return strconv.Itoa(id) + "." + f.Ext
+}
+
+// parseFilename extracts id and format from stored file name.
+func parseFilename(name string) (int, format.Format, bool) {
+	str, ext, ok := strings.Cut(name, ".")
+	if !ok {
+		return 0, format.Format{}, false
+	}
+
+	f, ok := format.ByExt(ext)
+	if !ok {
+		return 0, format.Format{}, false
+	}
+
+	id, err := strconv.Atoi(str)
+	if err != nil || id < 0 || strconv.Itoa(id) != str {
+		return 0, format.Format{}, false
+	}
+
+	return id, f, true
 }
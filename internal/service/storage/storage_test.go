@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"io"
 	"log/slog"
 	"os"
 	"sort"
@@ -86,6 +87,13 @@ func TestGetCurrentDir(t *testing.T) {
 			expectValue: tmpDir + "/1/0/2/3",
 			expectError: nil,
 		},
+		{
+			desc:        "id shorter than padding",
+			id:          10,
+			idLength:    8,
+			expectValue: tmpDir + "/0/0/0/0",
+			expectError: nil,
+		},
 		{
 			desc:        "negative id",
 			id:          -21,
@@ -119,3 +127,27 @@ func TestGetCurrentDir(t *testing.T) {
 		})
 	}
 }
+
+func TestReservedName(t *testing.T) {
+	for _, name := range []string{snapshotsDir, quarantineDir} {
+		assert.True(t, ReservedName(name), name)
+	}
+	assert.False(t, ReservedName("jingles"))
+}
+
+func TestRemoveTemporary(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".upload-1", ".download-2", "keep"} {
+		assert.NoError(t, os.WriteFile(dir+"/"+name, nil, 0644))
+	}
+
+	New(slog.New(slog.NewTextHandler(io.Discard, nil)), dir, 2, 5)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), "."), entry.Name())
+	}
+	_, err = os.Stat(dir + "/keep")
+	assert.NoError(t, err)
+}
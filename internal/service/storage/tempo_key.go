@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/musical"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/service"
+)
+
+const tempoKeyName = "tempo_key.json"
+
+// TempoKey returns estimated tempo and key of the file.
+//
+// Returns service.ErrNotAnalyzed if file was not
+// analyzed since it was last changed.
+func (s *Storage) TempoKey(ctx context.Context, id int) (models.TempoKey, error) {
+	const op = "Storage.TempoKey"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	res, err := analysisResult[models.TempoKey](s, id, tempoKeyName)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return models.TempoKey{}, err
+		}
+		if errors.Is(err, service.ErrNotAnalyzed) {
+			return models.TempoKey{}, err
+		}
+		log.Error("failed to read tempo and key", sl.Err(err))
+		return models.TempoKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// AnalyzeTempoKey decodes the file, estimates its tempo
+// and musical key and stores result in the sidecar.
+func (s *Storage) AnalyzeTempoKey(ctx context.Context, id int) (models.TempoKey, error) {
+	a := &tempoKeyAnalysis{}
+	if err := s.analyzeAudio(ctx, id, a); err != nil {
+		return models.TempoKey{}, err
+	}
+
+	return a.res, nil
+}
+
+// tempoKeyAnalysis estimates tempo and key of the file.
+type tempoKeyAnalysis struct {
+	*musical.Analyzer
+	res models.TempoKey
+}
+
+func (a *tempoKeyAnalysis) start(f pcm.Format) (err error) {
+	// Tempo and key do not depend on stereo image,
+	// analyzer mixes channels down.
+	a.Analyzer, err = musical.NewAnalyzer(f.SampleRate, f.Channels)
+	return err
+}
+
+func (a *tempoKeyAnalysis) store(s *Storage, id int, info os.FileInfo) error {
+	m := a.Result()
+	a.res = models.TempoKey{BPM: m.BPM}
+	if m.HasKey {
+		a.res.Key = m.Key.String()
+		a.res.Camelot = m.Key.Camelot()
+	}
+
+	return writeAnalysis(s, id, tempoKeyName, info, a.res)
+}
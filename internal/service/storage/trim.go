@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/service"
+)
+
+// trimSilence returns reader of MP3 file with whole frames
+// of leading and trailing digital silence dropped.
+func (s *Storage) trimSilence(id int, file io.ReaderAt, size int64) (*io.SectionReader, error) {
+	r, trimmed, err := mp3.TrimSilence(file, size)
+	if err != nil {
+		if errors.Is(err, mp3.ErrNoFrames) {
+			return nil, fmt.Errorf("%w: %w", service.ErrDecodeFailed, err)
+		}
+		return nil, err
+	}
+
+	s.log.Debug(
+		"trimmed silence",
+		slog.Int("id", id),
+		slog.Int("leading_frames", trimmed.Leading),
+		slog.Int("trailing_frames", trimmed.Trailing),
+	)
+
+	return r, nil
+}
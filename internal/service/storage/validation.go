@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/mp3"
+)
+
+const (
+	quarantineDir = "quarantine"
+)
+
+// ValidationPolicy lists rules checked for uploaded files.
+// Zero values disable corresponding rules.
+//
+// Rules based on audio frames apply to MP3 files only.
+type ValidationPolicy struct {
+	MinSize int64
+	MaxSize int64
+	// MinSyncRatio is minimal share of audio data
+	// occupied by valid frames.
+	MinSyncRatio float64
+	SampleRates  []int
+	// Bitrates lists allowed bitrates in kbps.
+	// Average bitrate of VBR file must lie within their range.
+	Bitrates    []int
+	MaxDuration time.Duration
+
+	// Quarantine keeps rejected uploads for inspection.
+	Quarantine bool
+}
+
+// WithValidation makes Upload reject files violating policy.
+func WithValidation(policy ValidationPolicy) Option {
+	return func(s *Storage) {
+		s.validation = policy
+	}
+}
+
+// check returns rules violated by the file.
+func (p ValidationPolicy) check(size int64, f format.Format, metadata models.Metadata, audio mp3.Info) []models.Violation {
+	var violations []models.Violation
+
+	violate := func(rule, description string, args ...any) {
+		violations = append(violations, models.Violation{
+			Rule:        rule,
+			Description: fmt.Sprintf(description, args...),
+		})
+	}
+
+	if p.MinSize > 0 && size < p.MinSize {
+		violate("min_size", "file size %d is less than %d bytes", size, p.MinSize)
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		violate("max_size", "file size %d exceeds %d bytes", size, p.MaxSize)
+	}
+
+	if f != format.MP3 {
+		return violations
+	}
+
+	if p.MinSyncRatio > 0 {
+		ratio := 0.
+		if audio.AudioSize > 0 {
+			ratio = float64(audio.FrameBytes) / float64(audio.AudioSize)
+		}
+		if ratio < p.MinSyncRatio {
+			violate("min_sync_ratio", "only %.1f%% of audio data are valid frames, %.1f%% required", ratio*100, p.MinSyncRatio*100)
+		}
+	}
+
+	if len(p.SampleRates) > 0 && !slices.Contains(p.SampleRates, metadata.SampleRate) {
+		violate("sample_rates", "sample rate %d Hz is not allowed", metadata.SampleRate)
+	}
+
+	if len(p.Bitrates) > 0 {
+		switch metadata.BitrateMode {
+		case models.BitrateCBR:
+			if !slices.Contains(p.Bitrates, metadata.Bitrate) {
+				violate("bitrates", "bitrate %d kbps is not allowed", metadata.Bitrate)
+			}
+		default:
+			if metadata.Bitrate < slices.Min(p.Bitrates) || metadata.Bitrate > slices.Max(p.Bitrates) {
+				violate("bitrates", "average bitrate %d kbps is out of allowed range", metadata.Bitrate)
+			}
+		}
+	}
+
+	if p.MaxDuration > 0 && metadata.Duration > p.MaxDuration {
+		violate("max_duration", "duration %s exceeds %s", metadata.Duration.Round(time.Second), p.MaxDuration)
+	}
+
+	return violations
+}
+
+// quarantineReport is written next to quarantined file.
+type quarantineReport struct {
+	RejectedAt time.Time          `json:"rejected_at"`
+	Format     string             `json:"format"`
+	Violations []models.Violation `json:"violations"`
+}
+
+// quarantine moves rejected upload to quarantine dir
+// along with report of violated rules.
+//
+// Returns name of quarantined file.
+func (s *Storage) quarantine(filename string, f format.Format, violations []models.Violation) (string, error) {
+	dir := s.dir + "/" + quarantineDir
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	name := now.Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+
+	report, err := json.MarshalIndent(quarantineReport{
+		RejectedAt: now,
+		Format:     f.Name,
+		Violations: violations,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(dir+"/"+name+".json", report, 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(filename, dir+"/"+name+"."+f.Ext); err != nil {
+		os.Remove(dir + "/" + name + ".json")
+		return "", err
+	}
+	s.invalidateServiceUsage()
+
+	return name, nil
+}
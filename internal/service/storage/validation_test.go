@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/lib/mp3/mp3test"
+)
+
+func TestValidationPolicy(t *testing.T) {
+	policy := ValidationPolicy{
+		MinSize:      1000,
+		MaxSize:      100000,
+		MinSyncRatio: 0.9,
+		SampleRates:  []int{44100, 48000},
+		Bitrates:     []int{192, 320},
+		MaxDuration:  2 * time.Second,
+	}
+
+	rules := func(data []byte, f format.Format) []string {
+		metadata, audio, err := analyze(bytes.NewReader(data), int64(len(data)), f)
+		require.NoError(t, err)
+
+		res := make([]string, 0)
+		for _, v := range policy.check(int64(len(data)), f, metadata, audio) {
+			res = append(res, v.Rule)
+		}
+		return res
+	}
+
+	// 128 kbps frames.
+	require.Equal(t, []string{"bitrates"}, rules(mp3test.Silence(50), format.MP3))
+	require.Equal(t, []string{"bitrates", "max_duration"}, rules(mp3test.Silence(100), format.MP3))
+	require.Equal(t, []string{"min_size", "min_sync_ratio", "sample_rates", "bitrates"}, rules(nil, format.MP3))
+	require.Equal(t, []string{"min_sync_ratio", "bitrates"},
+		rules(append(mp3test.Silence(10), bytes.Repeat([]byte("<html>"), 200)...), format.MP3))
+	require.Equal(t, []string{"max_size"}, rules(make([]byte, 100001), format.WAV))
+
+	policy.Bitrates = append(policy.Bitrates, 128)
+	require.Empty(t, rules(mp3test.Silence(50), format.MP3))
+
+	require.Empty(t, ValidationPolicy{}.check(0, format.MP3, models.Metadata{}, mp3.Info{}))
+}
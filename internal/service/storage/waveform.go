@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/lib/pcm"
+	"radio-storage/internal/lib/waveform"
+	"radio-storage/internal/service"
+)
+
+const waveformName = "waveform.json"
+
+// waveformLevels are samples per pixel of cached waveforms,
+// each one is a multiple of the previous.
+var waveformLevels = []int{256, 1024, 4096}
+
+// waveformFile returns sidecar file name of waveform level.
+func waveformFile(samplesPerPixel int) string {
+	return "waveform-" + strconv.Itoa(samplesPerPixel) + ".dat"
+}
+
+// HasWaveform reports whether up to date
+// waveforms of the file are cached.
+func (s *Storage) HasWaveform(ctx context.Context, id int) (bool, error) {
+	const op = "Storage.HasWaveform"
+
+	ok, err := hasAnalysis[[]int](s, id, waveformName)
+	if err != nil && !errors.Is(err, service.ErrFileNotExist) {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, err
+}
+
+// Waveform returns waveform of the file with given samples per pixel,
+// which must be a multiple of the finest cached level. Zero selects
+// the finest level.
+//
+// Waveforms are computed if they are not cached yet.
+func (s *Storage) Waveform(ctx context.Context, id int, samplesPerPixel int) (*waveform.Waveform, error) {
+	const op = "Storage.Waveform"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("id", id),
+	)
+
+	if samplesPerPixel == 0 {
+		samplesPerPixel = waveformLevels[0]
+	}
+	if samplesPerPixel < 0 || samplesPerPixel%waveformLevels[0] != 0 {
+		log.Warn("invalid resolution", slog.Int("samples_per_pixel", samplesPerPixel))
+		return nil, service.ErrInvalidResolution
+	}
+
+	filename, f, err := s.findFile(id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotExist) {
+			log.Warn("file not exists")
+			return nil, err
+		}
+		log.Error("failed to find file", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if f != format.MP3 {
+		return nil, service.ErrConversionUnsupported
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		log.Error("failed to probe file", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	levels, ok, err := readAnalysis[[]int](s, id, waveformName, info)
+	if err != nil {
+		log.Warn("failed to read waveform index", sl.Err(err))
+	}
+	if !ok {
+		if err := s.AnalyzeWaveform(ctx, id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		levels = waveformLevels
+	}
+
+	// Coarsest cached level that divides requested one.
+	level := 0
+	for _, l := range levels {
+		if samplesPerPixel%l == 0 {
+			level = max(level, l)
+		}
+	}
+	if level == 0 {
+		return nil, service.ErrInvalidResolution
+	}
+
+	data, err := s.readSidecarFile(id, waveformFile(level))
+	if err != nil {
+		log.Error("failed to read waveform", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var w waveform.Waveform
+	if err := w.UnmarshalBinary(data); err != nil {
+		log.Error("failed to decode waveform", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return w.Resample(samplesPerPixel)
+}
+
+// AnalyzeWaveform decodes the file and caches
+// its waveforms at every level.
+func (s *Storage) AnalyzeWaveform(ctx context.Context, id int) error {
+	return s.analyzeAudio(ctx, id, &waveformAnalysis{})
+}
+
+// waveformAnalysis caches waveforms of the file.
+type waveformAnalysis struct {
+	*waveform.Builder
+}
+
+func (a *waveformAnalysis) start(f pcm.Format) (err error) {
+	// Waveform shows mixed down mono.
+	a.Builder, err = waveform.NewBuilder(f.SampleRate, f.Channels, waveformLevels[0])
+	return err
+}
+
+func (a *waveformAnalysis) store(s *Storage, id int, info os.FileInfo) error {
+	w := a.Waveform()
+
+	for _, level := range waveformLevels {
+		lw, err := w.Resample(level)
+		if err != nil {
+			return err
+		}
+
+		data, err := lw.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		if err := s.writeSidecarFile(id, waveformFile(level), data); err != nil {
+			return err
+		}
+	}
+
+	// Index is written last, so it never
+	// points to missing levels.
+	return writeAnalysis(s, id, waveformName, info, slices.Clone(waveformLevels))
+}
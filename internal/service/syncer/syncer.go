@@ -0,0 +1,424 @@
+package syncer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/logger/sl"
+	"radio-storage/internal/service"
+)
+
+const (
+	bufferLen = 1024 * 32
+)
+
+type LocalStorage interface {
+	List(ctx context.Context, fromID, toID int, fn func(info models.FileInfo) error) error
+	Open(ctx context.Context, id int) (io.ReadCloser, error)
+	Put(ctx context.Context, id int, r io.Reader) error
+	Remove(ctx context.Context, id int) error
+	OpenSidecar(ctx context.Context, id int) (io.ReadCloser, error)
+	PutSidecar(ctx context.Context, id int, r io.Reader) error
+}
+
+// Syncer compares local storage with peer instance
+// and transfers only missing or differing files.
+// Sidecar files holding state of the file are compared
+// and transferred along with it.
+type Syncer struct {
+	log   *slog.Logger
+	local LocalStorage
+}
+
+func New(
+	log *slog.Logger,
+	local LocalStorage,
+) *Syncer {
+	return &Syncer{
+		log:   log,
+		local: local,
+	}
+}
+
+// SyncPeer connects to peer by address and synchronizes with it.
+func (s *Syncer) SyncPeer(ctx context.Context, addr string, opts models.SyncOptions) (models.SyncStats, error) {
+	const op = "Syncer.SyncPeer"
+
+	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return models.SyncStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer cc.Close()
+
+	return s.Sync(ctx, cc, opts)
+}
+
+// Sync synchronizes local storage with peer.
+func (s *Syncer) Sync(ctx context.Context, cc grpc.ClientConnInterface, opts models.SyncOptions) (models.SyncStats, error) {
+	const op = "Syncer.Sync"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("direction", int(opts.Direction)),
+		slog.Bool("mirror", opts.Mirror),
+		slog.Bool("dry_run", opts.DryRun),
+	)
+
+	var stats models.SyncStats
+
+	if opts.Direction < models.SyncPull || opts.Direction > models.SyncBoth ||
+		(opts.Mirror && opts.Direction == models.SyncBoth) {
+		log.Warn("invalid sync options")
+		return stats, service.ErrInvalidSyncOptions
+	}
+
+	peer := peer{
+		files: ssov1.NewFileServiceClient(cc),
+		admin: ssov1.NewAdminServiceClient(cc),
+	}
+
+	// Collect both listings, ordered by id.
+	local := make([]models.FileInfo, 0)
+	if err := s.local.List(ctx, 0, 0, func(info models.FileInfo) error {
+		local = append(local, info)
+		return nil
+	}); err != nil {
+		log.Error("failed to list local files", sl.Err(err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	remote, err := peer.list(ctx)
+	if err != nil {
+		log.Error("failed to list peer files", sl.Err(err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("comparing storages", slog.Int("local", len(local)), slog.Int("remote", len(remote)))
+
+	// Target is missing file or its version on the other side,
+	// only differing parts of the file are transferred.
+	pull := func(info models.FileInfo, target *models.FileInfo) error {
+		stats.Pulled++
+		file, sidecar := differs(info, target)
+		if file {
+			stats.Bytes += info.Size
+		}
+		if opts.DryRun {
+			return nil
+		}
+		log.Info("pulling file", slog.Int("id", info.ID))
+		if file {
+			if err := s.pull(ctx, peer, info); err != nil {
+				return err
+			}
+		}
+		if sidecar {
+			return s.pullSidecar(ctx, peer, info.ID)
+		}
+		return nil
+	}
+	push := func(info models.FileInfo, target *models.FileInfo) error {
+		stats.Pushed++
+		file, sidecar := differs(info, target)
+		if file {
+			stats.Bytes += info.Size
+		}
+		if opts.DryRun {
+			return nil
+		}
+		log.Info("pushing file", slog.Int("id", info.ID))
+		if file {
+			if err := s.push(ctx, peer, info); err != nil {
+				return err
+			}
+		}
+		if sidecar {
+			return s.pushSidecar(ctx, peer, info.ID)
+		}
+		return nil
+	}
+	deleteLocal := func(info models.FileInfo) error {
+		stats.DeletedLocal++
+		if opts.DryRun {
+			return nil
+		}
+		log.Info("deleting local file", slog.Int("id", info.ID))
+		return s.local.Remove(ctx, info.ID)
+	}
+	deleteRemote := func(info models.FileInfo) error {
+		stats.DeletedRemote++
+		if opts.DryRun {
+			return nil
+		}
+		log.Info("deleting peer file", slog.Int("id", info.ID))
+		_, err := peer.files.Delete(ctx, &ssov1.DeleteRequest{FileId: int32(info.ID)})
+		return err
+	}
+
+	// Merge listings.
+	for i, j := 0, 0; i < len(local) || j < len(remote); {
+		var err error
+
+		switch {
+		case j == len(remote) || (i < len(local) && local[i].ID < remote[j].ID):
+			// Only local.
+			switch {
+			case opts.Direction != models.SyncPull:
+				err = push(local[i], nil)
+			case opts.Mirror:
+				err = deleteLocal(local[i])
+			}
+			i++
+
+		case i == len(local) || remote[j].ID < local[i].ID:
+			// Only remote.
+			switch {
+			case opts.Direction != models.SyncPush:
+				err = pull(remote[j], nil)
+			case opts.Mirror:
+				err = deleteRemote(remote[j])
+			}
+			j++
+
+		default:
+			// Both sides.
+			if local[i].SHA256 != remote[j].SHA256 || local[i].SidecarSHA256 != remote[j].SidecarSHA256 {
+				switch opts.Direction {
+				case models.SyncPull:
+					err = pull(remote[j], &local[i])
+				case models.SyncPush:
+					err = push(local[i], &remote[j])
+				default:
+					log.Warn("file differs on both sides", slog.Int("id", local[i].ID))
+					stats.Conflicts++
+				}
+			}
+			i++
+			j++
+		}
+
+		if err != nil {
+			log.Error("failed to sync file", sl.Err(err))
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("sync finished",
+		slog.Int("pulled", stats.Pulled),
+		slog.Int("pushed", stats.Pushed),
+		slog.Int("deleted_local", stats.DeletedLocal),
+		slog.Int("deleted_remote", stats.DeletedRemote),
+		slog.Int("conflicts", stats.Conflicts),
+		slog.Int64("bytes", stats.Bytes),
+	)
+
+	return stats, nil
+}
+
+// pull downloads file from peer and verifies its checksum.
+func (s *Syncer) pull(ctx context.Context, peer peer, info models.FileInfo) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := peer.files.Download(ctx, &ssov1.DownloadRequest{FileId: int32(info.ID)})
+	if err != nil {
+		return err
+	}
+
+	return s.local.Put(ctx, info.ID, &downloadReader{
+		stream: stream,
+		hash:   sha256.New(),
+		sum:    info.SHA256,
+	})
+}
+
+// push uploads local file to peer.
+func (s *Syncer) push(ctx context.Context, peer peer, info models.FileInfo) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	file, err := s.local.Open(ctx, info.ID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := peer.admin.PutFile(ctx)
+	if err != nil {
+		return err
+	}
+
+	buffer := make([]byte, bufferLen)
+	for first := true; ; first = false {
+		n, err := io.ReadFull(file, buffer)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		if n > 0 || first {
+			if err := stream.Send(&ssov1.PutFileRequest{FileId: int32(info.ID), Chunk: buffer[:n]}); err != nil {
+				return err
+			}
+		}
+
+		if n < bufferLen {
+			break
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+
+	return err
+}
+
+// differs reports which parts of the file differ from target,
+// nil target is missing file.
+func differs(info models.FileInfo, target *models.FileInfo) (file, sidecar bool) {
+	if target == nil {
+		// New file has no sidecar.
+		return true, info.SidecarSHA256 != ""
+	}
+	return info.SHA256 != target.SHA256, info.SidecarSHA256 != target.SidecarSHA256
+}
+
+// pullSidecar downloads sidecar of the file from peer.
+func (s *Syncer) pullSidecar(ctx context.Context, peer peer, id int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := peer.admin.GetSidecar(ctx, &ssov1.GetSidecarRequest{FileId: int32(id)})
+	if err != nil {
+		return err
+	}
+
+	return s.local.PutSidecar(ctx, id, &sidecarReader{stream: stream})
+}
+
+// pushSidecar uploads sidecar of local file to peer.
+func (s *Syncer) pushSidecar(ctx context.Context, peer peer, id int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sidecar, err := s.local.OpenSidecar(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer sidecar.Close()
+
+	stream, err := peer.admin.PutSidecar(ctx)
+	if err != nil {
+		return err
+	}
+
+	buffer := make([]byte, bufferLen)
+	for first := true; ; first = false {
+		n, err := io.ReadFull(sidecar, buffer)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		if n > 0 || first {
+			if err := stream.Send(&ssov1.PutSidecarRequest{FileId: int32(id), Chunk: buffer[:n]}); err != nil {
+				return err
+			}
+		}
+
+		if n < bufferLen {
+			break
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+
+	return err
+}
+
+type peer struct {
+	files ssov1.FileServiceClient
+	admin ssov1.AdminServiceClient
+}
+
+func (p peer) list(ctx context.Context) ([]models.FileInfo, error) {
+	stream, err := p.admin.ListFiles(ctx, &ssov1.ListFilesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.FileInfo, 0)
+	for {
+		info, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return res, nil
+			}
+			return nil, err
+		}
+
+		res = append(res, models.FileInfo{
+			ID:            int(info.GetFileId()),
+			Size:          info.GetSize(),
+			SHA256:        info.GetSha256(),
+			SidecarSHA256: info.GetSidecarSha256(),
+		})
+	}
+}
+
+// downloadReader reads downloaded file
+// and fails at the end if checksum differs from expected.
+type downloadReader struct {
+	stream grpc.ServerStreamingClient[ssov1.DownloadResponse]
+	buf    []byte
+	hash   hash.Hash
+	sum    string
+}
+
+func (r *downloadReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		resp, err := r.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) && hex.EncodeToString(r.hash.Sum(nil)) != r.sum {
+				return 0, service.ErrChecksumMismatch
+			}
+			return 0, err
+		}
+		r.buf = resp.GetChunk()
+		r.hash.Write(r.buf)
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// sidecarReader reads sidecar archive downloaded from peer.
+type sidecarReader struct {
+	stream grpc.ServerStreamingClient[ssov1.SidecarChunk]
+	buf    []byte
+}
+
+func (r *sidecarReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		resp, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = resp.GetChunk()
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
@@ -0,0 +1,177 @@
+package syncer_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/service"
+	storage "radio-storage/internal/service/storage"
+	"radio-storage/internal/service/syncer"
+	"radio-storage/storagetest"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+
+	peer := storagetest.New(t)
+	local := storage.New(discardLog, t.TempDir(), 2, 5)
+
+	putRemote := func(id int, data string) {
+		stream, err := peer.AdminClient.PutFile(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&ssov1.PutFileRequest{FileId: int32(id), Chunk: []byte(data)}))
+		_, err = stream.CloseAndRecv()
+		require.NoError(t, err)
+	}
+	putLocal := func(id int, data string) {
+		require.NoError(t, local.Put(ctx, id, bytes.NewReader([]byte(data))))
+	}
+	listRemote := func() map[int]int64 {
+		res := make(map[int]int64)
+		stream, err := peer.AdminClient.ListFiles(ctx, &ssov1.ListFilesRequest{})
+		require.NoError(t, err)
+		for {
+			info, err := stream.Recv()
+			if err == io.EOF {
+				return res
+			}
+			require.NoError(t, err)
+			res[int(info.GetFileId())] = info.GetSize()
+		}
+	}
+	listLocal := func() map[int]int64 {
+		res := make(map[int]int64)
+		require.NoError(t, local.List(ctx, 0, 0, func(info models.FileInfo) error {
+			res[info.ID] = info.Size
+			return nil
+		}))
+		return res
+	}
+
+	putLocal(1, "only local")
+	putLocal(2, "same")
+	putLocal(3, "local version")
+	putRemote(2, "same")
+	putRemote(3, "remote version!")
+	putRemote(4, "only remote")
+
+	s := syncer.New(discardLog, local)
+
+	// Mirror is not allowed for both directions.
+	_, err := s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncBoth, Mirror: true})
+	require.ErrorIs(t, err, service.ErrInvalidSyncOptions)
+
+	// Dry run changes nothing.
+	stats, err := s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncBoth, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{Pulled: 1, Pushed: 1, Conflicts: 1, Bytes: 21}, stats)
+	require.Len(t, listRemote(), 3)
+
+	// Both directions copy missing files and report conflicts.
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncBoth})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{Pulled: 1, Pushed: 1, Conflicts: 1, Bytes: 21}, stats)
+	require.Equal(t, map[int]int64{1: 10, 2: 4, 3: 15, 4: 11}, listRemote())
+	require.Equal(t, map[int]int64{1: 10, 2: 4, 3: 13, 4: 11}, listLocal())
+
+	// Pull resolves difference in favour of peer.
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncPull})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{Pulled: 1, Bytes: 15}, stats)
+	require.Equal(t, listRemote(), listLocal())
+
+	// Mirror push deletes files absent locally.
+	require.NoError(t, local.Remove(ctx, 4))
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncPush, Mirror: true})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{DeletedRemote: 1}, stats)
+	require.Equal(t, map[int]int64{1: 10, 2: 4, 3: 15}, listRemote())
+
+	// Mirror pull deletes files absent on peer.
+	putLocal(5, "extra")
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncPull, Mirror: true})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{DeletedLocal: 1}, stats)
+	require.Equal(t, listRemote(), listLocal())
+}
+
+func TestSyncSidecar(t *testing.T) {
+	ctx := context.Background()
+
+	peer := storagetest.New(t)
+	local := storage.New(discardLog, t.TempDir(), 2, 5)
+
+	putRemote := func(id int, data string) {
+		stream, err := peer.AdminClient.PutFile(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&ssov1.PutFileRequest{FileId: int32(id), Chunk: []byte(data)}))
+		_, err = stream.CloseAndRecv()
+		require.NoError(t, err)
+	}
+	remoteAttachment := func(id int, name string) []byte {
+		attachment, err := peer.Client.GetAttachment(ctx, &ssov1.GetAttachmentRequest{FileId: int32(id), Name: name})
+		require.NoError(t, err)
+		return attachment.GetData()
+	}
+	localAttachment := func(id int, name string) []byte {
+		attachment, err := local.Attachment(ctx, id, name)
+		require.NoError(t, err)
+		return attachment.Data
+	}
+
+	require.NoError(t, local.Put(ctx, 1, bytes.NewReader([]byte("local"))))
+	_, err := local.PutAttachment(ctx, 1, "notes.txt", []byte("local notes"))
+	require.NoError(t, err)
+
+	putRemote(2, "remote")
+	_, err = peer.Client.PutAttachment(ctx, &ssov1.PutAttachmentRequest{FileId: 2, Name: "cover.txt", Data: []byte("remote cover")})
+	require.NoError(t, err)
+
+	s := syncer.New(discardLog, local)
+
+	// Missing files come with their attachments.
+	_, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncBoth})
+	require.NoError(t, err)
+	require.Equal(t, []byte("local notes"), remoteAttachment(1, "notes.txt"))
+	require.Equal(t, []byte("remote cover"), localAttachment(2, "cover.txt"))
+
+	// Difference in attachments only is synced too.
+	_, err = local.PutAttachment(ctx, 2, "cover.txt", []byte("local cover"))
+	require.NoError(t, err)
+
+	stats, err := s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncBoth, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{Conflicts: 1}, stats)
+
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncPush})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{Pushed: 1}, stats)
+	require.Equal(t, []byte("local cover"), remoteAttachment(2, "cover.txt"))
+
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncBoth})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{}, stats)
+
+	// Labels are pulled along with attachments.
+	_, err = peer.Client.SetLabels(ctx, &ssov1.SetLabelsRequest{FileId: 2, Labels: map[string]string{"type": "jingle"}})
+	require.NoError(t, err)
+
+	stats, err = s.Sync(ctx, peer.Conn, models.SyncOptions{Direction: models.SyncPull})
+	require.NoError(t, err)
+	require.Equal(t, models.SyncStats{Pulled: 1}, stats)
+
+	found, err := local.Find(ctx, models.FindQuery{Selector: "type=jingle"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, 2, found[0].FileID)
+	require.Equal(t, []byte("local cover"), localAttachment(2, "cover.txt"))
+}
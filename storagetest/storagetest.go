@@ -0,0 +1,317 @@
+// Package storagetest runs a real storage gRPC server in-process,
+// so dependent services can test against actual storage semantics
+// without an externally running instance.
+package storagetest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"radio-storage/internal/domain/models"
+	storageGRPC "radio-storage/internal/grpc"
+	"radio-storage/internal/lib/format"
+	"radio-storage/internal/service/analyzer"
+	"radio-storage/internal/service/reaper"
+	storage "radio-storage/internal/service/storage"
+	"radio-storage/internal/service/syncer"
+)
+
+const (
+	bufSize = 1024 * 1024
+
+	// bufconnAddr is the peer address reported for bufconn connections.
+	bufconnAddr = "bufconn"
+
+	defaultNestingDepth = 2
+	defaultIdLength     = 5
+
+	analysisQueueLen = 64
+)
+
+// Server is an in-process storage server
+// backed by a temporary directory.
+type Server struct {
+	// Dir is the root of the storage tree.
+	Dir string
+	// NestingDepth and IdLength describe the storage layout.
+	NestingDepth int
+	IdLength     int
+
+	// Client, AdminClient and MediaClient are connected to the server.
+	Client      ssov1.FileServiceClient
+	AdminClient ssov1.AdminServiceClient
+	MediaClient ssov1.MediaServiceClient
+	// Conn is the underlying client connection,
+	// may be used to build clients for other services.
+	Conn *grpc.ClientConn
+}
+
+type options struct {
+	log          *slog.Logger
+	dir          string
+	nestingDepth int
+	idLength     int
+	formats      []string
+	validation   ValidationPolicy
+	analysis     bool
+	namespaces   []string
+	quotas       QuotaPolicy
+	retention    time.Duration
+	reapInterval time.Duration
+}
+
+// ValidationPolicy lists rules checked for uploaded files.
+type ValidationPolicy = storage.ValidationPolicy
+
+// QuotaPolicy lists limits of storage quotas.
+type QuotaPolicy = storage.QuotaPolicy
+
+// Option configures the test server.
+type Option func(*options)
+
+// WithLogger sets server logger.
+// By default all logs are discarded.
+func WithLogger(log *slog.Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+// WithDir sets storage root.
+// By default a fresh temporary directory is used.
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// WithLayout sets nesting depth and id length of the storage tree.
+func WithLayout(nestingDepth, idLength int) Option {
+	return func(o *options) {
+		o.nestingDepth = nestingDepth
+		o.idLength = idLength
+	}
+}
+
+// WithFormats limits formats accepted on upload
+// by their names, e.g. "mp3" or "flac".
+func WithFormats(formats ...string) Option {
+	return func(o *options) {
+		o.formats = formats
+	}
+}
+
+// WithValidation makes server reject uploads violating policy.
+func WithValidation(policy ValidationPolicy) Option {
+	return func(o *options) {
+		o.validation = policy
+	}
+}
+
+// WithAnalysis runs background analysis of uploaded files,
+// as production server does.
+func WithAnalysis() Option {
+	return func(o *options) {
+		o.analysis = true
+	}
+}
+
+// WithNamespaces adds named namespaces rooted
+// in subdirectories of the storage root.
+// Clients select them with "namespace" metadata.
+func WithNamespaces(names ...string) Option {
+	return func(o *options) {
+		o.namespaces = names
+	}
+}
+
+// WithQuotas makes server reject uploads exceeding policy.
+// Uploads of test clients are counted under "bufconn" client.
+func WithQuotas(policy QuotaPolicy) Option {
+	return func(o *options) {
+		o.quotas = policy
+	}
+}
+
+// WithRetention makes files of all namespaces expire
+// after given age, unless their expiry is set.
+func WithRetention(retention time.Duration) Option {
+	return func(o *options) {
+		o.retention = retention
+	}
+}
+
+// WithReaper deletes expired files every interval,
+// as production server does.
+func WithReaper(interval time.Duration) Option {
+	return func(o *options) {
+		o.reapInterval = interval
+	}
+}
+
+// New starts storage server on in-memory connection
+// and returns connected client.
+//
+// Server and connection are closed on test cleanup.
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	o := options{
+		log:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		nestingDepth: defaultNestingDepth,
+		idLength:     defaultIdLength,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.dir == "" {
+		o.dir = t.TempDir()
+	}
+
+	storageOpts := []storage.Option{
+		storage.WithValidation(o.validation),
+	}
+	if len(o.formats) != 0 {
+		formats := make([]format.Format, 0, len(o.formats))
+		for _, name := range o.formats {
+			f, ok := format.ByName(name)
+			if !ok {
+				t.Fatalf("unknown file format: %s", name)
+			}
+			formats = append(formats, f)
+		}
+		storageOpts = append(storageOpts, storage.WithFormats(formats...))
+	}
+
+	lis := bufconn.Listen(bufSize)
+
+	gRPCServer := grpc.NewServer()
+
+	// Analysis and reaping must finish before
+	// temporary directory is removed.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	stop := func() {
+		cancel()
+		wg.Wait()
+	}
+
+	files := make(storageGRPC.Namespaces[storageGRPC.Storage])
+	admins := make(storageGRPC.Namespaces[storageGRPC.Admin])
+	syncers := make(storageGRPC.Namespaces[storageGRPC.Syncer])
+	media := make(storageGRPC.Namespaces[storageGRPC.Media])
+
+	dirs := map[string]string{models.DefaultNamespace: o.dir}
+	for _, name := range o.namespaces {
+		dirs[name] = o.dir + "/" + name
+	}
+
+	quotas := storage.NewQuotas(o.quotas)
+
+	for name, dir := range dirs {
+		nsOpts := slices.Clone(storageOpts)
+		nsOpts = append(nsOpts,
+			storage.WithQuotas(quotas, name),
+			storage.WithRetention(o.retention),
+		)
+
+		var a *analyzer.Analyzer
+		if o.analysis {
+			a = analyzer.New(o.log, analysisQueueLen)
+			nsOpts = append(nsOpts, storage.WithAnalyzer(a))
+		}
+
+		storageSrv := storage.New(
+			o.log,
+			dir,
+			o.nestingDepth,
+			o.idLength,
+			nsOpts...,
+		)
+
+		if a != nil {
+			a.Register(analyzer.Stages(storageSrv)...)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.Run(ctx)
+			}()
+		}
+
+		if o.reapInterval != 0 {
+			r := reaper.New(o.log, storageSrv, o.reapInterval)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Run(ctx)
+			}()
+		}
+
+		files[name] = storageSrv
+		admins[name] = storageSrv
+		syncers[name] = syncer.New(o.log, storageSrv)
+		media[name] = storageSrv
+	}
+
+	storageGRPC.Register(
+		gRPCServer,
+		files,
+		[]string{bufconnAddr},
+	)
+	storageGRPC.RegisterAdmin(
+		gRPCServer,
+		admins,
+		syncers,
+		quotas,
+		[]string{bufconnAddr},
+	)
+	storageGRPC.RegisterMedia(
+		gRPCServer,
+		media,
+		[]string{bufconnAddr},
+	)
+
+	go func() {
+		_ = gRPCServer.Serve(lis)
+	}()
+
+	cc, err := grpc.NewClient(
+		"passthrough:///"+bufconnAddr,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		stop()
+		gRPCServer.Stop()
+		t.Fatalf("grpc server connection failed: %v", err)
+	}
+
+	t.Cleanup(func() {
+		stop()
+		cc.Close()
+		gRPCServer.Stop()
+	})
+
+	return &Server{
+		Dir:          o.dir,
+		NestingDepth: o.nestingDepth,
+		IdLength:     o.idLength,
+		Client:       ssov1.NewFileServiceClient(cc),
+		AdminClient:  ssov1.NewAdminServiceClient(cc),
+		MediaClient:  ssov1.NewMediaServiceClient(cc),
+		Conn:         cc,
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestArtwork(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var cover bytes.Buffer
+	require.NoError(t, png.Encode(&cover, img))
+
+	frames := map[string]string{"TIT2": "Song"}
+	data := append(mp3test.ID3v2Picture(frames, "image/png", cover.Bytes()), mp3test.Silence(10)...)
+	id := upload(ctx, t, st, data)
+
+	metadata, err := st.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.True(t, metadata.GetHasCover())
+
+	// Original is served as it is embedded.
+	artwork, err := st.MediaClient.GetArtwork(ctx, &storagev1.GetArtworkRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.Equal(t, cover.Bytes(), artwork.GetData())
+	require.Equal(t, "image/png", artwork.GetContentType())
+	require.EqualValues(t, 640, artwork.GetWidth())
+	require.EqualValues(t, 480, artwork.GetHeight())
+
+	artwork, err = st.MediaClient.GetArtwork(ctx, &storagev1.GetArtworkRequest{FileId: int32(id), Size: 300})
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", artwork.GetContentType())
+	require.EqualValues(t, 300, artwork.GetWidth())
+	require.EqualValues(t, 225, artwork.GetHeight())
+
+	scaled, err := jpeg.Decode(bytes.NewReader(artwork.GetData()))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 300, 225), scaled.Bounds())
+
+	_, err = st.MediaClient.GetArtwork(ctx, &storagev1.GetArtworkRequest{FileId: int32(id), Size: 150})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Pictures are stripped from downloaded file only.
+	stripped, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		StripArtwork: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", contentType)
+	require.Equal(t, append(mp3test.ID3v2(frames), mp3test.Silence(10)...), stripped)
+
+	original, err := download(ctx, t, st, id)
+	require.NoError(t, err)
+	require.Equal(t, data, original)
+
+	_, _, err = downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV,
+		StripArtwork: true,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// File without cover art.
+	plainID := upload(ctx, t, st, append(mp3test.ID3v2(frames), mp3test.Silence(10)...))
+	_, err = st.MediaClient.GetArtwork(ctx, &storagev1.GetArtworkRequest{FileId: int32(plainID)})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	flacID := upload(ctx, t, st, flacData)
+	_, err = st.MediaClient.GetArtwork(ctx, &storagev1.GetArtworkRequest{FileId: int32(flacID)})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAttachments(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	id := int32(upload(ctx, t, st, mp3test.Silence(10)))
+
+	chapters := []byte(`{"chapters":[{"start":0,"title":"Intro"}]}`)
+	attachment, err := st.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{
+		FileId: id,
+		Name:   "chapters.json",
+		Data:   chapters,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "chapters.json", attachment.GetName())
+	require.Equal(t, "application/json", attachment.GetContentType())
+	require.EqualValues(t, len(chapters), attachment.GetSize())
+	require.Empty(t, attachment.GetData())
+
+	_, err = st.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{
+		FileId: id,
+		Name:   "lyrics.lrc",
+		Data:   []byte("[00:01.00]La la la"),
+	})
+	require.NoError(t, err)
+
+	// Existing attachment is replaced.
+	lyrics := []byte("[00:01.00]Na na na")
+	_, err = st.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{
+		FileId: id,
+		Name:   "lyrics.lrc",
+		Data:   lyrics,
+	})
+	require.NoError(t, err)
+
+	attachment, err = st.Client.GetAttachment(ctx, &storagev1.GetAttachmentRequest{FileId: id, Name: "lyrics.lrc"})
+	require.NoError(t, err)
+	require.Equal(t, lyrics, attachment.GetData())
+	require.EqualValues(t, len(lyrics), attachment.GetSize())
+
+	list, err := st.Client.ListAttachments(ctx, &storagev1.ListAttachmentsRequest{FileId: id})
+	require.NoError(t, err)
+	require.Len(t, list.GetAttachments(), 2)
+	require.Equal(t, "chapters.json", list.GetAttachments()[0].GetName())
+	require.Equal(t, "lyrics.lrc", list.GetAttachments()[1].GetName())
+
+	_, err = st.Client.DeleteAttachment(ctx, &storagev1.DeleteAttachmentRequest{FileId: id, Name: "chapters.json"})
+	require.NoError(t, err)
+	_, err = st.Client.DeleteAttachment(ctx, &storagev1.DeleteAttachmentRequest{FileId: id, Name: "chapters.json"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.Client.GetAttachment(ctx, &storagev1.GetAttachmentRequest{FileId: id, Name: "chapters.json"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	for _, name := range []string{"", "../meta.json", ".hidden", "a/b", strings.Repeat("a", 129)} {
+		_, err = st.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{FileId: id, Name: name, Data: lyrics})
+		require.Equal(t, codes.InvalidArgument, status.Code(err), name)
+	}
+	_, err = st.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{
+		FileId: id,
+		Name:   "transcript.vtt",
+		Data:   make([]byte, 2<<20+1),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Attachments are deleted along with the file.
+	_, err = st.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: id})
+	require.NoError(t, err)
+
+	_, err = st.Client.ListAttachments(ctx, &storagev1.ListAttachmentsRequest{FileId: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{FileId: id, Name: "lyrics.lrc", Data: lyrics})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	dir, err := st.GetCorrespondingDir(int(id))
+	require.NoError(t, err)
+	require.NoDirExists(t, dir+"/"+strconv.Itoa(int(id))+".d")
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestCuePoints(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(100))
+	require.NoError(t, err)
+	id := resp.GetFileId()
+
+	// Silent track has no cue points.
+	cues, err := srv.MediaClient.GetCuePoints(ctx, &storagev1.GetCuePointsRequest{FileId: id})
+	require.NoError(t, err)
+	require.Equal(t, id, cues.GetFileId())
+	require.Zero(t, cues.GetDetected().GetEnd().AsDuration())
+	require.Nil(t, cues.GetOverride().GetStart())
+
+	cues, err = srv.MediaClient.SetCuePoints(ctx, &storagev1.SetCuePointsRequest{
+		FileId: id,
+		Override: &storagev1.CuePoints{
+			End:     durationpb.New(2 * time.Second),
+			FadeOut: durationpb.New(time.Second),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, cues.GetEffective().GetEnd().AsDuration())
+	require.Equal(t, time.Second, cues.GetEffective().GetFadeOut().AsDuration())
+	require.Zero(t, cues.GetEffective().GetStart().AsDuration())
+	require.Zero(t, cues.GetDetected().GetEnd().AsDuration())
+
+	// Override is stored.
+	cues, err = srv.MediaClient.GetCuePoints(ctx, &storagev1.GetCuePointsRequest{FileId: id})
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, cues.GetOverride().GetEnd().AsDuration())
+	require.Nil(t, cues.GetOverride().GetIntroEnd())
+
+	// Start after end.
+	_, err = srv.MediaClient.SetCuePoints(ctx, &storagev1.SetCuePointsRequest{
+		FileId:   id,
+		Override: &storagev1.CuePoints{Start: durationpb.New(time.Second)},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = srv.MediaClient.SetCuePoints(ctx, &storagev1.SetCuePointsRequest{
+		FileId:   id,
+		Override: &storagev1.CuePoints{IntroEnd: durationpb.New(-time.Second)},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Empty override restores detected cue points.
+	cues, err = srv.MediaClient.SetCuePoints(ctx, &storagev1.SetCuePointsRequest{FileId: id})
+	require.NoError(t, err)
+	require.Zero(t, cues.GetEffective().GetEnd().AsDuration())
+	require.Nil(t, cues.GetOverride().GetEnd())
+
+	_, err = srv.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: id})
+	require.NoError(t, err)
+	_, err = srv.MediaClient.GetCuePoints(ctx, &storagev1.GetCuePointsRequest{FileId: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
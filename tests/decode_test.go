@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDownloadDecoded(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	const frames = 20
+
+	data := append(mp3test.ID3v2(map[string]string{"TIT2": "Silence"}), mp3test.Silence(frames)...)
+	id := upload(ctx, t, st, data)
+
+	samples := frames * mp3test.FrameSamples
+
+	// WAV keeps sample rate and channels of the file.
+	wav, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "audio/wav", contentType)
+	require.Equal(t, "RIFF", string(wav[:4]))
+	require.Equal(t, "WAVE", string(wav[8:12]))
+	require.Equal(t, uint16(2), binary.LittleEndian.Uint16(wav[22:]))
+	require.Equal(t, uint32(mp3test.SampleRate), binary.LittleEndian.Uint32(wav[24:]))
+	require.Equal(t, uint32(samples*4), binary.LittleEndian.Uint32(wav[40:]))
+	require.Len(t, wav, 44+samples*4)
+	require.Equal(t, make([]byte, samples*4), wav[44:])
+
+	// Raw PCM is resampled and downmixed.
+	raw, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_PCM,
+		SampleRate:   48000,
+		Channels:     1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "audio/pcm;rate=48000;channels=1;format=s16le", contentType)
+	require.Len(t, raw, samples*48000/mp3test.SampleRate*2)
+
+	// Unsupported sample rate.
+	_, _, err = downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_PCM,
+		SampleRate:   22050,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Only MP3 is decoded.
+	flacID := upload(ctx, t, st, flacData)
+	_, _, err = downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(flacID),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV,
+	})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Original file is not affected.
+	original, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", contentType)
+	require.Equal(t, data, original)
+}
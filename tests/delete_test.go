@@ -16,7 +16,7 @@ func TestDelete(t *testing.T) {
 	var size int64 = rand.Int63n(maxDataSize)
 	// data := make([]byte, 0, size)
 
-	var bufferSize int64 = rand.Int63n(maxBufferLen)
+	var bufferSize int64 = rand.Int63n(maxBufferLen) + 1
 
 	stream, err := st.Client.Upload(ctx)
 	require.NoError(t, err)
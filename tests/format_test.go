@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var flacData = append([]byte("fLaC\x80\x00\x00\x22"), make([]byte, 34)...)
+
+func TestFormats(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := uploadWith(ctx, t, st.Client, flacData)
+	require.NoError(t, err)
+	require.Equal(t, "audio/flac", resp.GetContentType())
+
+	id := int(resp.GetFileId())
+
+	// File is stored with extension of detected format.
+	dir, err := st.GetCorrespondingDir(id)
+	require.NoError(t, err)
+	require.FileExists(t, dir+"/"+strconv.Itoa(id)+".flac")
+	require.NoFileExists(t, dir+"/"+strconv.Itoa(id)+".mp3")
+
+	// Content type comes with the first chunk.
+	download, err := st.Client.Download(ctx, &storagev1.DownloadRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	chunk, err := download.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "audio/flac", chunk.GetContentType())
+	require.Equal(t, flacData, chunk.GetChunk())
+	_, err = download.Recv()
+	require.True(t, errors.Is(err, io.EOF))
+
+	metadata, err := st.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.Equal(t, "flac", metadata.GetFormat())
+	require.Equal(t, "audio/flac", metadata.GetContentType())
+
+	// MP3 is detected too.
+	mp3ID := upload(ctx, t, st, mp3test.Silence(10))
+	metadata, err = st.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: int32(mp3ID)})
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", metadata.GetContentType())
+
+	_, err = st.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	_, err = os.Stat(dir + "/" + strconv.Itoa(id) + ".flac")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestFormatsAllowlist(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithFormats("mp3", "wav"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := uploadWith(ctx, t, srv.Client, flacData)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(10))
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", resp.GetContentType())
+
+	// Imported file is checked too.
+	put, err := srv.AdminClient.PutFile(ctx)
+	require.NoError(t, err)
+	require.NoError(t, put.Send(&storagev1.PutFileRequest{FileId: resp.GetFileId(), Chunk: flacData}))
+	_, err = put.CloseAndRecv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func find(ctx context.Context, t *testing.T, client storagev1.FileServiceClient, req *storagev1.FindRequest) []int32 {
+	t.Helper()
+
+	resp, err := client.Find(ctx, req)
+	require.NoError(t, err)
+
+	var ids []int32
+	for _, f := range resp.GetFiles() {
+		ids = append(ids, f.GetFileId())
+	}
+	return ids
+}
+
+func TestLabels(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	srv := storagetest.New(t, storagetest.WithDir(dir))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	file := mp3test.Silence(5)
+
+	resp, err := uploadWith(ctx, t, srv.Client, file, withLabels(map[string]string{"type": "jingle", "show": "morning"}))
+	require.NoError(t, err)
+	jingle := resp.GetFileId()
+	resp, err = uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	ad := resp.GetFileId()
+	resp, err = uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	plain := resp.GetFileId()
+
+	labels, err := srv.Client.GetLabels(ctx, &storagev1.GetLabelsRequest{FileId: jingle})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"type": "jingle", "show": "morning"}, labels.GetLabels())
+
+	labels, err = srv.Client.GetLabels(ctx, &storagev1.GetLabelsRequest{FileId: plain})
+	require.NoError(t, err)
+	require.Empty(t, labels.GetLabels())
+
+	// First search loads the index, later changes must update it.
+	require.Equal(t, []int32{jingle}, find(ctx, t, srv.Client, &storagev1.FindRequest{Selector: "type=jingle"}))
+
+	_, err = srv.Client.SetLabels(ctx, &storagev1.SetLabelsRequest{
+		FileId: ad,
+		Labels: map[string]string{"type": "ad", "license_expires": "2027-01-01"},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []int32{ad}, find(ctx, t, srv.Client, &storagev1.FindRequest{Selector: "license_expires"}))
+	require.ElementsMatch(t, []int32{jingle, ad}, find(ctx, t, srv.Client, &storagev1.FindRequest{Selector: "type in (ad,jingle)"}))
+	require.Equal(t, []int32{jingle}, find(ctx, t, srv.Client, &storagev1.FindRequest{Selector: "type, !license_expires"}))
+
+	// Paging by id.
+	first := find(ctx, t, srv.Client, &storagev1.FindRequest{Limit: 1})
+	require.Len(t, first, 1)
+	rest := find(ctx, t, srv.Client, &storagev1.FindRequest{AfterId: first[0]})
+	require.Len(t, rest, 1)
+	require.ElementsMatch(t, []int32{jingle, ad}, append(first, rest...))
+
+	// Index is rebuilt from sidecars after restart.
+	restarted := storagetest.New(t, storagetest.WithDir(dir))
+	require.Equal(t, []int32{ad}, find(ctx, t, restarted.Client, &storagev1.FindRequest{Selector: "type=ad"}))
+
+	// Empty labels remove all.
+	_, err = srv.Client.SetLabels(ctx, &storagev1.SetLabelsRequest{FileId: ad})
+	require.NoError(t, err)
+	require.Empty(t, find(ctx, t, srv.Client, &storagev1.FindRequest{Selector: "type=ad"}))
+
+	// Labels are removed along with the file.
+	_, err = srv.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: jingle})
+	require.NoError(t, err)
+	require.Empty(t, find(ctx, t, srv.Client, &storagev1.FindRequest{}))
+
+	_, err = srv.Client.GetLabels(ctx, &storagev1.GetLabelsRequest{FileId: jingle})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = srv.Client.SetLabels(ctx, &storagev1.SetLabelsRequest{FileId: jingle, Labels: map[string]string{"type": "ad"}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = uploadWith(ctx, t, srv.Client, file, withLabels(map[string]string{"type": "a b"}))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = srv.Client.SetLabels(ctx, &storagev1.SetLabelsRequest{FileId: plain, Labels: map[string]string{"-type": "ad"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	for _, req := range []*storagev1.FindRequest{
+		{Selector: "type=(ad)"},
+		{Limit: 10001},
+		{Limit: -1},
+	} {
+		_, err = srv.Client.Find(ctx, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestUploadInvalidLabels(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithQuotas(storagetest.QuotaPolicy{
+		Global: models.Usage{Bytes: 1},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Labels are checked before size exceeding quota.
+	_, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(5),
+		withSize(1<<30),
+		withLabels(map[string]string{"type": "a b"}),
+	)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoudness(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(100))
+	require.NoError(t, err)
+
+	// Analysis runs in background after upload.
+	var loudness *storagev1.Loudness
+	require.Eventually(t, func() bool {
+		metadata, err := srv.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: resp.GetFileId()})
+		require.NoError(t, err)
+		loudness = metadata.GetLoudness()
+		return loudness != nil
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// Silence is reported at the floor level.
+	require.Equal(t, -70.0, loudness.GetIntegrated())
+	require.Equal(t, 0.0, loudness.GetRange())
+	require.Equal(t, -70.0, loudness.GetTruePeak())
+	require.Equal(t, 52.0, loudness.GetTrackGain())
+
+	// Result is dropped once file changes.
+	put, err := srv.AdminClient.PutFile(ctx)
+	require.NoError(t, err)
+	require.NoError(t, put.Send(&storagev1.PutFileRequest{FileId: resp.GetFileId(), Chunk: mp3test.Silence(50)}))
+	_, err = put.CloseAndRecv()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		metadata, err := srv.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: resp.GetFileId()})
+		require.NoError(t, err)
+		return metadata.GetDuration().AsDuration() < 2*time.Second && metadata.GetLoudness() != nil
+	}, 10*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetadata(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	var track []byte
+	track = append(track, mp3test.ID3v2(map[string]string{
+		"TIT2": "Eine kleine Nachtmusik",
+		"TPE1": "Mozart",
+		"TALB": "Serenades",
+		"TYER": "1787",
+	})...)
+	track = append(track, mp3test.Silence(200)...)
+
+	id := upload(ctx, t, st, track)
+
+	metadata, err := st.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.Equal(t, "Eine kleine Nachtmusik", metadata.GetTitle())
+	require.Equal(t, "Mozart", metadata.GetArtist())
+	require.Equal(t, "Serenades", metadata.GetAlbum())
+	require.Equal(t, "1787", metadata.GetYear())
+	require.False(t, metadata.GetHasCover())
+	require.Equal(t, time.Duration(200*mp3test.FrameSamples)*time.Second/mp3test.SampleRate, metadata.GetDuration().AsDuration())
+	require.Equal(t, int32(128), metadata.GetBitrate())
+	require.Equal(t, storagev1.BitrateMode_BITRATE_MODE_CBR, metadata.GetBitrateMode())
+	require.Equal(t, int32(mp3test.SampleRate), metadata.GetSampleRate())
+	require.Equal(t, int32(2), metadata.GetChannels())
+
+	// Sidecar index is written on upload and removed with the file.
+	dir, err := st.GetCorrespondingDir(id)
+	require.NoError(t, err)
+	sidecar := dir + "/" + strconv.Itoa(id) + ".d"
+	require.FileExists(t, sidecar+"/meta.json")
+
+	// Missing index is rebuilt.
+	require.NoError(t, os.RemoveAll(sidecar))
+	metadata, err = st.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.Equal(t, "Mozart", metadata.GetArtist())
+	require.FileExists(t, sidecar+"/meta.json")
+
+	_, err = st.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: int32(id)})
+	require.NoError(t, err)
+	require.NoDirExists(t, sidecar)
+
+	_, err = st.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: int32(id)})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// listIDs returns ids of all files listed by admin service.
+func listIDs(ctx context.Context, t *testing.T, client storagev1.AdminServiceClient) []int32 {
+	t.Helper()
+
+	stream, err := client.ListFiles(ctx, &storagev1.ListFilesRequest{})
+	require.NoError(t, err)
+
+	var ids []int32
+	for {
+		info, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return ids
+		}
+		require.NoError(t, err)
+		ids = append(ids, info.GetFileId())
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithNamespaces("jingles", "ads"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	jinglesCtx := metadata.AppendToOutgoingContext(ctx, "namespace", "jingles")
+	defaultCtx := metadata.AppendToOutgoingContext(ctx, "namespace", "default")
+
+	uploaded, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(5), withLabels(map[string]string{"type": "track"}))
+	require.NoError(t, err)
+	track := uploaded.GetFileId()
+	uploaded, err = uploadWith(jinglesCtx, t, srv.Client, mp3test.Silence(5), withLabels(map[string]string{"type": "jingle"}))
+	require.NoError(t, err)
+	jingle := uploaded.GetFileId()
+
+	// Requests without namespace are served by default one.
+	require.Equal(t, []int32{track}, listIDs(ctx, t, srv.AdminClient))
+	require.Equal(t, []int32{track}, listIDs(defaultCtx, t, srv.AdminClient))
+	require.Equal(t, []int32{jingle}, listIDs(jinglesCtx, t, srv.AdminClient))
+	require.Empty(t, listIDs(metadata.AppendToOutgoingContext(ctx, "namespace", "ads"), t, srv.AdminClient))
+
+	resp, err := srv.Client.Find(jinglesCtx, &storagev1.FindRequest{Selector: "type"})
+	require.NoError(t, err)
+	require.Len(t, resp.GetFiles(), 1)
+	require.Equal(t, jingle, resp.GetFiles()[0].GetFileId())
+
+	metadataResp, err := srv.MediaClient.GetMetadata(jinglesCtx, &storagev1.GetMetadataRequest{FileId: jingle})
+	require.NoError(t, err)
+	require.Equal(t, jingle, metadataResp.GetFileId())
+
+	// Namespace is rooted in subdirectory named after it.
+	files, err := filepath.Glob(srv.Dir + "/jingles/*/*/" + strconv.Itoa(int(jingle)) + ".mp3")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	_, err = srv.Client.Delete(jinglesCtx, &storagev1.DeleteRequest{FileId: jingle})
+	require.NoError(t, err)
+	require.Empty(t, listIDs(jinglesCtx, t, srv.AdminClient))
+	require.Equal(t, []int32{track}, listIDs(ctx, t, srv.AdminClient))
+
+	unknownCtx := metadata.AppendToOutgoingContext(ctx, "namespace", "podcasts")
+	_, err = srv.Client.Find(unknownCtx, &storagev1.FindRequest{})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = srv.Client.Delete(unknownCtx, &storagev1.DeleteRequest{FileId: track})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = srv.MediaClient.GetMetadata(unknownCtx, &storagev1.GetMetadataRequest{FileId: track})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPin(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	first := upload(ctx, t, st, []byte("first file"))
+	second := upload(ctx, t, st, []byte("second file"))
+
+	listPinned := func() []int32 {
+		t.Helper()
+
+		res, err := st.AdminClient.ListPinned(ctx, &storagev1.ListPinnedRequest{})
+		require.NoError(t, err)
+		return res.GetFileIds()
+	}
+
+	require.Empty(t, listPinned())
+
+	for _, id := range []int{first, second} {
+		_, err := st.AdminClient.SetPinned(ctx, &storagev1.SetPinnedRequest{FileId: int32(id), Pinned: true})
+		require.NoError(t, err)
+	}
+	// Ids are random, list is sorted.
+	require.ElementsMatch(t, []int32{int32(first), int32(second)}, listPinned())
+
+	// Pinning twice is not an error.
+	_, err := st.AdminClient.SetPinned(ctx, &storagev1.SetPinnedRequest{FileId: int32(first), Pinned: true})
+	require.NoError(t, err)
+
+	_, err = st.AdminClient.SetPinned(ctx, &storagev1.SetPinnedRequest{FileId: int32(first)})
+	require.NoError(t, err)
+	require.Equal(t, []int32{int32(second)}, listPinned())
+
+	// Pin is removed along with the file.
+	_, err = st.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: int32(second)})
+	require.NoError(t, err)
+	require.Empty(t, listPinned())
+
+	_, err = st.AdminClient.SetPinned(ctx, &storagev1.SetPinnedRequest{FileId: int32(second), Pinned: true})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
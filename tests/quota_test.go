@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"radio-storage/internal/domain/models"
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func usageOf(ctx context.Context, t *testing.T, client storagev1.AdminServiceClient, scope storagev1.QuotaScope, name string) *storagev1.Quota {
+	t.Helper()
+
+	resp, err := client.GetUsage(ctx, &storagev1.GetUsageRequest{})
+	require.NoError(t, err)
+
+	for _, q := range resp.GetQuotas() {
+		if q.GetScope() == scope && q.GetName() == name {
+			return q
+		}
+	}
+	t.Fatalf("no usage of %s %q", scope, name)
+	return nil
+}
+
+func TestQuotaFiles(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithQuotas(storagetest.QuotaPolicy{
+		Global: models.Usage{Files: 2},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	file := mp3test.Silence(10)
+
+	resp, err := uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	first := resp.GetFileId()
+	_, err = uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+
+	_, err = uploadWith(ctx, t, srv.Client, file)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Imported file is counted unless it replaces stored one.
+	putFile := func(id int32) error {
+		put, err := srv.AdminClient.PutFile(ctx)
+		require.NoError(t, err)
+		require.NoError(t, put.Send(&storagev1.PutFileRequest{FileId: id, Chunk: file}))
+		_, err = put.CloseAndRecv()
+		return err
+	}
+	require.NoError(t, putFile(first))
+	require.Equal(t, codes.ResourceExhausted, status.Code(putFile(first+100)))
+
+	// Deleted file frees its place.
+	_, err = srv.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: first})
+	require.NoError(t, err)
+	_, err = uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+}
+
+func TestQuotaBytes(t *testing.T) {
+	t.Parallel()
+
+	file := mp3test.Silence(10)
+
+	srv := storagetest.New(t, storagetest.WithQuotas(storagetest.QuotaPolicy{
+		Clients: map[string]models.Usage{
+			"bufconn": {Bytes: int64(len(file)) * 3 / 2},
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Declared size is checked before file is received.
+	_, err := uploadWith(ctx, t, srv.Client, file, withSize(int64(len(file))*2))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+
+	// Undeclared size is checked as chunks arrive.
+	_, err = uploadWith(ctx, t, srv.Client, file)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	require.Equal(t, int32(1), usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_CLIENT, "bufconn").GetFiles())
+}
+
+func TestGetUsage(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t,
+		storagetest.WithNamespaces("jingles"),
+		storagetest.WithQuotas(storagetest.QuotaPolicy{
+			Namespaces: map[string]models.Usage{"jingles": {Files: 10}},
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	jinglesCtx := metadata.AppendToOutgoingContext(ctx, "namespace", "jingles")
+
+	file := mp3test.Silence(10)
+	size := int64(len(file))
+
+	_, err := uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	resp, err := uploadWith(jinglesCtx, t, srv.Client, file)
+	require.NoError(t, err)
+	jingle := resp.GetFileId()
+	_, err = uploadWith(jinglesCtx, t, srv.Client, file)
+	require.NoError(t, err)
+
+	global := usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "")
+	require.Equal(t, int32(3), global.GetFiles())
+	// Bytes include sidecars of the files.
+	require.GreaterOrEqual(t, global.GetBytes(), 3*size)
+
+	jingles := usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_NAMESPACE, "jingles")
+	require.Equal(t, int32(2), jingles.GetFiles())
+	require.GreaterOrEqual(t, jingles.GetBytes(), 2*size)
+	require.Equal(t, int32(10), jingles.GetMaxFiles())
+
+	def := usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_NAMESPACE, "default")
+	require.Equal(t, global.GetBytes(), def.GetBytes()+jingles.GetBytes())
+
+	require.Equal(t, int32(3), usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_CLIENT, "bufconn").GetFiles())
+
+	_, err = srv.Client.Delete(jinglesCtx, &storagev1.DeleteRequest{FileId: jingle})
+	require.NoError(t, err)
+
+	require.Equal(t, int32(1), usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_NAMESPACE, "jingles").GetFiles())
+	after := usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "").GetBytes()
+	require.Less(t, after, global.GetBytes()-size)
+}
+
+func TestQuotaAttachments(t *testing.T) {
+	t.Parallel()
+
+	file := mp3test.Silence(10)
+	size := int64(len(file))
+
+	srv := storagetest.New(t, storagetest.WithQuotas(storagetest.QuotaPolicy{
+		Global: models.Usage{Bytes: 5 * size / 2},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	resp, err := uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	id := resp.GetFileId()
+	before := usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "").GetBytes()
+
+	// Attachments count in usage of the file.
+	attachment := make([]byte, size/2)
+	_, err = srv.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{FileId: id, Name: "cover.bin", Data: attachment})
+	require.NoError(t, err)
+	require.Equal(t, before+size/2, usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "").GetBytes())
+
+	_, err = srv.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{FileId: id, Name: "other.bin", Data: attachment})
+	require.NoError(t, err)
+
+	// Quota is taken by attachments.
+	_, err = uploadWith(ctx, t, srv.Client, file)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = srv.Client.PutAttachment(ctx, &storagev1.PutAttachmentRequest{FileId: id, Name: "third.bin", Data: attachment})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = srv.Client.DeleteAttachment(ctx, &storagev1.DeleteAttachmentRequest{FileId: id, Name: "other.bin"})
+	require.NoError(t, err)
+	require.Equal(t, before+size/2, usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "").GetBytes())
+}
+
+func TestQuotaSnapshots(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithQuotas(storagetest.QuotaPolicy{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	file := mp3test.Silence(10)
+	size := int64(len(file))
+
+	resp, err := uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	id := resp.GetFileId()
+
+	_, err = srv.AdminClient.CreateSnapshot(ctx, &storagev1.CreateSnapshotRequest{Name: "before"})
+	require.NoError(t, err)
+	_, err = srv.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: id})
+	require.NoError(t, err)
+
+	// Deleted file is still kept by snapshot.
+	global := usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "")
+	require.Equal(t, int32(0), global.GetFiles())
+	require.GreaterOrEqual(t, global.GetBytes(), size)
+
+	_, err = srv.AdminClient.DeleteSnapshot(ctx, &storagev1.DeleteSnapshotRequest{Name: "before"})
+	require.NoError(t, err)
+	require.Zero(t, usageOf(ctx, t, srv.AdminClient, storagev1.QuotaScope_QUOTA_SCOPE_GLOBAL, "").GetBytes())
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRetention(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithReaper(20*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	exists := func(id int32) bool {
+		_, err := srv.Client.GetExpiry(ctx, &storagev1.GetExpiryRequest{FileId: id})
+		if status.Code(err) == codes.NotFound {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	file := mp3test.Silence(5)
+
+	uploaded, err := uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	kept := uploaded.GetFileId()
+	uploaded, err = uploadWith(ctx, t, srv.Client, file, withExpiry(timestamppb.New(time.Now().Add(-time.Minute))))
+	require.NoError(t, err)
+	expired := uploaded.GetFileId()
+	uploaded, err = uploadWith(ctx, t, srv.Client, file)
+	require.NoError(t, err)
+	pinned := uploaded.GetFileId()
+
+	_, err = srv.AdminClient.SetPinned(ctx, &storagev1.SetPinnedRequest{FileId: pinned, Pinned: true})
+	require.NoError(t, err)
+	_, err = srv.Client.SetExpiry(ctx, &storagev1.SetExpiryRequest{FileId: pinned, ExpiresAt: timestamppb.New(time.Now())})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return !exists(expired) }, 10*time.Second, 10*time.Millisecond)
+
+	resp, err := srv.Client.GetExpiry(ctx, &storagev1.GetExpiryRequest{FileId: kept})
+	require.NoError(t, err)
+	require.Nil(t, resp.GetExpiresAt())
+
+	// Pin overrides expiry.
+	require.True(t, exists(pinned))
+
+	later := time.Now().Add(time.Hour).Truncate(time.Second)
+	_, err = srv.Client.SetExpiry(ctx, &storagev1.SetExpiryRequest{FileId: kept, ExpiresAt: timestamppb.New(later)})
+	require.NoError(t, err)
+	resp, err = srv.Client.GetExpiry(ctx, &storagev1.GetExpiryRequest{FileId: kept})
+	require.NoError(t, err)
+	require.True(t, later.Equal(resp.GetExpiresAt().AsTime()))
+
+	_, err = srv.Client.SetExpiry(ctx, &storagev1.SetExpiryRequest{FileId: expired})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRetentionOfNamespace(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithRetention(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	uploaded, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(5))
+	require.NoError(t, err)
+	id := uploaded.GetFileId()
+
+	resp, err := srv.Client.GetExpiry(ctx, &storagev1.GetExpiryRequest{FileId: id})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), resp.GetExpiresAt().AsTime(), time.Minute)
+}
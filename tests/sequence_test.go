@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDownloadSequence(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	var news []byte
+	news = append(news, mp3test.ID3v2(map[string]string{"TIT2": "News"})...)
+	news = append(news, mp3test.Sound(3)...)
+	news = append(news, mp3test.ID3v1("News", "", "", "")...)
+	newsID := upload(ctx, t, st, news)
+
+	jingleID := upload(ctx, t, st, mp3test.Silence(2))
+
+	data, items, contentType, err := downloadSequence(ctx, st, newsID, jingleID, newsID)
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", contentType)
+
+	// Tags are stripped.
+	var want []byte
+	want = append(want, mp3test.Sound(3)...)
+	want = append(want, mp3test.Silence(2)...)
+	want = append(want, mp3test.Sound(3)...)
+	require.Equal(t, want, data)
+
+	frame := time.Duration(mp3test.FrameSamples) * time.Second / mp3test.SampleRate
+	require.Len(t, items, 3)
+	for i, want := range []struct {
+		id     int
+		frames int
+		offset int
+	}{
+		{newsID, 3, 0},
+		{jingleID, 2, 3},
+		{newsID, 3, 5},
+	} {
+		item := items[i]
+		require.Equal(t, int32(i), item.GetIndex())
+		require.Equal(t, int32(want.id), item.GetFileId())
+		require.Equal(t, int64(want.offset*mp3test.FrameLen), item.GetByteOffset())
+		require.Equal(t, time.Duration(want.offset*mp3test.FrameSamples)*time.Second/mp3test.SampleRate, item.GetTimeOffset().AsDuration())
+		require.InDelta(t, time.Duration(want.frames)*frame, item.GetDuration().AsDuration(), float64(time.Microsecond))
+	}
+
+	// Sample rates differ.
+	frame48k := bytes.Repeat(append([]byte{0xFF, 0xFB, 0x94, 0x00}, make([]byte, 380)...), 3)
+	otherID := upload(ctx, t, st, frame48k)
+	_, _, _, err = downloadSequence(ctx, st, newsID, otherID)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, _, _, err = downloadSequence(ctx, st)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: int32(otherID)})
+	require.NoError(t, err)
+	_, _, _, err = downloadSequence(ctx, st, newsID, otherID)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	flacID := upload(ctx, t, st, flacData)
+	_, _, _, err = downloadSequence(ctx, st, flacID)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// downloadSequence returns concatenated stream of files,
+// positions of its items and its content type.
+func downloadSequence(ctx context.Context, st *suite.Suite, ids ...int) ([]byte, []*storagev1.SequenceItem, string, error) {
+	req := &storagev1.DownloadSequenceRequest{}
+	for _, id := range ids {
+		req.FileIds = append(req.FileIds, int32(id))
+	}
+
+	stream, err := st.Client.DownloadSequence(ctx, req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var (
+		data        []byte
+		items       []*storagev1.SequenceItem
+		contentType string
+	)
+	for {
+		recv, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return data, items, contentType, nil
+			}
+			return nil, nil, "", err
+		}
+
+		if recv.GetContentType() != "" {
+			contentType = recv.GetContentType()
+		}
+		if item := recv.GetItem(); item != nil {
+			items = append(items, item)
+		}
+		data = append(data, recv.GetChunk()...)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFindSimilar(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ids := make([]int32, 0, 2)
+	for i := 0; i < 2; i++ {
+		resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(100))
+		require.NoError(t, err)
+		ids = append(ids, resp.GetFileId())
+	}
+
+	// Silence does not match other silence, nor itself.
+	res, err := srv.MediaClient.FindSimilar(ctx, &storagev1.FindSimilarRequest{FileId: ids[0]})
+	require.NoError(t, err)
+	require.Empty(t, res.GetFiles())
+
+	res, err = srv.MediaClient.FindSimilar(ctx, &storagev1.FindSimilarRequest{Clip: mp3test.Silence(200)})
+	require.NoError(t, err)
+	require.Empty(t, res.GetFiles())
+
+	for name, req := range map[string]*storagev1.FindSimilarRequest{
+		"not audio":      {Clip: []byte("not audio")},
+		"too short":      {Clip: mp3test.Silence(5)},
+		"min confidence": {FileId: ids[0], MinConfidence: 1.5},
+		"limit":          {FileId: ids[0], Limit: 1000},
+	} {
+		_, err = srv.MediaClient.FindSimilar(ctx, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), name)
+	}
+
+	_, err = srv.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: ids[1]})
+	require.NoError(t, err)
+	_, err = srv.MediaClient.FindSimilar(ctx, &storagev1.FindSimilarRequest{FileId: ids[1]})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// melody returns MP3 track of random two-note chords changing
+// every 12 granules, about 0.16 s, played at given gain.
+func melody(seed int64, seconds, gain int) []byte {
+	rnd := rand.New(rand.NewSource(seed))
+
+	granules := make([]mp3test.Granule, seconds*mp3test.SampleRate/mp3test.GranuleSamples)
+	var lines []int
+	for i := range granules {
+		if i%12 == 0 {
+			// Lines from 300 to 2000 Hz.
+			lines = []int{8 + rnd.Intn(45), 8 + rnd.Intn(45)}
+		}
+		granules[i] = mp3test.Granule{Lines: lines, Gain: gain}
+	}
+
+	return mp3test.Tones(granules)
+}
+
+func TestFindSimilarTracks(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	track := melody(1, 20, mp3test.FullGain-10)
+	uploaded, err := uploadWith(ctx, t, srv.Client, track)
+	require.NoError(t, err)
+	original := uploaded.GetFileId()
+	// Quieter copy of the same track.
+	uploaded, err = uploadWith(ctx, t, srv.Client, melody(1, 20, mp3test.FullGain-16))
+	require.NoError(t, err)
+	quieter := uploaded.GetFileId()
+	uploaded, err = uploadWith(ctx, t, srv.Client, melody(2, 20, mp3test.FullGain-10))
+	require.NoError(t, err)
+	unrelated := uploaded.GetFileId()
+
+	// Fingerprints are computed in background after upload.
+	var res *storagev1.FindSimilarResponse
+	require.Eventually(t, func() bool {
+		var err error
+		res, err = srv.MediaClient.FindSimilar(ctx, &storagev1.FindSimilarRequest{FileId: original})
+		require.NoError(t, err)
+		return len(res.GetFiles()) != 0
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Len(t, res.GetFiles(), 1)
+	require.Equal(t, quieter, res.GetFiles()[0].GetFileId())
+	require.Greater(t, res.GetFiles()[0].GetConfidence(), 0.9)
+
+	res, err = srv.MediaClient.FindSimilar(ctx, &storagev1.FindSimilarRequest{FileId: unrelated})
+	require.NoError(t, err)
+	require.Empty(t, res.GetFiles())
+
+	// Clip from the middle of the track is found at its position.
+	const clipStart = 200
+	clip := track[clipStart*mp3test.FrameLen : 600*mp3test.FrameLen]
+	res, err = srv.MediaClient.FindSimilar(ctx, &storagev1.FindSimilarRequest{Clip: clip})
+	require.NoError(t, err)
+
+	found := make([]int32, 0, len(res.GetFiles()))
+	for _, f := range res.GetFiles() {
+		found = append(found, f.GetFileId())
+		offset := time.Duration(clipStart*mp3test.FrameSamples) * time.Second / mp3test.SampleRate
+		require.InDelta(t, offset.Seconds(), f.GetOffset().AsDuration().Seconds(), 0.2)
+	}
+	require.ElementsMatch(t, []int32{original, quieter}, found)
+}
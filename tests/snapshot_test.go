@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"testing"
+
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	first := upload(ctx, t, st, []byte("first file"))
+	second := upload(ctx, t, st, []byte("second file"))
+
+	snapshot, err := st.AdminClient.CreateSnapshot(ctx, &storagev1.CreateSnapshotRequest{Name: "before-cleanup"})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), snapshot.GetFiles())
+	require.Equal(t, int64(len("first file")+len("second file")), snapshot.GetSize())
+
+	_, err = st.AdminClient.CreateSnapshot(ctx, &storagev1.CreateSnapshotRequest{Name: "before-cleanup"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = st.AdminClient.CreateSnapshot(ctx, &storagev1.CreateSnapshotRequest{Name: "../escape"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := st.AdminClient.ListSnapshots(ctx, &storagev1.ListSnapshotsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetSnapshots(), 1)
+	require.Equal(t, "before-cleanup", list.GetSnapshots()[0].GetName())
+
+	// Delete both files and restore them one by one.
+	for _, id := range []int{first, second} {
+		_, err := st.Client.Delete(ctx, &storagev1.DeleteRequest{FileId: int32(id)})
+		require.NoError(t, err)
+	}
+
+	restored, err := st.AdminClient.RestoreSnapshot(ctx, &storagev1.RestoreSnapshotRequest{
+		Name:    "before-cleanup",
+		FileIds: []int32{int32(first)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), restored.GetRestored())
+
+	data, err := download(ctx, t, st, first)
+	require.NoError(t, err)
+	require.Equal(t, []byte("first file"), data)
+
+	_, err = download(ctx, t, st, second)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	restored, err = st.AdminClient.RestoreSnapshot(ctx, &storagev1.RestoreSnapshotRequest{Name: "before-cleanup"})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), restored.GetRestored())
+
+	data, err = download(ctx, t, st, second)
+	require.NoError(t, err)
+	require.Equal(t, []byte("second file"), data)
+
+	_, err = st.AdminClient.DeleteSnapshot(ctx, &storagev1.DeleteSnapshotRequest{Name: "before-cleanup"})
+	require.NoError(t, err)
+
+	_, err = st.AdminClient.DeleteSnapshot(ctx, &storagev1.DeleteSnapshotRequest{Name: "before-cleanup"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
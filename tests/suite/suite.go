@@ -3,37 +3,48 @@ package suite
 import (
 	"context"
 	"fmt"
-	"net"
-	"os"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"radio-storage/internal/config"
+	"radio-storage/storagetest"
 
 	ssov1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Suite struct {
 	*testing.T
-	Cfg    *config.Config
-	Client ssov1.FileServiceClient
+	Cfg         *config.Config
+	Client      ssov1.FileServiceClient
+	AdminClient ssov1.AdminServiceClient
+	MediaClient ssov1.MediaServiceClient
 }
 
 const (
-	grpcHost = "localhost"
+	timeout = time.Minute
 )
 
-// New creates new test suite.
-//
-// TODO: for pipeline tests we need to wait for app is ready
+// New creates new test suite
+// backed by in-process storage server.
 func New(t *testing.T) (context.Context, *Suite) {
 	t.Helper()
 	t.Parallel()
 
-	cfg := config.MustLoadPath(configPath())
+	srv := storagetest.New(t)
+
+	cfg := &config.Config{
+		Env: "local",
+		GRPC: config.GRPCConfig{
+			Timeout: timeout,
+		},
+		Source: config.SourceStorage{
+			SourcePath:   srv.Dir,
+			NestingDepth: srv.NestingDepth,
+			IdLength:     srv.IdLength,
+		},
+	}
 
 	ctx, cancelCtx := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
 
@@ -42,18 +53,12 @@ func New(t *testing.T) (context.Context, *Suite) {
 		cancelCtx()
 	})
 
-	cc, err := grpc.NewClient(
-		grpcAddress(cfg),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		t.Fatalf("grpc server connection failed: %v", err)
-	}
-
 	return ctx, &Suite{
-		T:      t,
-		Cfg:    cfg,
-		Client: ssov1.NewFileServiceClient(cc),
+		T:           t,
+		Cfg:         cfg,
+		Client:      srv.Client,
+		AdminClient: srv.AdminClient,
+		MediaClient: srv.MediaClient,
 	}
 }
 
@@ -74,7 +79,7 @@ func (s *Suite) GetCorrespondingDir(id int) (string, error) {
 
 	splitted := make([]string, s.Cfg.Source.NestingDepth)
 
-	for j := 0; j < s.Cfg.Source.IdLength-len(str); j++ {
+	for j := 0; j < s.Cfg.Source.IdLength-len(str) && j < s.Cfg.Source.NestingDepth; j++ {
 		splitted[j] = "0"
 	}
 	for j := s.Cfg.Source.IdLength - len(str); j < s.Cfg.Source.NestingDepth; j++ {
@@ -83,17 +88,3 @@ func (s *Suite) GetCorrespondingDir(id int) (string, error) {
 
 	return s.Cfg.Source.SourcePath + "/" + strings.Join(splitted, "/"), nil
 }
-
-func configPath() string {
-	const key = "CONFIG_PATH"
-
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-
-	return "../config/local-tests.yaml"
-}
-
-func grpcAddress(cfg *config.Config) string {
-	return net.JoinHostPort(grpcHost, strconv.Itoa(cfg.GRPC.Port))
-}
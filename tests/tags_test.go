@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"testing"
+
+	"radio-storage/internal/lib/mp3"
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDownloadRewriteTags(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	var data []byte
+	data = append(data, mp3test.ID3v2(map[string]string{"TIT2": "Junk", "COMM": "visit example.com"})...)
+	data = append(data, mp3test.Silence(5)...)
+	data = append(data, mp3test.Sound(5)...)
+	data = append(data, mp3test.ID3v1("Junk", "Spam", "", "")...)
+	id := upload(ctx, t, st, data)
+
+	audio := append(mp3test.Silence(5), mp3test.Sound(5)...)
+
+	stripped, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:  int32(id),
+		TagMode: storagev1.TagMode_TAG_MODE_STRIP,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", contentType)
+	require.Equal(t, audio, stripped)
+
+	replaced, _, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:  int32(id),
+		TagMode: storagev1.TagMode_TAG_MODE_REPLACE,
+		Tags:    &storagev1.Tags{Title: "Song", Artist: "Артист"},
+	})
+	require.NoError(t, err)
+
+	tag := replaced[:mp3.ID3v2Size(replaced)]
+	tags, err := mp3.ParseID3v2(tag)
+	require.NoError(t, err)
+	require.Equal(t, mp3.Tags{Title: "Song", Artist: "Артист"}, tags)
+	require.Equal(t, audio, replaced[len(tag):])
+
+	// Silence is trimmed from rewritten file.
+	trimmed, _, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:      int32(id),
+		TagMode:     storagev1.TagMode_TAG_MODE_STRIP,
+		TrimSilence: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, mp3test.Sound(5), trimmed)
+
+	// Stored file is not affected.
+	original, err := download(ctx, t, st, id)
+	require.NoError(t, err)
+	require.Equal(t, data, original)
+
+	for _, req := range []*storagev1.DownloadRequest{
+		{FileId: int32(id), TagMode: storagev1.TagMode(3)},
+		{FileId: int32(id), TagMode: storagev1.TagMode_TAG_MODE_STRIP, OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV},
+		{FileId: int32(id), TagMode: storagev1.TagMode_TAG_MODE_STRIP, Tags: &storagev1.Tags{Title: "Song"}},
+	} {
+		_, _, err = downloadWith(ctx, t, st, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	flacID := upload(ctx, t, st, flacData)
+	_, _, err = downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:  int32(flacID),
+		TagMode: storagev1.TagMode_TAG_MODE_STRIP,
+	})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTempoKey(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(500))
+	require.NoError(t, err)
+
+	// Analysis runs in background after upload.
+	var tempoKey *storagev1.TempoKey
+	require.Eventually(t, func() bool {
+		metadata, err := srv.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: resp.GetFileId()})
+		require.NoError(t, err)
+		tempoKey = metadata.GetTempoKey()
+		return tempoKey != nil
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// Silence has neither beat nor key.
+	require.Zero(t, tempoKey.GetBpm())
+	require.Empty(t, tempoKey.GetKey())
+	require.Empty(t, tempoKey.GetCamelot())
+}
+
+// song returns MP3 track of chords of given MDCT lines,
+// every one held for four beats and struck on every beat.
+func song(bpm float64, chords [][]int, seconds int) []byte {
+	granule := float64(mp3test.GranuleSamples) / mp3test.SampleRate
+	beat := 60 / bpm
+
+	granules := make([]mp3test.Granule, seconds*mp3test.SampleRate/mp3test.GranuleSamples)
+	for i := range granules {
+		t := float64(i) * granule
+		n := int(t / beat)
+		// Chord decays by 0.75 dB per granule after the beat.
+		since := int(math.Round((t - float64(n)*beat) / granule))
+		granules[i] = mp3test.Granule{
+			Lines: chords[n/4%len(chords)],
+			Gain:  mp3test.FullGain - 10 - since/2,
+		}
+	}
+
+	return mp3test.Tones(granules)
+}
+
+func TestTempoKeyDetected(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Line k sounds at (k+0.5)*mp3test.LineFreq Hz, so lines are
+	// C5 13, D5 15, E5 17, F5 18, G5 20, G#5 21, A4 11 and B5 25,
+	// all within 30 cents of the notes.
+	for _, tt := range []struct {
+		name    string
+		bpm     float64
+		chords  [][]int
+		key     string
+		camelot string
+	}{
+		// I-IV-V-I.
+		{"C major", 100, [][]int{{13, 17, 20}, {18, 11, 13}, {20, 25, 15}, {13, 17, 20}}, "C major", "8B"},
+		// i-iv-V-i.
+		{"A minor", 140, [][]int{{11, 13, 17}, {15, 18, 11}, {17, 21, 25}, {11, 13, 17}}, "A minor", "8A"},
+	} {
+		resp, err := uploadWith(ctx, t, srv.Client, song(tt.bpm, tt.chords, 20))
+		require.NoError(t, err)
+
+		var tempoKey *storagev1.TempoKey
+		require.Eventually(t, func() bool {
+			metadata, err := srv.MediaClient.GetMetadata(ctx, &storagev1.GetMetadataRequest{FileId: resp.GetFileId()})
+			require.NoError(t, err)
+			tempoKey = metadata.GetTempoKey()
+			return tempoKey != nil
+		}, 10*time.Second, 50*time.Millisecond)
+
+		require.InDelta(t, tt.bpm, tempoKey.GetBpm(), 1.5, tt.name)
+		require.Equal(t, tt.key, tempoKey.GetKey(), tt.name)
+		require.Equal(t, tt.camelot, tempoKey.GetCamelot(), tt.name)
+	}
+}
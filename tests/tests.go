@@ -1,6 +1,113 @@
 package tests
 
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
 const (
 	maxDataSize  = 1024 * 1024 * 100 // 100 MB
 	maxBufferLen = 1024              // 32 kB
 )
+
+// uploadOption sets fields of the first upload request.
+type uploadOption func(req *storagev1.UploadRequest)
+
+// withLabels uploads file with labels.
+func withLabels(labels map[string]string) uploadOption {
+	return func(req *storagev1.UploadRequest) {
+		req.Labels = labels
+	}
+}
+
+// withSize declares expected size of uploaded file.
+func withSize(size int64) uploadOption {
+	return func(req *storagev1.UploadRequest) {
+		req.Size = size
+	}
+}
+
+// withExpiry uploads file expiring at given time.
+func withExpiry(at *timestamppb.Timestamp) uploadOption {
+	return func(req *storagev1.UploadRequest) {
+		req.ExpiresAt = at
+	}
+}
+
+// upload uploads data and returns id of created file.
+func upload(ctx context.Context, t *testing.T, st *suite.Suite, data []byte) int {
+	t.Helper()
+
+	resp, err := uploadWith(ctx, t, st.Client, data)
+	require.NoError(t, err)
+
+	return int(resp.GetFileId())
+}
+
+// uploadWith uploads data in chunks and returns response.
+// Upload may be rejected before all chunks are sent,
+// then error of rejection is returned.
+func uploadWith(ctx context.Context, t *testing.T, client storagev1.FileServiceClient, data []byte, opts ...uploadOption) (*storagev1.UploadResponse, error) {
+	t.Helper()
+
+	stream, err := client.Upload(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i == 0 || i < len(data); i += maxBufferLen {
+		req := &storagev1.UploadRequest{Chunk: data[i:min(i+maxBufferLen, len(data))]}
+		if i == 0 {
+			for _, opt := range opts {
+				opt(req)
+			}
+		}
+
+		if err := stream.Send(req); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+// download returns content of the file.
+func download(ctx context.Context, t *testing.T, st *suite.Suite, id int) ([]byte, error) {
+	t.Helper()
+
+	data, _, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{FileId: int32(id)})
+	return data, err
+}
+
+// downloadWith returns content and content type
+// of the file downloaded with given request.
+func downloadWith(ctx context.Context, t *testing.T, st *suite.Suite, req *storagev1.DownloadRequest) ([]byte, string, error) {
+	t.Helper()
+
+	stream, err := st.Client.Download(ctx, req)
+	require.NoError(t, err)
+
+	data := make([]byte, 0)
+	contentType := ""
+	for {
+		recv, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return data, contentType, nil
+			}
+			return nil, "", err
+		}
+
+		if recv.GetContentType() != "" {
+			contentType = recv.GetContentType()
+		}
+		data = append(data, recv.GetChunk()...)
+	}
+}
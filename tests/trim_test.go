@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"testing"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/tests/suite"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDownloadTrimSilence(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	tag := mp3test.ID3v2(map[string]string{"TIT2": "Jingle"})
+
+	var data []byte
+	data = append(data, tag...)
+	data = append(data, mp3test.Silence(10)...)
+	data = append(data, mp3test.Sound(5)...)
+	data = append(data, mp3test.Silence(10)...)
+	id := upload(ctx, t, st, data)
+
+	// Tags and one silent frame after audio are kept.
+	var want []byte
+	want = append(want, tag...)
+	want = append(want, mp3test.Sound(5)...)
+	want = append(want, mp3test.Silence(1)...)
+
+	trimmed, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:      int32(id),
+		TrimSilence: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", contentType)
+	require.Equal(t, want, trimmed)
+
+	// Stored file is not affected.
+	original, err := download(ctx, t, st, id)
+	require.NoError(t, err)
+	require.Equal(t, data, original)
+
+	// Decoded output is trimmed the same way.
+	decoded, contentType, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV,
+		TrimSilence:  true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "audio/wav", contentType)
+
+	wantID := upload(ctx, t, st, want)
+	wantDecoded, _, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(wantID),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV,
+	})
+	require.NoError(t, err)
+	require.Equal(t, wantDecoded, decoded)
+
+	untrimmed, _, err := downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:       int32(id),
+		OutputFormat: storagev1.OutputFormat_OUTPUT_FORMAT_WAV,
+	})
+	require.NoError(t, err)
+	require.Less(t, len(decoded), len(untrimmed))
+
+	// Only MP3 is trimmed.
+	flacID := upload(ctx, t, st, flacData)
+	_, _, err = downloadWith(ctx, t, st, &storagev1.DownloadRequest{
+		FileId:      int32(flacID),
+		TrimSilence: true,
+	})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
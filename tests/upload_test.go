@@ -19,7 +19,7 @@ func TestUpload(t *testing.T) {
 	var size int64 = rand.Int63n(maxDataSize)
 	data := make([]byte, 0, size)
 
-	var bufferSize int64 = rand.Int63n(maxBufferLen)
+	var bufferSize int64 = rand.Int63n(maxBufferLen) + 1
 
 	stream, err := st.Client.Upload(ctx)
 	require.NoError(t, err)
@@ -49,7 +49,7 @@ func TestUpload(t *testing.T) {
 	// Get filename of file.
 	dir, err := st.GetCorrespondingDir(id)
 	require.NoError(t, err)
-	filename := fmt.Sprintf("../%s/%d.mp3", dir, id)
+	filename := fmt.Sprintf("%s/%d.mp3", dir, id)
 
 	// Extract actual data.
 	dataActual, err := os.ReadFile(filename)
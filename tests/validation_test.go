@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/storagetest"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithValidation(storagetest.ValidationPolicy{
+		MinSize:      1024,
+		MinSyncRatio: 0.95,
+		SampleRates:  []int{44100},
+		MaxDuration:  10 * time.Second,
+		Quarantine:   true,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	violations := func(err error) []string {
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+
+		res := make([]string, 0)
+		for _, detail := range st.Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				for _, v := range badRequest.GetFieldViolations() {
+					res = append(res, v.GetField())
+				}
+			}
+		}
+		return res
+	}
+
+	_, err := uploadWith(ctx, t, srv.Client, nil)
+	require.Equal(t, []string{"min_size", "min_sync_ratio", "sample_rates"}, violations(err))
+
+	_, err = uploadWith(ctx, t, srv.Client, []byte(strings.Repeat("<html><body>502 Bad Gateway</body></html>", 100)))
+	require.Equal(t, []string{"min_sync_ratio", "sample_rates"}, violations(err))
+
+	_, err = uploadWith(ctx, t, srv.Client, mp3test.Silence(1000))
+	require.Equal(t, []string{"max_duration"}, violations(err))
+
+	resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(100))
+	require.NoError(t, err)
+	require.Equal(t, "audio/mpeg", resp.GetContentType())
+
+	// Rejected uploads are kept with reports.
+	entries, err := os.ReadDir(srv.Dir + "/quarantine")
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"radio-storage/internal/lib/mp3/mp3test"
+	"radio-storage/internal/lib/waveform"
+	"radio-storage/storagetest"
+
+	storagev1 "github.com/GintGld/fizteh-radio-proto/gen/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWaveform(t *testing.T) {
+	t.Parallel()
+
+	srv := storagetest.New(t, storagetest.WithAnalysis())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	const frames = 100
+
+	resp, err := uploadWith(ctx, t, srv.Client, mp3test.Silence(frames))
+	require.NoError(t, err)
+	id := int(resp.GetFileId())
+
+	// Waveforms are cached next to the file after upload.
+	pattern := srv.Dir + strings.Repeat("/*", srv.NestingDepth) + "/" + strconv.Itoa(id) + ".d"
+	var sidecar string
+	require.Eventually(t, func() bool {
+		matches, err := filepath.Glob(pattern + "/waveform.json")
+		require.NoError(t, err)
+		if len(matches) == 0 {
+			return false
+		}
+		sidecar = filepath.Dir(matches[0])
+		return true
+	}, 10*time.Second, 50*time.Millisecond)
+	require.FileExists(t, sidecar+"/waveform-256.dat")
+	require.FileExists(t, sidecar+"/waveform-4096.dat")
+
+	samples := frames * mp3test.FrameSamples
+
+	res, err := srv.MediaClient.GetWaveform(ctx, &storagev1.GetWaveformRequest{FileId: int32(id), Resolution: 2048, Bits: 8})
+	require.NoError(t, err)
+	require.Equal(t, "application/json", res.GetContentType())
+
+	var w struct {
+		Version         int     `json:"version"`
+		SampleRate      int     `json:"sample_rate"`
+		SamplesPerPixel int     `json:"samples_per_pixel"`
+		Bits            int     `json:"bits"`
+		Length          int     `json:"length"`
+		Data            []int16 `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(res.GetData(), &w))
+	require.Equal(t, 2, w.Version)
+	require.Equal(t, mp3test.SampleRate, w.SampleRate)
+	require.Equal(t, 2048, w.SamplesPerPixel)
+	require.Equal(t, 8, w.Bits)
+	require.Equal(t, (samples+2047)/2048, w.Length)
+	require.Equal(t, make([]int16, 2*w.Length), w.Data)
+
+	res, err = srv.MediaClient.GetWaveform(ctx, &storagev1.GetWaveformRequest{
+		FileId: int32(id),
+		Format: storagev1.WaveformFormat_WAVEFORM_FORMAT_BINARY,
+	})
+	require.NoError(t, err)
+	var bw waveform.Waveform
+	require.NoError(t, bw.UnmarshalBinary(res.GetData()))
+	require.Equal(t, 256, bw.SamplesPerPixel)
+	require.Equal(t, 16, bw.Bits)
+	require.Equal(t, (samples+255)/256, bw.Len())
+
+	_, err = srv.MediaClient.GetWaveform(ctx, &storagev1.GetWaveformRequest{FileId: int32(id), Resolution: 100})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = srv.MediaClient.GetWaveform(ctx, &storagev1.GetWaveformRequest{FileId: int32(id + 1)})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
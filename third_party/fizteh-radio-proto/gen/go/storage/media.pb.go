@@ -0,0 +1,1621 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.12.4
+// source: storage/media.proto
+
+package storagev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BitrateMode int32
+
+const (
+	BitrateMode_BITRATE_MODE_UNSPECIFIED BitrateMode = 0
+	BitrateMode_BITRATE_MODE_CBR         BitrateMode = 1
+	BitrateMode_BITRATE_MODE_VBR         BitrateMode = 2
+	BitrateMode_BITRATE_MODE_ABR         BitrateMode = 3
+)
+
+// Enum value maps for BitrateMode.
+var (
+	BitrateMode_name = map[int32]string{
+		0: "BITRATE_MODE_UNSPECIFIED",
+		1: "BITRATE_MODE_CBR",
+		2: "BITRATE_MODE_VBR",
+		3: "BITRATE_MODE_ABR",
+	}
+	BitrateMode_value = map[string]int32{
+		"BITRATE_MODE_UNSPECIFIED": 0,
+		"BITRATE_MODE_CBR":         1,
+		"BITRATE_MODE_VBR":         2,
+		"BITRATE_MODE_ABR":         3,
+	}
+)
+
+func (x BitrateMode) Enum() *BitrateMode {
+	p := new(BitrateMode)
+	*p = x
+	return p
+}
+
+func (x BitrateMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BitrateMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_storage_media_proto_enumTypes[0].Descriptor()
+}
+
+func (BitrateMode) Type() protoreflect.EnumType {
+	return &file_storage_media_proto_enumTypes[0]
+}
+
+func (x BitrateMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BitrateMode.Descriptor instead.
+func (BitrateMode) EnumDescriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{0}
+}
+
+// Waveform encoding compatible with audiowaveform.
+type WaveformFormat int32
+
+const (
+	WaveformFormat_WAVEFORM_FORMAT_JSON   WaveformFormat = 0
+	WaveformFormat_WAVEFORM_FORMAT_BINARY WaveformFormat = 1
+)
+
+// Enum value maps for WaveformFormat.
+var (
+	WaveformFormat_name = map[int32]string{
+		0: "WAVEFORM_FORMAT_JSON",
+		1: "WAVEFORM_FORMAT_BINARY",
+	}
+	WaveformFormat_value = map[string]int32{
+		"WAVEFORM_FORMAT_JSON":   0,
+		"WAVEFORM_FORMAT_BINARY": 1,
+	}
+)
+
+func (x WaveformFormat) Enum() *WaveformFormat {
+	p := new(WaveformFormat)
+	*p = x
+	return p
+}
+
+func (x WaveformFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WaveformFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_storage_media_proto_enumTypes[1].Descriptor()
+}
+
+func (WaveformFormat) Type() protoreflect.EnumType {
+	return &file_storage_media_proto_enumTypes[1]
+}
+
+func (x WaveformFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WaveformFormat.Descriptor instead.
+func (WaveformFormat) EnumDescriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{1}
+}
+
+type GetMetadataRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+}
+
+func (x *GetMetadataRequest) Reset() {
+	*x = GetMetadataRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetadataRequest) ProtoMessage() {}
+
+func (x *GetMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetadataRequest.ProtoReflect.Descriptor instead.
+func (*GetMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetMetadataRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+type Metadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId      int32                `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Title       string               `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Artist      string               `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	Album       string               `protobuf:"bytes,4,opt,name=album,proto3" json:"album,omitempty"`
+	Year        string               `protobuf:"bytes,5,opt,name=year,proto3" json:"year,omitempty"`
+	HasCover    bool                 `protobuf:"varint,6,opt,name=has_cover,json=hasCover,proto3" json:"has_cover,omitempty"`
+	Duration    *durationpb.Duration `protobuf:"bytes,7,opt,name=duration,proto3" json:"duration,omitempty"`
+	Bitrate     int32                `protobuf:"varint,8,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	BitrateMode BitrateMode          `protobuf:"varint,9,opt,name=bitrate_mode,json=bitrateMode,proto3,enum=storage.BitrateMode" json:"bitrate_mode,omitempty"`
+	SampleRate  int32                `protobuf:"varint,10,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Channels    int32                `protobuf:"varint,11,opt,name=channels,proto3" json:"channels,omitempty"`
+	Format      string               `protobuf:"bytes,12,opt,name=format,proto3" json:"format,omitempty"`
+	ContentType string               `protobuf:"bytes,13,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Set once file is analyzed.
+	Loudness *Loudness `protobuf:"bytes,14,opt,name=loudness,proto3" json:"loudness,omitempty"`
+	TempoKey *TempoKey `protobuf:"bytes,15,opt,name=tempo_key,json=tempoKey,proto3" json:"tempo_key,omitempty"`
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Metadata) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *Metadata) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Metadata) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
+}
+
+func (x *Metadata) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *Metadata) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *Metadata) GetHasCover() bool {
+	if x != nil {
+		return x.HasCover
+	}
+	return false
+}
+
+func (x *Metadata) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *Metadata) GetBitrate() int32 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *Metadata) GetBitrateMode() BitrateMode {
+	if x != nil {
+		return x.BitrateMode
+	}
+	return BitrateMode_BITRATE_MODE_UNSPECIFIED
+}
+
+func (x *Metadata) GetSampleRate() int32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *Metadata) GetChannels() int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+func (x *Metadata) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Metadata) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Metadata) GetLoudness() *Loudness {
+	if x != nil {
+		return x.Loudness
+	}
+	return nil
+}
+
+func (x *Metadata) GetTempoKey() *TempoKey {
+	if x != nil {
+		return x.TempoKey
+	}
+	return nil
+}
+
+type Loudness struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Integrated loudness, LUFS.
+	Integrated float64 `protobuf:"fixed64,1,opt,name=integrated,proto3" json:"integrated,omitempty"`
+	// Loudness range, LU.
+	Range float64 `protobuf:"fixed64,2,opt,name=range,proto3" json:"range,omitempty"`
+	// True peak, dBTP.
+	TruePeak float64 `protobuf:"fixed64,3,opt,name=true_peak,json=truePeak,proto3" json:"true_peak,omitempty"`
+	// ReplayGain 2.0 track gain, dB.
+	TrackGain float64 `protobuf:"fixed64,4,opt,name=track_gain,json=trackGain,proto3" json:"track_gain,omitempty"`
+}
+
+func (x *Loudness) Reset() {
+	*x = Loudness{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Loudness) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Loudness) ProtoMessage() {}
+
+func (x *Loudness) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Loudness.ProtoReflect.Descriptor instead.
+func (*Loudness) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Loudness) GetIntegrated() float64 {
+	if x != nil {
+		return x.Integrated
+	}
+	return 0
+}
+
+func (x *Loudness) GetRange() float64 {
+	if x != nil {
+		return x.Range
+	}
+	return 0
+}
+
+func (x *Loudness) GetTruePeak() float64 {
+	if x != nil {
+		return x.TruePeak
+	}
+	return 0
+}
+
+func (x *Loudness) GetTrackGain() float64 {
+	if x != nil {
+		return x.TrackGain
+	}
+	return 0
+}
+
+type TempoKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Zero if track has no beat.
+	Bpm float64 `protobuf:"fixed64,1,opt,name=bpm,proto3" json:"bpm,omitempty"`
+	// Like "A minor", empty if key is not detected.
+	Key string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// Code of the key on Camelot wheel like "8A".
+	Camelot string `protobuf:"bytes,3,opt,name=camelot,proto3" json:"camelot,omitempty"`
+}
+
+func (x *TempoKey) Reset() {
+	*x = TempoKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TempoKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TempoKey) ProtoMessage() {}
+
+func (x *TempoKey) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TempoKey.ProtoReflect.Descriptor instead.
+func (*TempoKey) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TempoKey) GetBpm() float64 {
+	if x != nil {
+		return x.Bpm
+	}
+	return 0
+}
+
+func (x *TempoKey) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TempoKey) GetCamelot() string {
+	if x != nil {
+		return x.Camelot
+	}
+	return ""
+}
+
+type GetWaveformRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Samples per pixel, multiple of 256.
+	// Zero selects 256.
+	Resolution int32          `protobuf:"varint,2,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	Format     WaveformFormat `protobuf:"varint,3,opt,name=format,proto3,enum=storage.WaveformFormat" json:"format,omitempty"`
+	// Bits per value, 8 or 16. Zero selects 16.
+	Bits int32 `protobuf:"varint,4,opt,name=bits,proto3" json:"bits,omitempty"`
+}
+
+func (x *GetWaveformRequest) Reset() {
+	*x = GetWaveformRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetWaveformRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWaveformRequest) ProtoMessage() {}
+
+func (x *GetWaveformRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWaveformRequest.ProtoReflect.Descriptor instead.
+func (*GetWaveformRequest) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetWaveformRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *GetWaveformRequest) GetResolution() int32 {
+	if x != nil {
+		return x.Resolution
+	}
+	return 0
+}
+
+func (x *GetWaveformRequest) GetFormat() WaveformFormat {
+	if x != nil {
+		return x.Format
+	}
+	return WaveformFormat_WAVEFORM_FORMAT_JSON
+}
+
+func (x *GetWaveformRequest) GetBits() int32 {
+	if x != nil {
+		return x.Bits
+	}
+	return 0
+}
+
+type Waveform struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data        []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	ContentType string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+}
+
+func (x *Waveform) Reset() {
+	*x = Waveform{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Waveform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Waveform) ProtoMessage() {}
+
+func (x *Waveform) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Waveform.ProtoReflect.Descriptor instead.
+func (*Waveform) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Waveform) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Waveform) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+// Cue points are positions in a track used for segues.
+type CuePoints struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// End of leading silence.
+	Start *durationpb.Duration `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	// Start of trailing silence.
+	End *durationpb.Duration `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	// Start of fade out.
+	FadeOut *durationpb.Duration `protobuf:"bytes,3,opt,name=fade_out,json=fadeOut,proto3" json:"fade_out,omitempty"`
+	// Estimated end of intro.
+	IntroEnd *durationpb.Duration `protobuf:"bytes,4,opt,name=intro_end,json=introEnd,proto3" json:"intro_end,omitempty"`
+}
+
+func (x *CuePoints) Reset() {
+	*x = CuePoints{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CuePoints) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CuePoints) ProtoMessage() {}
+
+func (x *CuePoints) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CuePoints.ProtoReflect.Descriptor instead.
+func (*CuePoints) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CuePoints) GetStart() *durationpb.Duration {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *CuePoints) GetEnd() *durationpb.Duration {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *CuePoints) GetFadeOut() *durationpb.Duration {
+	if x != nil {
+		return x.FadeOut
+	}
+	return nil
+}
+
+func (x *CuePoints) GetIntroEnd() *durationpb.Duration {
+	if x != nil {
+		return x.IntroEnd
+	}
+	return nil
+}
+
+type GetCuePointsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+}
+
+func (x *GetCuePointsRequest) Reset() {
+	*x = GetCuePointsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCuePointsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCuePointsRequest) ProtoMessage() {}
+
+func (x *GetCuePointsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCuePointsRequest.ProtoReflect.Descriptor instead.
+func (*GetCuePointsRequest) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCuePointsRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+type SetCuePointsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Unset fields keep detected cue points,
+	// empty override restores them all.
+	Override *CuePoints `protobuf:"bytes,2,opt,name=override,proto3" json:"override,omitempty"`
+}
+
+func (x *SetCuePointsRequest) Reset() {
+	*x = SetCuePointsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetCuePointsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetCuePointsRequest) ProtoMessage() {}
+
+func (x *SetCuePointsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetCuePointsRequest.ProtoReflect.Descriptor instead.
+func (*SetCuePointsRequest) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SetCuePointsRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *SetCuePointsRequest) GetOverride() *CuePoints {
+	if x != nil {
+		return x.Override
+	}
+	return nil
+}
+
+type Cues struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Cue points used for playout.
+	Effective *CuePoints `protobuf:"bytes,2,opt,name=effective,proto3" json:"effective,omitempty"`
+	Detected  *CuePoints `protobuf:"bytes,3,opt,name=detected,proto3" json:"detected,omitempty"`
+	// Only set fields are overridden.
+	Override *CuePoints `protobuf:"bytes,4,opt,name=override,proto3" json:"override,omitempty"`
+}
+
+func (x *Cues) Reset() {
+	*x = Cues{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Cues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cues) ProtoMessage() {}
+
+func (x *Cues) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cues.ProtoReflect.Descriptor instead.
+func (*Cues) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Cues) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *Cues) GetEffective() *CuePoints {
+	if x != nil {
+		return x.Effective
+	}
+	return nil
+}
+
+func (x *Cues) GetDetected() *CuePoints {
+	if x != nil {
+		return x.Detected
+	}
+	return nil
+}
+
+func (x *Cues) GetOverride() *CuePoints {
+	if x != nil {
+		return x.Override
+	}
+	return nil
+}
+
+// Searches stored files sounding like given audio
+// by acoustic fingerprints.
+type FindSimilarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Stored file to search for, used if clip is empty.
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// MP3 audio to search for.
+	Clip []byte `protobuf:"bytes,2,opt,name=clip,proto3" json:"clip,omitempty"`
+	// From 0 to 1. Zero selects 0.5.
+	MinConfidence float64 `protobuf:"fixed64,3,opt,name=min_confidence,json=minConfidence,proto3" json:"min_confidence,omitempty"`
+	// Maximum number of results, up to 100. Zero selects 10.
+	Limit int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *FindSimilarRequest) Reset() {
+	*x = FindSimilarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindSimilarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindSimilarRequest) ProtoMessage() {}
+
+func (x *FindSimilarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindSimilarRequest.ProtoReflect.Descriptor instead.
+func (*FindSimilarRequest) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FindSimilarRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *FindSimilarRequest) GetClip() []byte {
+	if x != nil {
+		return x.Clip
+	}
+	return nil
+}
+
+func (x *FindSimilarRequest) GetMinConfidence() float64 {
+	if x != nil {
+		return x.MinConfidence
+	}
+	return 0
+}
+
+func (x *FindSimilarRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SimilarFile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Position in the file where searched audio starts.
+	Offset *durationpb.Duration `protobuf:"bytes,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// From 0 for unrelated audio to 1 for identical one.
+	Confidence float64 `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+}
+
+func (x *SimilarFile) Reset() {
+	*x = SimilarFile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SimilarFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarFile) ProtoMessage() {}
+
+func (x *SimilarFile) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarFile.ProtoReflect.Descriptor instead.
+func (*SimilarFile) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SimilarFile) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *SimilarFile) GetOffset() *durationpb.Duration {
+	if x != nil {
+		return x.Offset
+	}
+	return nil
+}
+
+func (x *SimilarFile) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type FindSimilarResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Best match first.
+	Files []*SimilarFile `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+}
+
+func (x *FindSimilarResponse) Reset() {
+	*x = FindSimilarResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindSimilarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindSimilarResponse) ProtoMessage() {}
+
+func (x *FindSimilarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindSimilarResponse.ProtoReflect.Descriptor instead.
+func (*FindSimilarResponse) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FindSimilarResponse) GetFiles() []*SimilarFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+// Cover art embedded in the file.
+type GetArtworkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileId int32 `protobuf:"varint,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Size of square scaled artwork fits,
+	// one of 100, 300 or 600. Zero selects original one.
+	Size int32 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+}
+
+func (x *GetArtworkRequest) Reset() {
+	*x = GetArtworkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetArtworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetArtworkRequest) ProtoMessage() {}
+
+func (x *GetArtworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetArtworkRequest.ProtoReflect.Descriptor instead.
+func (*GetArtworkRequest) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetArtworkRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *GetArtworkRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type Artwork struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data        []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	ContentType string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Width       int32  `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height      int32  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *Artwork) Reset() {
+	*x = Artwork{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_media_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Artwork) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artwork) ProtoMessage() {}
+
+func (x *Artwork) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_media_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artwork.ProtoReflect.Descriptor instead.
+func (*Artwork) Descriptor() ([]byte, []int) {
+	return file_storage_media_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Artwork) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Artwork) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Artwork) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Artwork) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+var File_storage_media_proto protoreflect.FileDescriptor
+
+var file_storage_media_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2f, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x1a, 0x1e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x2d,
+	0x0a, 0x12, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x65, 0x49, 0x64, 0x22, 0xf9, 0x03,
+	0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69,
+	0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c,
+	0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x72, 0x74,
+	0x69, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x72, 0x74, 0x69, 0x73,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x79, 0x65, 0x61, 0x72, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x79, 0x65, 0x61, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x68,
+	0x61, 0x73, 0x5f, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x68, 0x61, 0x73, 0x43, 0x6f, 0x76, 0x65, 0x72, 0x12, 0x35, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x18, 0x0a, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x12, 0x37, 0x0a, 0x0c, 0x62, 0x69, 0x74,
+	0x72, 0x61, 0x74, 0x65, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x14, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x42, 0x69, 0x74, 0x72, 0x61, 0x74,
+	0x65, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0b, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x4d, 0x6f,
+	0x64, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x72, 0x61, 0x74,
+	0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x52,
+	0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2d, 0x0a, 0x08, 0x6c, 0x6f,
+	0x75, 0x64, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x4c, 0x6f, 0x75, 0x64, 0x6e, 0x65, 0x73, 0x73, 0x52,
+	0x08, 0x6c, 0x6f, 0x75, 0x64, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x2e, 0x0a, 0x09, 0x74, 0x65, 0x6d,
+	0x70, 0x6f, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6f, 0x4b, 0x65, 0x79, 0x52,
+	0x08, 0x74, 0x65, 0x6d, 0x70, 0x6f, 0x4b, 0x65, 0x79, 0x22, 0x7c, 0x0a, 0x08, 0x4c, 0x6f, 0x75,
+	0x64, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x61,
+	0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67,
+	0x72, 0x61, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x74,
+	0x72, 0x75, 0x65, 0x5f, 0x70, 0x65, 0x61, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08,
+	0x74, 0x72, 0x75, 0x65, 0x50, 0x65, 0x61, 0x6b, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63,
+	0x6b, 0x5f, 0x67, 0x61, 0x69, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x74, 0x72,
+	0x61, 0x63, 0x6b, 0x47, 0x61, 0x69, 0x6e, 0x22, 0x48, 0x0a, 0x08, 0x54, 0x65, 0x6d, 0x70, 0x6f,
+	0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x62, 0x70, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x03, 0x62, 0x70, 0x6d, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x61, 0x6d, 0x65, 0x6c,
+	0x6f, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x61, 0x6d, 0x65, 0x6c, 0x6f,
+	0x74, 0x22, 0x92, 0x01, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x57, 0x61, 0x76, 0x65, 0x66, 0x6f, 0x72,
+	0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69, 0x6c, 0x65,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x65, 0x49,
+	0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x2f, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x17, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x57, 0x61, 0x76, 0x65,
+	0x66, 0x6f, 0x72, 0x6d, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x69, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x62, 0x69, 0x74, 0x73, 0x22, 0x41, 0x0a, 0x08, 0x57, 0x61, 0x76, 0x65, 0x66, 0x6f,
+	0x72, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x22, 0xd7, 0x01, 0x0a, 0x09, 0x43, 0x75,
+	0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x2f, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2b, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x34, 0x0a, 0x08, 0x66, 0x61, 0x64, 0x65, 0x5f, 0x6f, 0x75,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x07, 0x66, 0x61, 0x64, 0x65, 0x4f, 0x75, 0x74, 0x12, 0x36, 0x0a, 0x09, 0x69,
+	0x6e, 0x74, 0x72, 0x6f, 0x5f, 0x65, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x69, 0x6e, 0x74, 0x72, 0x6f,
+	0x45, 0x6e, 0x64, 0x22, 0x2e, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69,
+	0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c,
+	0x65, 0x49, 0x64, 0x22, 0x5e, 0x0a, 0x13, 0x53, 0x65, 0x74, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69,
+	0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c,
+	0x65, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x08, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
+	0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x08, 0x6f, 0x76, 0x65, 0x72, 0x72,
+	0x69, 0x64, 0x65, 0x22, 0xb1, 0x01, 0x0a, 0x04, 0x43, 0x75, 0x65, 0x73, 0x12, 0x17, 0x0a, 0x07,
+	0x66, 0x69, 0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66,
+	0x69, 0x6c, 0x65, 0x49, 0x64, 0x12, 0x30, 0x0a, 0x09, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x09, 0x65, 0x66,
+	0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x2e, 0x0a, 0x08, 0x64, 0x65, 0x74, 0x65, 0x63,
+	0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x6f, 0x72,
+	0x61, 0x67, 0x65, 0x2e, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x08, 0x64,
+	0x65, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x2e, 0x0a, 0x08, 0x6f, 0x76, 0x65, 0x72, 0x72,
+	0x69, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x6f, 0x72,
+	0x61, 0x67, 0x65, 0x2e, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x08, 0x6f,
+	0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x22, 0x7e, 0x0a, 0x12, 0x46, 0x69, 0x6e, 0x64, 0x53,
+	0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a,
+	0x07, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x65, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6c, 0x69, 0x70, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x63, 0x6c, 0x69, 0x70, 0x12, 0x25, 0x0a, 0x0e, 0x6d, 0x69,
+	0x6e, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0d, 0x6d, 0x69, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x79, 0x0a, 0x0b, 0x53, 0x69, 0x6d, 0x69, 0x6c,
+	0x61, 0x72, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x65, 0x49, 0x64, 0x12,
+	0x31, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73,
+	0x65, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e,
+	0x63, 0x65, 0x22, 0x41, 0x0a, 0x13, 0x46, 0x69, 0x6e, 0x64, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61,
+	0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x66, 0x69, 0x6c,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x05,
+	0x66, 0x69, 0x6c, 0x65, 0x73, 0x22, 0x40, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x41, 0x72, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x69,
+	0x6c, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x69, 0x6c,
+	0x65, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x22, 0x6e, 0x0a, 0x07, 0x41, 0x72, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64,
+	0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12,
+	0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x2a, 0x6d, 0x0a, 0x0b, 0x42, 0x69, 0x74, 0x72, 0x61,
+	0x74, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x18, 0x42, 0x49, 0x54, 0x52, 0x41, 0x54,
+	0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x42, 0x49, 0x54, 0x52, 0x41, 0x54, 0x45, 0x5f,
+	0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x43, 0x42, 0x52, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x42, 0x49,
+	0x54, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x56, 0x42, 0x52, 0x10, 0x02,
+	0x12, 0x14, 0x0a, 0x10, 0x42, 0x49, 0x54, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45,
+	0x5f, 0x41, 0x42, 0x52, 0x10, 0x03, 0x2a, 0x46, 0x0a, 0x0e, 0x57, 0x61, 0x76, 0x65, 0x66, 0x6f,
+	0x72, 0x6d, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x18, 0x0a, 0x14, 0x57, 0x41, 0x56, 0x45,
+	0x46, 0x4f, 0x52, 0x4d, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x4a, 0x53, 0x4f, 0x4e,
+	0x10, 0x00, 0x12, 0x1a, 0x0a, 0x16, 0x57, 0x41, 0x56, 0x45, 0x46, 0x4f, 0x52, 0x4d, 0x5f, 0x46,
+	0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x42, 0x49, 0x4e, 0x41, 0x52, 0x59, 0x10, 0x01, 0x32, 0x8c,
+	0x03, 0x0a, 0x0c, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
+	0x3d, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1b,
+	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x3d,
+	0x0a, 0x0b, 0x47, 0x65, 0x74, 0x57, 0x61, 0x76, 0x65, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x1b, 0x2e,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x57, 0x61, 0x76, 0x65, 0x66,
+	0x6f, 0x72, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x73, 0x74, 0x6f,
+	0x72, 0x61, 0x67, 0x65, 0x2e, 0x57, 0x61, 0x76, 0x65, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x3b, 0x0a,
+	0x0c, 0x47, 0x65, 0x74, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x1c, 0x2e,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x75, 0x65, 0x50, 0x6f,
+	0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x43, 0x75, 0x65, 0x73, 0x12, 0x3b, 0x0a, 0x0c, 0x53, 0x65,
+	0x74, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x1c, 0x2e, 0x73, 0x74, 0x6f,
+	0x72, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x43, 0x75, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x43, 0x75, 0x65, 0x73, 0x12, 0x48, 0x0a, 0x0b, 0x46, 0x69, 0x6e, 0x64, 0x53,
+	0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x12, 0x1b, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x46, 0x69, 0x6e, 0x64, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x46, 0x69,
+	0x6e, 0x64, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x3a, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x41, 0x72, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12,
+	0x1a, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x72, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x41, 0x72, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x42, 0x1a, 0x5a,
+	0x18, 0x67, 0x6c, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x76, 0x31, 0x3b,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_storage_media_proto_rawDescOnce sync.Once
+	file_storage_media_proto_rawDescData = file_storage_media_proto_rawDesc
+)
+
+func file_storage_media_proto_rawDescGZIP() []byte {
+	file_storage_media_proto_rawDescOnce.Do(func() {
+		file_storage_media_proto_rawDescData = protoimpl.X.CompressGZIP(file_storage_media_proto_rawDescData)
+	})
+	return file_storage_media_proto_rawDescData
+}
+
+var file_storage_media_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_storage_media_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_storage_media_proto_goTypes = []any{
+	(BitrateMode)(0),            // 0: storage.BitrateMode
+	(WaveformFormat)(0),         // 1: storage.WaveformFormat
+	(*GetMetadataRequest)(nil),  // 2: storage.GetMetadataRequest
+	(*Metadata)(nil),            // 3: storage.Metadata
+	(*Loudness)(nil),            // 4: storage.Loudness
+	(*TempoKey)(nil),            // 5: storage.TempoKey
+	(*GetWaveformRequest)(nil),  // 6: storage.GetWaveformRequest
+	(*Waveform)(nil),            // 7: storage.Waveform
+	(*CuePoints)(nil),           // 8: storage.CuePoints
+	(*GetCuePointsRequest)(nil), // 9: storage.GetCuePointsRequest
+	(*SetCuePointsRequest)(nil), // 10: storage.SetCuePointsRequest
+	(*Cues)(nil),                // 11: storage.Cues
+	(*FindSimilarRequest)(nil),  // 12: storage.FindSimilarRequest
+	(*SimilarFile)(nil),         // 13: storage.SimilarFile
+	(*FindSimilarResponse)(nil), // 14: storage.FindSimilarResponse
+	(*GetArtworkRequest)(nil),   // 15: storage.GetArtworkRequest
+	(*Artwork)(nil),             // 16: storage.Artwork
+	(*durationpb.Duration)(nil), // 17: google.protobuf.Duration
+}
+var file_storage_media_proto_depIdxs = []int32{
+	17, // 0: storage.Metadata.duration:type_name -> google.protobuf.Duration
+	0,  // 1: storage.Metadata.bitrate_mode:type_name -> storage.BitrateMode
+	4,  // 2: storage.Metadata.loudness:type_name -> storage.Loudness
+	5,  // 3: storage.Metadata.tempo_key:type_name -> storage.TempoKey
+	1,  // 4: storage.GetWaveformRequest.format:type_name -> storage.WaveformFormat
+	17, // 5: storage.CuePoints.start:type_name -> google.protobuf.Duration
+	17, // 6: storage.CuePoints.end:type_name -> google.protobuf.Duration
+	17, // 7: storage.CuePoints.fade_out:type_name -> google.protobuf.Duration
+	17, // 8: storage.CuePoints.intro_end:type_name -> google.protobuf.Duration
+	8,  // 9: storage.SetCuePointsRequest.override:type_name -> storage.CuePoints
+	8,  // 10: storage.Cues.effective:type_name -> storage.CuePoints
+	8,  // 11: storage.Cues.detected:type_name -> storage.CuePoints
+	8,  // 12: storage.Cues.override:type_name -> storage.CuePoints
+	17, // 13: storage.SimilarFile.offset:type_name -> google.protobuf.Duration
+	13, // 14: storage.FindSimilarResponse.files:type_name -> storage.SimilarFile
+	2,  // 15: storage.MediaService.GetMetadata:input_type -> storage.GetMetadataRequest
+	6,  // 16: storage.MediaService.GetWaveform:input_type -> storage.GetWaveformRequest
+	9,  // 17: storage.MediaService.GetCuePoints:input_type -> storage.GetCuePointsRequest
+	10, // 18: storage.MediaService.SetCuePoints:input_type -> storage.SetCuePointsRequest
+	12, // 19: storage.MediaService.FindSimilar:input_type -> storage.FindSimilarRequest
+	15, // 20: storage.MediaService.GetArtwork:input_type -> storage.GetArtworkRequest
+	3,  // 21: storage.MediaService.GetMetadata:output_type -> storage.Metadata
+	7,  // 22: storage.MediaService.GetWaveform:output_type -> storage.Waveform
+	11, // 23: storage.MediaService.GetCuePoints:output_type -> storage.Cues
+	11, // 24: storage.MediaService.SetCuePoints:output_type -> storage.Cues
+	14, // 25: storage.MediaService.FindSimilar:output_type -> storage.FindSimilarResponse
+	16, // 26: storage.MediaService.GetArtwork:output_type -> storage.Artwork
+	21, // [21:27] is the sub-list for method output_type
+	15, // [15:21] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_storage_media_proto_init() }
+func file_storage_media_proto_init() {
+	if File_storage_media_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_storage_media_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*GetMetadataRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Metadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Loudness); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*TempoKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*GetWaveformRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Waveform); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*CuePoints); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*GetCuePointsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*SetCuePointsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*Cues); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*FindSimilarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*SimilarFile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*FindSimilarResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*GetArtworkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_media_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*Artwork); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_storage_media_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_storage_media_proto_goTypes,
+		DependencyIndexes: file_storage_media_proto_depIdxs,
+		EnumInfos:         file_storage_media_proto_enumTypes,
+		MessageInfos:      file_storage_media_proto_msgTypes,
+	}.Build()
+	File_storage_media_proto = out.File
+	file_storage_media_proto_rawDesc = nil
+	file_storage_media_proto_goTypes = nil
+	file_storage_media_proto_depIdxs = nil
+}